@@ -0,0 +1,45 @@
+package kopia
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (KopiaBackend) SupportsCheck() bool {
+	return true
+}
+
+// Check verifies the snapshots of the repository with 'kopia snapshot verify', which checks that the contents of all
+// snapshots are present in the repository, and (except for a quick check) reads a percentage of the files.
+func (KopiaBackend) Check(ctx context.Context, path string, options model.CheckOptions) error {
+
+	dataPercent, err := options.DataPercent()
+	if err != nil {
+		return err
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
+	if err != nil {
+		return err
+	}
+
+	if err := connectKopiaRepository(ctx, config, kopiaCredentials); err != nil {
+		return err
+	}
+
+	verifyDI := newKopiaDirectInvocation(config, []string{
+		"kopia",
+		"snapshot",
+		"verify",
+		"--verify-files-percent=" + strconv.Itoa(dataPercent),
+	})
+
+	return verifyDI.Execute(ctx)
+}
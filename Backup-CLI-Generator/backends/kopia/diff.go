@@ -0,0 +1,170 @@
+package kopia
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+)
+
+func (KopiaBackend) SupportsDiff() bool {
+	return true
+}
+
+// Diff compares two snapshots with 'kopia diff'. Kopia creates a separate snapshot for each source folder, so by default the
+// previous and latest snapshots of each of the config file's folders are compared.
+func (KopiaBackend) Diff(ctx context.Context, path string, options model.DiffOptions) ([]model.SnapshotDiff, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := connectKopiaRepository(ctx, config, kopiaCredentials); err != nil {
+		return nil, err
+	}
+
+	kopiaSnapshots, err := listKopiaSnapshots(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each entry is a pair of snapshots (of a single source) to compare
+	pairs := [][]kopiaSnapshot{}
+
+	if options.From != "" || options.To != "" {
+
+		from, to, err := model.SelectDiffSnapshots(toModelSnapshots(kopiaSnapshots), options)
+		if err != nil {
+			return nil, err
+		}
+
+		pairs = append(pairs, []kopiaSnapshot{findKopiaSnapshot(kopiaSnapshots, from.ID), findKopiaSnapshot(kopiaSnapshots, to.ID)})
+
+	} else {
+
+		processedFolders, err := generate.PopulateProcessedFolders(model.Kopia, config.Folders, config.Substitutions, map[string][]string{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
+		}
+
+		for _, processedFolder := range processedFolders {
+
+			folderSnapshots := []kopiaSnapshot{}
+			for _, kopiaSnapshot := range kopiaSnapshots {
+				if kopiaSnapshot.Source.Path == processedFolder.SrcFolderPath {
+					folderSnapshots = append(folderSnapshots, kopiaSnapshot)
+				}
+			}
+
+			from, to, err := model.SelectDiffSnapshots(toModelSnapshots(folderSnapshots), options)
+			if err != nil {
+				fmt.Printf("Skipping '%s': %v\n", processedFolder.SrcFolderPath, err)
+				continue
+			}
+
+			pairs = append(pairs, []kopiaSnapshot{findKopiaSnapshot(folderSnapshots, from.ID), findKopiaSnapshot(folderSnapshots, to.ID)})
+		}
+
+		if len(pairs) == 0 {
+			return nil, fmt.Errorf("none of the folders have two snapshots to compare")
+		}
+	}
+
+	res := []model.SnapshotDiff{}
+
+	for _, pair := range pairs {
+
+		from, to := pair[0], pair[1]
+
+		diffDI := newKopiaDirectInvocation(config, []string{"kopia", "diff", from.RootEntry.ObjectID, to.RootEntry.ObjectID})
+
+		output, err := diffDI.ExecuteWithOutput(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := model.SnapshotDiff{
+			From:    toModelSnapshots([]kopiaSnapshot{from})[0],
+			To:      toModelSnapshots([]kopiaSnapshot{to})[0],
+			Changes: parseKopiaDiff(output, to.Source.Path),
+		}
+		diff.SortChanges()
+
+		res = append(res, diff)
+	}
+
+	return res, nil
+}
+
+func findKopiaSnapshot(kopiaSnapshots []kopiaSnapshot, id string) kopiaSnapshot {
+	for _, kopiaSnapshot := range kopiaSnapshots {
+		if kopiaSnapshot.ID == id {
+			return kopiaSnapshot
+		}
+	}
+	return kopiaSnapshot{}
+}
+
+var (
+	kopiaDiffAddedFile     = regexp.MustCompile(`^added file (.+) \((\d+) bytes\)$`)
+	kopiaDiffRemovedFile   = regexp.MustCompile(`^removed file (.+) \((\d+) bytes\)$`)
+	kopiaDiffAddedFolder   = regexp.MustCompile(`^added directory (.+)$`)
+	kopiaDiffRemovedFolder = regexp.MustCompile(`^removed directory (.+)$`)
+	kopiaDiffChangedFile   = regexp.MustCompile(`^changed (.+?) at \d{4}-.* \(size (\d+) -> (\d+)\)$`)
+)
+
+// parseKopiaDiff parses the output of 'kopia diff', in which paths are relative to the snapshot root ('./path'); the returned
+// paths are joined with the source path. Other lines (such as mode or modification time differences) are ignored.
+func parseKopiaDiff(output []byte, sourcePath string) []model.DiffEntry {
+
+	res := []model.DiffEntry{}
+
+	toPath := func(relPath string) string {
+		return filepath.Join(sourcePath, filepath.FromSlash(strings.TrimPrefix(relPath, "./")))
+	}
+
+	toSize := func(value string) int64 {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0
+		}
+		return size
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := kopiaDiffAddedFile.FindStringSubmatch(line); match != nil {
+			res = append(res, model.DiffEntry{Path: toPath(match[1]), Change: model.DiffAdded, Size: toSize(match[2])})
+
+		} else if match := kopiaDiffRemovedFile.FindStringSubmatch(line); match != nil {
+			res = append(res, model.DiffEntry{Path: toPath(match[1]), Change: model.DiffRemoved, Size: toSize(match[2])})
+
+		} else if match := kopiaDiffAddedFolder.FindStringSubmatch(line); match != nil {
+			res = append(res, model.DiffEntry{Path: toPath(match[1]), Change: model.DiffAdded, IsDir: true})
+
+		} else if match := kopiaDiffRemovedFolder.FindStringSubmatch(line); match != nil {
+			res = append(res, model.DiffEntry{Path: toPath(match[1]), Change: model.DiffRemoved, IsDir: true})
+
+		} else if match := kopiaDiffChangedFile.FindStringSubmatch(line); match != nil {
+			res = append(res, model.DiffEntry{Path: toPath(match[1]), Change: model.DiffModified, OldSize: toSize(match[2]), Size: toSize(match[3])})
+		}
+	}
+
+	return res
+}
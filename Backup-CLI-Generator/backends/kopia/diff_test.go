@@ -0,0 +1,36 @@
+package kopia
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestParseKopiaDiff(t *testing.T) {
+
+	output := []byte(`added file ./docs/new.txt (12 bytes)
+removed file ./old.txt (5 bytes)
+added directory ./photos
+removed directory ./tmp
+changed ./notes.txt at 2024-05-01 10:00:00.123 +0000 UTC (size 100 -> 120)
+./notes.txt modification times differ:  2024-04-01 10:00:00 +0000 UTC 2024-05-01 10:00:00 +0000 UTC
+`)
+
+	source := filepath.Join(string(filepath.Separator), "home", "me")
+
+	expected := []model.DiffEntry{
+		{Path: filepath.Join(source, "docs", "new.txt"), Change: model.DiffAdded, Size: 12},
+		{Path: filepath.Join(source, "old.txt"), Change: model.DiffRemoved, Size: 5},
+		{Path: filepath.Join(source, "photos"), Change: model.DiffAdded, IsDir: true},
+		{Path: filepath.Join(source, "tmp"), Change: model.DiffRemoved, IsDir: true},
+		{Path: filepath.Join(source, "notes.txt"), Change: model.DiffModified, Size: 120, OldSize: 100},
+	}
+
+	actual := parseKopiaDiff(output, source)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,15 @@
+package kopia
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (KopiaBackend) SupportsFolderPairs() bool {
+	return false
+}
+
+func (KopiaBackend) FolderPairs(path string) ([]model.FolderPair, error) {
+	return nil, fmt.Errorf("unsupported")
+}
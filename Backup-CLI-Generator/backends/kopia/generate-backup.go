@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 
 	"github.com/jgwest/backup-cli/model"
@@ -49,6 +50,18 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		return "", err
 	}
 
+	nodes, err := generateBackupNodesFromConfigFile(configFilePath, config)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.ToString()
+}
+
+// generateBackupNodesFromConfigFile returns the text nodes of the backup script of the config file. Monitor folders are not
+// checked, so that the graph of the nodes can be generated regardless.
+func generateBackupNodesFromConfigFile(configFilePath string, config model.ConfigFile) (*util.TextNodes, error) {
+
 	nodes := util.NewTextNodes()
 
 	cmds.AddGenericPrefixNode(nodes)
@@ -57,7 +70,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	if config.Metadata != nil {
 
 		if config.Metadata.Name == "" {
-			return "", fmt.Errorf("if metadata is specified, then name must be specified")
+			return nil, fmt.Errorf("if metadata is specified, then name must be specified")
 		}
 
 		if config.Metadata.AppendDateTime {
@@ -90,14 +103,14 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 
 			expandedValue, err := util.Expand(exclude, config.Substitutions)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			// TODO: Kopia: This needs to be something different on Windows, probably without the slash
 			excludesNode.SetEnv("EXCLUDES", substring+"--add-ignore \\\""+expandedValue+"\\\"")
 
 			if nodes.IsWindows() {
-				return "", fmt.Errorf("this needs to be something different on Windows, probably without the slash")
+				return nil, fmt.Errorf("this needs to be something different on Windows, probably without the slash")
 			}
 
 		}
@@ -114,7 +127,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		foldersNode := nodes.NewTextNode()
 
 		if len(config.Folders) == 0 {
-			return "", errors.New("at least one folder is required")
+			return nil, errors.New("at least one folder is required")
 		}
 
 		foldersNode.Out("")
@@ -124,7 +137,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		// - This function also updates kopiaPolicyExcludes, if applicable.
 		processedFolders, err := generate.PopulateProcessedFolders(model.Kopia, config.Folders, config.Substitutions, kopiaPolicyExcludes)
 		if err != nil {
-			return "", fmt.Errorf("unable to populateProcessedFolder: %v", err)
+			return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
 		}
 
 		for index, processedFolder := range processedFolders {
@@ -153,7 +166,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	// Uses TODO, BACKUP_DATE_TIME, EXCLUDES, from above
 	invocationNode, err := generateBackupInvocationNode(kopiaPolicyExcludes, config, nodes)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	suffixNode := nodes.NewTextNode()
@@ -162,7 +175,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	suffixNode.Out("backup-cli check \"" + configFilePath + "\" " + suffixNode.Env("SCRIPTPATH"))
 	suffixNode.AddDependency(invocationNode)
 
-	return nodes.ToString()
+	return nodes, nil
 }
 
 func generateBackupInvocationNode(kopiaPolicyExcludes map[string][]string, config model.ConfigFile, textNodes *util.TextNodes) (*util.TextNode, error) {
@@ -199,6 +212,16 @@ func generateBackupInvocationNode(kopiaPolicyExcludes map[string][]string, confi
 
 	textNode.Out(cliInvocation)
 
+	limits, err := config.GetLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Limits != nil {
+		util.AddLimitsScheduleNote(textNode, limits)
+		textNode.Out(strings.Join(kopiaThrottleInvocation(limits.DefaultBandwidth()), " "))
+	}
+
 	if len(config.GlobalExcludes) > 0 {
 		cliInvocation = fmt.Sprintf("kopia policy set --global %s", textNode.Env("EXCLUDES"))
 		textNode.Out(cliInvocation)
@@ -240,7 +263,13 @@ func generateBackupInvocationNode(kopiaPolicyExcludes map[string][]string, confi
 		descriptionSubstring = fmt.Sprintf("--description=\"%s\" ", description)
 	}
 
-	cliInvocation = fmt.Sprintf("kopia snapshot create %s%s",
+	ioPrioritySubstring := ""
+	if prefix := util.IOPriorityCommandPrefix(limits, runtime.GOOS); len(prefix) > 0 {
+		ioPrioritySubstring = strings.Join(prefix, " ") + " "
+	}
+
+	cliInvocation = fmt.Sprintf("%skopia snapshot create %s%s",
+		ioPrioritySubstring,
 		descriptionSubstring,
 		textNode.Env("TODO"))
 
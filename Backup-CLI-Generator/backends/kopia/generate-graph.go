@@ -0,0 +1,20 @@
+package kopia
+
+func (KopiaBackend) SupportsGenerateGraph() bool {
+	return true
+}
+
+func (KopiaBackend) GenerateGraph(path string) (string, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	nodes, err := generateBackupNodesFromConfigFile(path, config)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.ToDOT()
+}
@@ -0,0 +1,96 @@
+package kopia
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (KopiaBackend) SupportsInitRepo() bool {
+	return true
+}
+
+// InitRepo creates the kopia S3 repository with 'kopia repository create s3', unless kopia is able to connect to an existing
+// repository. With ApplyPolicy, the retention and compression policy is set as the global kopia policy of the repository.
+func (KopiaBackend) InitRepo(ctx context.Context, path string, options model.InitRepoOptions) error {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
+	if err != nil {
+		return err
+	}
+
+	policy, err := config.GetPolicy()
+	if err != nil {
+		return err
+	}
+
+	if options.ApplyPolicy && policy == nil {
+		return fmt.Errorf("config file has no 'policy' to apply")
+	}
+
+	if connectErr := connectKopiaRepository(ctx, config, kopiaCredentials); connectErr == nil {
+		fmt.Println("Repository already exists; it was not modified.")
+
+	} else {
+
+		fmt.Printf("Unable to connect to an existing repository (%v): creating it.\n", connectErr)
+
+		// 'kopia repository create' fails if the storage location already contains a repository, so an existing repository that
+		// could not be connected to (for example, due to a wrong password) is never overwritten.
+		createDI := newKopiaS3RepositoryDirectInvocation(config, "create", kopiaCredentials)
+		if err := createDI.Execute(ctx); err != nil {
+			return fmt.Errorf("unable to create the repository: %w", err)
+		}
+
+		fmt.Println("Repository created.")
+	}
+
+	if !options.ApplyPolicy {
+		return nil
+	}
+
+	policyDI := newKopiaDirectInvocation(config, kopiaPolicyInvocation(*policy))
+	if err := policyDI.Execute(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("Policy applied.")
+
+	return nil
+}
+
+// kopiaPolicyInvocation returns the 'kopia policy set --global' invocation of the retention and compression policy. Retention
+// values that are not specified are left unchanged.
+func kopiaPolicyInvocation(policy model.RepositoryPolicy) []string {
+
+	res := []string{"kopia", "policy", "set", "--global"}
+
+	for _, keep := range []struct {
+		arg   string
+		value int
+	}{
+		{"--keep-latest", policy.KeepLast},
+		{"--keep-hourly", policy.KeepHourly},
+		{"--keep-daily", policy.KeepDaily},
+		{"--keep-weekly", policy.KeepWeekly},
+		{"--keep-monthly", policy.KeepMonthly},
+		{"--keep-annual", policy.KeepYearly},
+	} {
+		if keep.value > 0 {
+			res = append(res, keep.arg+"="+strconv.Itoa(keep.value))
+		}
+	}
+
+	if policy.Compression != "" {
+		res = append(res, "--compression="+policy.Compression)
+	}
+
+	return res
+}
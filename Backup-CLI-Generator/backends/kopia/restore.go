@@ -0,0 +1,124 @@
+package kopia
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+	"github.com/jgwest/backup-cli/util/cmds/restore"
+)
+
+func (KopiaBackend) SupportsRestore() bool {
+	return true
+}
+
+// Restore restores the snapshot of each of the config file's folders: kopia creates a separate snapshot for each source
+// folder, so the selector is applied to the snapshots of each folder (except for an ID selector, which identifies a single
+// snapshot).
+func (KopiaBackend) Restore(ctx context.Context, path string, options model.RestoreOptions) error {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
+	if err != nil {
+		return err
+	}
+
+	if err := restore.PrepareTarget(options.Target, options.Force); err != nil {
+		return err
+	}
+
+	if err := connectKopiaRepository(ctx, config, kopiaCredentials); err != nil {
+		return err
+	}
+
+	kopiaSnapshots, err := listKopiaSnapshots(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	// The snapshots to restore
+	selected := []kopiaSnapshot{}
+
+	if options.Snapshot.Type == model.SnapshotSelectID {
+
+		snapshot, err := model.SelectSnapshot(toModelSnapshots(kopiaSnapshots), options.Snapshot)
+		if err != nil {
+			return err
+		}
+
+		for _, kopiaSnapshot := range kopiaSnapshots {
+			if kopiaSnapshot.ID == snapshot.ID {
+				selected = append(selected, kopiaSnapshot)
+			}
+		}
+
+	} else {
+
+		processedFolders, err := generate.PopulateProcessedFolders(model.Kopia, config.Folders, config.Substitutions, map[string][]string{})
+		if err != nil {
+			return fmt.Errorf("unable to populateProcessedFolder: %v", err)
+		}
+
+		for _, processedFolder := range processedFolders {
+
+			folderSnapshots := []kopiaSnapshot{}
+			for _, kopiaSnapshot := range kopiaSnapshots {
+				if kopiaSnapshot.Source.Path == processedFolder.SrcFolderPath {
+					folderSnapshots = append(folderSnapshots, kopiaSnapshot)
+				}
+			}
+
+			snapshot, err := model.SelectSnapshot(toModelSnapshots(folderSnapshots), options.Snapshot)
+			if err != nil {
+				return fmt.Errorf("%s: %w", processedFolder.SrcFolderPath, err)
+			}
+
+			for _, kopiaSnapshot := range folderSnapshots {
+				if kopiaSnapshot.ID == snapshot.ID {
+					selected = append(selected, kopiaSnapshot)
+				}
+			}
+		}
+	}
+
+	restored := 0
+
+	for _, snapshot := range selected {
+
+		for _, relPath := range restore.RelativePathsUnder(snapshot.Source.Path, options.Paths) {
+
+			fmt.Printf("Restoring snapshot %s of '%s' (%s)\n", snapshot.ID, snapshot.Source.Path, snapshot.StartTime.Format(time.RFC3339))
+
+			// A path within a snapshot is identified by the root object ID, followed by the relative path
+			objectPath := snapshot.RootEntry.ObjectID
+			if relPath != "." {
+				objectPath += "/" + filepath.ToSlash(relPath)
+			}
+
+			restoreDI := newKopiaDirectInvocation(config, []string{
+				"kopia", "snapshot", "restore",
+				objectPath,
+				restore.TargetPath(options.Target, filepath.Join(snapshot.Source.Path, relPath)),
+			})
+
+			if err := restoreDI.Execute(ctx); err != nil {
+				return err
+			}
+
+			restored++
+		}
+	}
+
+	if restored == 0 {
+		return fmt.Errorf("none of the paths are contained in the selected snapshots")
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package kopia
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (KopiaBackend) SupportsRotateKey() bool {
+	return true
+}
+
+// AddKey changes the password of the repository with 'kopia repository change-password' (kopia repositories have a single
+// password), then verifies it by connecting to the repository with the new password.
+func (KopiaBackend) AddKey(ctx context.Context, path string, newPassword string) (model.KeyRotation, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
+	if err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	if err := connectKopiaRepository(ctx, config, kopiaCredentials); err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	// The new password is passed in the environment variable that kopia reads it from, so that it is not visible to other processes
+	changePasswordDI := newKopiaDirectInvocation(config, []string{"kopia", "repository", "change-password"})
+	changePasswordDI.EnvironmentVariables["KOPIA_NEW_PASSWORD"] = newPassword
+	if err := changePasswordDI.Execute(ctx); err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	newKopiaCredentials := *kopiaCredentials
+	newKopiaCredentials.Password = newPassword
+
+	if err := connectKopiaRepository(ctx, config, &newKopiaCredentials); err != nil {
+		return model.KeyRotation{}, fmt.Errorf("unable to connect to the repository with the new password: %w", err)
+	}
+
+	fmt.Println("Changed the repository password.")
+
+	return model.KeyRotation{}, nil
+}
+
+// RemoveOldKey has nothing to remove, as the kopia password is changed in place.
+func (KopiaBackend) RemoveOldKey(ctx context.Context, path string, rotation model.KeyRotation) error {
+	return nil
+}
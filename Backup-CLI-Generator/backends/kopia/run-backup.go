@@ -1,7 +1,10 @@
 package kopia
 
 import (
+	"context"
 	"fmt"
+	"runtime"
+	"time"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -13,18 +16,32 @@ func (KopiaBackend) SupportsBackup() bool {
 	return true
 }
 
-func (KopiaBackend) Backup(path string, rehashSource bool) error {
+func (KopiaBackend) Backup(ctx context.Context, path string, options model.BackupOptions) error {
 
-	if rehashSource {
+	if options.RehashSource {
 		return fmt.Errorf("unsupported flag: rehash source")
 	}
 
+	if options.Parallel > 1 {
+		return fmt.Errorf("unsupported flag: parallel")
+	}
+
+	if options.Resume {
+		return fmt.Errorf("unsupported flag: resume")
+	}
+
 	config, err := extractAndValidateConfigFile(path)
 	if err != nil {
 		return err
 	}
 
-	if err := runBackupFromConfigFile(path, config); err != nil {
+	ctx, endBackup, err := runbackup.BeginBackup(ctx, path, config, options)
+	if err != nil {
+		return err
+	}
+	defer endBackup()
+
+	if err := runBackupFromConfigFile(ctx, path, config); err != nil {
 		return err
 	}
 
@@ -32,7 +49,7 @@ func (KopiaBackend) Backup(path string, rehashSource bool) error {
 
 }
 
-func runBackupFromConfigFile(configFilePath string, config model.ConfigFile) error {
+func runBackupFromConfigFile(ctx context.Context, configFilePath string, config model.ConfigFile) error {
 
 	res := runbackup.BackupRunObject{}
 
@@ -83,7 +100,7 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile) err
 	}
 
 	// Uses TODO, BACKUP_DATE_TIME, EXCLUDES, from above
-	if err := executeBackupInvocation(kopiaPolicyExcludes, config, res); err != nil {
+	if err := executeBackupInvocation(ctx, kopiaPolicyExcludes, config, res); err != nil {
 		return err
 	}
 
@@ -94,34 +111,27 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile) err
 	return nil
 }
 
-func executeBackupInvocation(kopiaPolicyExcludes map[string][]string, config model.ConfigFile, input runbackup.BackupRunObject) error {
+func executeBackupInvocation(ctx context.Context, kopiaPolicyExcludes map[string][]string, config model.ConfigFile, input runbackup.BackupRunObject) error {
 
 	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
 	if err != nil {
 		return err
 	}
 
-	// Connect the repository
-	{
-		repositoryConnectInvocation := []string{
-			"kopia",
-			"repository",
-			"connect",
-			"s3",
-			"--bucket=" + kopiaCredentials.KopiaS3.Bucket,
-			"--access-key=" + kopiaCredentials.S3.AccessKeyID,
-			"--secret-access-key=" + kopiaCredentials.S3.SecretAccessKey,
-			"--password=" + kopiaCredentials.Password,
-			"--endpoint=" + kopiaCredentials.KopiaS3.Endpoint,
-			"--region=" + kopiaCredentials.KopiaS3.Region,
-		}
+	if err := connectKopiaRepository(ctx, config, kopiaCredentials); err != nil {
+		return err
+	}
 
-		repositoryConnectDI := util.DirectInvocation{
-			Args:                 repositoryConnectInvocation,
-			EnvironmentVariables: map[string]string{},
-		}
+	limits, err := config.GetLimits()
+	if err != nil {
+		return err
+	}
+
+	// Set the repository throttle, to the limit that applies at the start of the backup
+	if config.Limits != nil {
+		throttleDI := newKopiaDirectInvocation(config, kopiaThrottleInvocation(limits.BandwidthAt(time.Now())))
 
-		if err := repositoryConnectDI.Execute(); err != nil {
+		if err := throttleDI.Execute(ctx); err != nil {
 			return err
 		}
 	}
@@ -139,12 +149,9 @@ func executeBackupInvocation(kopiaPolicyExcludes map[string][]string, config mod
 			excludePolicyInvocation = append(excludePolicyInvocation, "--add-ignore", globalExcludedFolder)
 		}
 
-		setPolicyDI := util.DirectInvocation{
-			Args:                 excludePolicyInvocation,
-			EnvironmentVariables: map[string]string{},
-		}
+		setPolicyDI := newKopiaDirectInvocation(config, excludePolicyInvocation)
 
-		if err := setPolicyDI.Execute(); err != nil {
+		if err := setPolicyDI.Execute(ctx); err != nil {
 			return err
 		}
 
@@ -171,12 +178,9 @@ func executeBackupInvocation(kopiaPolicyExcludes map[string][]string, config mod
 
 			cliInvocation = append(cliInvocation, backupPath)
 
-			localPolicyDI := util.DirectInvocation{
-				Args:                 cliInvocation,
-				EnvironmentVariables: map[string]string{},
-			}
+			localPolicyDI := newKopiaDirectInvocation(config, cliInvocation)
 
-			if err := localPolicyDI.Execute(); err != nil {
+			if err := localPolicyDI.Execute(ctx); err != nil {
 				return err
 			}
 
@@ -196,17 +200,14 @@ func executeBackupInvocation(kopiaPolicyExcludes map[string][]string, config mod
 		descriptionSubstring = append(descriptionSubstring, "--description="+description)
 	}
 
-	createsSnaphotDI := []string{
-		"kopia", "snapshot", "create",
-	}
+	createsSnaphotDI := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+
+	createsSnaphotDI = append(createsSnaphotDI, "kopia", "snapshot", "create")
 
 	createsSnaphotDI = append(createsSnaphotDI, descriptionSubstring...)
 	createsSnaphotDI = append(createsSnaphotDI, input.Todo...)
 
-	directionInvocation := util.DirectInvocation{
-		Args:                 createsSnaphotDI,
-		EnvironmentVariables: map[string]string{},
-	}
+	directionInvocation := newKopiaDirectInvocation(config, createsSnaphotDI)
 
-	return directionInvocation.Execute()
+	return directionInvocation.Execute(ctx)
 }
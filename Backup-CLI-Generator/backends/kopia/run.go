@@ -1,6 +1,7 @@
 package kopia
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -8,6 +9,6 @@ func (KopiaBackend) SupportsRun() bool {
 	return false
 }
 
-func (KopiaBackend) Run(path string, args []string) error {
+func (KopiaBackend) Run(ctx context.Context, path string, args []string) error {
 	return fmt.Errorf("unsupported")
 }
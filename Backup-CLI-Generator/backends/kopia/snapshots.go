@@ -0,0 +1,98 @@
+package kopia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (KopiaBackend) SupportsSnapshots() bool {
+	return true
+}
+
+func (KopiaBackend) Snapshots(ctx context.Context, path string) ([]model.Snapshot, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kopiaCredentials, err := getAndValidateKopiaCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := connectKopiaRepository(ctx, config, kopiaCredentials); err != nil {
+		return nil, err
+	}
+
+	kopiaSnapshots, err := listKopiaSnapshots(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelSnapshots(kopiaSnapshots), nil
+}
+
+// kopiaSnapshot is an entry of the output of 'kopia snapshot list --json'.
+type kopiaSnapshot struct {
+	ID     string `json:"id"`
+	Source struct {
+		Host     string `json:"host"`
+		UserName string `json:"userName"`
+		Path     string `json:"path"`
+	} `json:"source"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"startTime"`
+	Stats       struct {
+		TotalSize int64 `json:"totalSize"`
+	} `json:"stats"`
+	RootEntry struct {
+		ObjectID string `json:"obj"`
+	} `json:"rootEntry"`
+}
+
+// listKopiaSnapshots returns the snapshots of all sources of the connected repository.
+func listKopiaSnapshots(ctx context.Context, config model.ConfigFile) ([]kopiaSnapshot, error) {
+
+	listDI := newKopiaDirectInvocation(config, []string{"kopia", "snapshot", "list", "--all", "--json"})
+
+	output, err := listDI.ExecuteWithOutput(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []kopiaSnapshot{}
+	if err := json.Unmarshal(output, &res); err != nil {
+		return nil, fmt.Errorf("unable to parse kopia snapshot list: %w", err)
+	}
+
+	return res, nil
+}
+
+func toModelSnapshots(kopiaSnapshots []kopiaSnapshot) []model.Snapshot {
+
+	res := []model.Snapshot{}
+
+	for _, kopiaSnapshot := range kopiaSnapshots {
+
+		snapshot := model.Snapshot{
+			ID:    kopiaSnapshot.ID,
+			Time:  kopiaSnapshot.StartTime,
+			Host:  kopiaSnapshot.Source.Host,
+			Paths: []string{kopiaSnapshot.Source.Path},
+			Size:  kopiaSnapshot.Stats.TotalSize,
+		}
+
+		if kopiaSnapshot.Description != "" {
+			snapshot.Tags = []string{kopiaSnapshot.Description}
+		}
+
+		res = append(res, snapshot)
+	}
+
+	return res
+}
@@ -1,9 +1,12 @@
 package kopia
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
 )
 
 func getAndValidateKopiaCredentials(config model.ConfigFile) (*model.KopiaCredentials, error) {
@@ -53,3 +56,83 @@ func extractAndValidateConfigFile(path string) (model.ConfigFile, error) {
 
 	return config, nil
 }
+
+// newKopiaDirectInvocation returns a kopia invocation with the given arguments, which uses the retry policy of the config file.
+func newKopiaDirectInvocation(config model.ConfigFile, args []string) util.DirectInvocation {
+	return util.DirectInvocation{
+		Args:                 args,
+		EnvironmentVariables: map[string]string{},
+		Retry:                config.Retry,
+		IsRetryable:          isRetryableKopiaFailure,
+	}
+}
+
+// connectKopiaRepository connects kopia to the S3 repository of the config file.
+func connectKopiaRepository(ctx context.Context, config model.ConfigFile, kopiaCredentials *model.KopiaCredentials) error {
+
+	repositoryConnectDI := newKopiaS3RepositoryDirectInvocation(config, "connect", kopiaCredentials)
+
+	return repositoryConnectDI.Execute(ctx)
+}
+
+// newKopiaS3RepositoryDirectInvocation returns the 'kopia repository <command> s3' invocation (e.g. 'connect' or 'create') for
+// the S3 repository of the credentials. The keys and password are passed in the environment variables that kopia reads them
+// from, rather than as arguments, so that they are not visible to other processes.
+func newKopiaS3RepositoryDirectInvocation(config model.ConfigFile, command string, kopiaCredentials *model.KopiaCredentials) util.DirectInvocation {
+
+	di := newKopiaDirectInvocation(config, []string{
+		"kopia",
+		"repository",
+		command,
+		"s3",
+		"--bucket=" + kopiaCredentials.KopiaS3.Bucket,
+		"--endpoint=" + kopiaCredentials.KopiaS3.Endpoint,
+		"--region=" + kopiaCredentials.KopiaS3.Region,
+	})
+
+	di.EnvironmentVariables["AWS_ACCESS_KEY_ID"] = kopiaCredentials.S3.AccessKeyID
+	di.EnvironmentVariables["AWS_SECRET_ACCESS_KEY"] = kopiaCredentials.S3.SecretAccessKey
+	di.EnvironmentVariables["KOPIA_PASSWORD"] = kopiaCredentials.Password
+
+	return di
+}
+
+// kopiaThrottleInvocation returns the kopia invocation that sets the (persistent) throttle of the connected repository to
+// the bandwidth limit; kopia's limits are in bytes per second.
+func kopiaThrottleInvocation(limit model.BandwidthLimit) []string {
+
+	bytesPerSecond := func(kibps int) string {
+		if kibps == 0 {
+			return "unlimited"
+		}
+		return strconv.Itoa(kibps * 1024)
+	}
+
+	return []string{
+		"kopia", "repository", "throttle", "set",
+		"--upload-bytes-per-second=" + bytesPerSecond(limit.UploadKiBps),
+		"--download-bytes-per-second=" + bytesPerSecond(limit.DownloadKiBps),
+	}
+}
+
+// kopiaPermanentErrorPatterns are stderr substrings of failures that will not succeed on retry, such as authentication failures.
+var kopiaPermanentErrorPatterns = []string{
+	"invalid repository password",
+	"AccessDenied",
+	"Access Denied",
+	"InvalidAccessKeyId",
+	"SignatureDoesNotMatch",
+	"repository not initialized",
+	"NoSuchBucket",
+}
+
+// isRetryableKopiaFailure returns true if the kopia failure is transient (for example, a network error), rather than
+// permanent (for example, an authentication failure).
+func isRetryableKopiaFailure(exitCode int, stderr string) bool {
+
+	if util.ContainsAny(stderr, kopiaPermanentErrorPatterns) {
+		return false
+	}
+
+	return util.ContainsAny(stderr, util.TransientNetworkErrorPatterns)
+}
@@ -0,0 +1,105 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	runbackup "github.com/jgwest/backup-cli/util/cmds/run-backup"
+)
+
+func (RcloneBackend) SupportsCheck() bool {
+	return true
+}
+
+// Check compares each source folder with its destination folder using 'rclone check': a quick check compares file sizes,
+// a standard check compares sizes and hashes (where both sides support a common hash), and a deep check downloads the
+// destination files and compares their content.
+func (RcloneBackend) Check(ctx context.Context, path string, options model.CheckOptions) error {
+
+	dataPercent, err := options.DataPercent()
+	if err != nil {
+		return err
+	}
+
+	if options.Level == model.CheckLevelDeep && dataPercent != 100 {
+		return fmt.Errorf("rclone does not support reading a percentage of the data")
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	rcloneFolders, err := rcloneFolderPairs(config)
+	if err != nil {
+		return err
+	}
+
+	limits, err := config.GetLimits()
+	if err != nil {
+		return err
+	}
+
+	concurrency, err := config.GetConcurrency(model.BackupOptions{})
+	if err != nil {
+		return err
+	}
+
+	switches := []string{}
+
+	switch options.Level {
+	case model.CheckLevelQuick:
+		switches = append(switches, "--size-only")
+	case model.CheckLevelDeep:
+		switches = append(switches, "--download")
+	}
+
+	if bwlimit := rcloneBandwidthLimitArg(limits); bwlimit != "" {
+		switches = append(switches, "--bwlimit", bwlimit)
+	}
+
+	// The same files are excluded as by the backup (which deletes excluded files from the destination)
+	for _, exclude := range config.GlobalExcludes {
+
+		expandedValue, err := util.Expand(exclude, config.Substitutions)
+		if err != nil {
+			return err
+		}
+
+		switches = append(switches, "--exclude", expandedValue)
+	}
+
+	jobs := []runbackup.FolderJob{}
+
+	for _, folderTuple := range rcloneFolders {
+
+		cliInvocation := []string{"rclone", "check", folderTuple.source, folderTuple.dest}
+		cliInvocation = append(cliInvocation, switches...)
+
+		jobs = append(jobs, runbackup.FolderJob{
+			Source: folderTuple.source,
+			Dest:   folderTuple.dest,
+			Run: func(outputPrefix string) error {
+
+				rcloneDI := util.DirectInvocation{
+					Args:                 cliInvocation,
+					EnvironmentVariables: map[string]string{},
+					OutputPrefix:         outputPrefix,
+					Retry:                config.Retry,
+					IsRetryable:          isRetryableRcloneFailure,
+				}
+
+				return rcloneDI.Execute(ctx)
+			},
+		})
+	}
+
+	runResult := runbackup.ExecuteFolderJobs(jobs, concurrency)
+
+	runResult.PrintSummary(os.Stdout)
+
+	return runResult.FailureError("check")
+}
@@ -0,0 +1,22 @@
+package rclone
+
+import (
+	"context"
+
+	"github.com/jgwest/backup-cli/model"
+	runbackup "github.com/jgwest/backup-cli/util/cmds/run-backup"
+)
+
+func (RcloneBackend) SupportsDiff() bool {
+	return true
+}
+
+// Diff compares the manifests of the source folders that were recorded after two successful backups.
+func (RcloneBackend) Diff(ctx context.Context, path string, options model.DiffOptions) ([]model.SnapshotDiff, error) {
+
+	if _, err := extractAndValidateConfigFile(path); err != nil {
+		return nil, err
+	}
+
+	return runbackup.DiffManifestSnapshots(path, options)
+}
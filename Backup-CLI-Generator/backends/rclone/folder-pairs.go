@@ -0,0 +1,57 @@
+package rclone
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+)
+
+func (RcloneBackend) SupportsFolderPairs() bool {
+	return true
+}
+
+func (RcloneBackend) FolderPairs(path string) ([]model.FolderPair, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rcloneFolders, err := rcloneFolderPairs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []model.FolderPair{}
+	for _, rcloneFolder := range rcloneFolders {
+		res = append(res, model.FolderPair{Source: rcloneFolder.source, Dest: rcloneFolder.dest})
+	}
+
+	return res, nil
+}
+
+// rcloneFolderPairs returns the source folders of the config file, and the destination folder of each (with the basename of
+// the source folder appended). Example:
+// - [C:\Users] -> [B:\backup\C-Users]
+// - [D:\Users] -> [B:\backup\D-Users]
+// - [C:\To-Backup] -> [B:\backup\To-Backup]
+func rcloneFolderPairs(config model.ConfigFile) ([]sourceToDestFolder, error) {
+
+	processedFolders, err := generate.PopulateProcessedFolders(model.Rclone, config.Folders, config.Substitutions, map[string][]string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
+	}
+
+	// Ensure that none of the folders share a basename
+	if err := rcloneValidateBasenames(processedFolders); err != nil {
+		return nil, err
+	}
+
+	rcloneCredentials, err := config.GetRcloneCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	return rcloneGenerateTargetPaths(processedFolders, rcloneCredentials)
+}
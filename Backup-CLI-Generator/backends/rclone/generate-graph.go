@@ -0,0 +1,13 @@
+package rclone
+
+import (
+	"fmt"
+)
+
+func (RcloneBackend) SupportsGenerateGraph() bool {
+	return false
+}
+
+func (RcloneBackend) GenerateGraph(path string) (string, error) {
+	return "", fmt.Errorf("unsupported")
+}
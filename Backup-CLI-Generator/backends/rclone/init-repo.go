@@ -0,0 +1,16 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (RcloneBackend) SupportsInitRepo() bool {
+	return false
+}
+
+func (RcloneBackend) InitRepo(ctx context.Context, path string, options model.InitRepoOptions) error {
+	return fmt.Errorf("unsupported")
+}
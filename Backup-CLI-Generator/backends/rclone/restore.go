@@ -0,0 +1,87 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/restore"
+)
+
+func (RcloneBackend) SupportsRestore() bool {
+	return true
+}
+
+// Restore copies the folders back from the destination folder: as rclone mirrors the source folders, only the latest
+// state of each folder is available.
+func (RcloneBackend) Restore(ctx context.Context, path string, options model.RestoreOptions) error {
+
+	if options.Snapshot.Type != model.SnapshotSelectLatest {
+		return fmt.Errorf("rclone only supports restoring the latest snapshot")
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	rcloneFolders, err := rcloneFolderPairs(config)
+	if err != nil {
+		return err
+	}
+
+	limits, err := config.GetLimits()
+	if err != nil {
+		return err
+	}
+
+	if err := restore.PrepareTarget(options.Target, options.Force); err != nil {
+		return err
+	}
+
+	restored := 0
+
+	for _, folderTuple := range rcloneFolders {
+
+		for _, relPath := range restore.RelativePathsUnder(folderTuple.source, options.Paths) {
+
+			// 'copyto' copies either a file or a folder, to a file or folder of the given name
+			args := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+			args = append(args,
+				"rclone",
+				"copyto",
+				filepath.Join(folderTuple.dest, relPath),
+				restore.TargetPath(options.Target, filepath.Join(folderTuple.source, relPath)),
+				"--progress",
+				"--create-empty-src-dirs",
+				"--transfers", "8",
+			)
+
+			if bwlimit := rcloneBandwidthLimitArg(limits); bwlimit != "" {
+				args = append(args, "--bwlimit", bwlimit)
+			}
+
+			rcloneDI := util.DirectInvocation{
+				Args:                 args,
+				EnvironmentVariables: map[string]string{},
+				Retry:                config.Retry,
+				IsRetryable:          isRetryableRcloneFailure,
+			}
+
+			if err := rcloneDI.Execute(ctx); err != nil {
+				return err
+			}
+
+			restored++
+		}
+	}
+
+	if restored == 0 {
+		return fmt.Errorf("none of the paths are contained in the backed up folders")
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (RcloneBackend) SupportsRotateKey() bool {
+	return false
+}
+
+func (RcloneBackend) AddKey(ctx context.Context, path string, newPassword string) (model.KeyRotation, error) {
+	return model.KeyRotation{}, fmt.Errorf("unsupported")
+}
+
+func (RcloneBackend) RemoveOldKey(ctx context.Context, path string, rotation model.KeyRotation) error {
+	return fmt.Errorf("unsupported")
+}
@@ -1,10 +1,13 @@
 package rclone
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -16,14 +19,20 @@ func (RcloneBackend) SupportsBackup() bool {
 	return true
 }
 
-func (RcloneBackend) Backup(path string, rehashSource bool) error {
+func (RcloneBackend) Backup(ctx context.Context, path string, options model.BackupOptions) error {
 
 	config, err := extractAndValidateConfigFile(path)
 	if err != nil {
 		return err
 	}
 
-	if err := runBackupFromConfigFile(path, config, rehashSource); err != nil {
+	ctx, endBackup, err := runbackup.BeginBackup(ctx, path, config, options)
+	if err != nil {
+		return err
+	}
+	defer endBackup()
+
+	if err := runBackupFromConfigFile(ctx, path, config, options); err != nil {
 		return err
 	}
 
@@ -38,7 +47,7 @@ type sourceToDestFolder struct {
 	dest string
 }
 
-func runBackupFromConfigFile(configFilePath string, config model.ConfigFile, rehashSource bool) error {
+func runBackupFromConfigFile(ctx context.Context, configFilePath string, config model.ConfigFile, options model.BackupOptions) error {
 
 	res := runbackup.BackupRunObject{}
 
@@ -56,46 +65,12 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile, reh
 
 	}
 
-	// rcloneFolders contains a slice of:
-	// - source folder path
-	// - destination folder (with basename of source folder appended)
-	// Example:
-	// - [C:\Users] -> [B:\backup\C-Users]
-	// - [D:\Users] -> [B:\backup\D-Users]
-	// - [C:\To-Backup] -> [B:\backup\To-Backup]
-	var rcloneFolders []sourceToDestFolder
-
-	// Process folders
-	// - Populate TODO env var, for everything except robocopy
-	// - For robocopy, populate rcloneFolders
-	{
-
-		// processFolder is a slice of: [string (path to backup), model.Folder (folder object)]
-		// - This function also updates kopiaPolicyExcludes, if applicable.
-		processedFolders, err := generate.PopulateProcessedFolders(model.Rclone, config.Folders, config.Substitutions, map[string][]string{})
-		if err != nil {
-			return fmt.Errorf("unable to populateProcessedFolder: %v", err)
-		}
-
-		// Ensure that none of the folders share a basename
-		if err := rcloneValidateBasenames(processedFolders); err != nil {
-			return err
-		}
-
-		if rcloneCredentials, err := config.GetRcloneCredential(); err == nil {
-
-			rcloneFolders, err = rcloneGenerateTargetPaths(processedFolders, rcloneCredentials)
-			if err != nil {
-				return err
-			}
-
-		} else {
-			return err
-		}
-
+	rcloneFolders, err := rcloneFolderPairs(config)
+	if err != nil {
+		return err
 	}
 
-	if err := executeBackupInvocation(config, rcloneFolders, res, rehashSource); err != nil {
+	if err := executeBackupInvocation(ctx, configFilePath, config, rcloneFolders, res, options); err != nil {
 		return err
 	}
 
@@ -106,7 +81,7 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile, reh
 	return nil
 }
 
-func executeBackupInvocation(config model.ConfigFile, rcloneFolders []sourceToDestFolder, input runbackup.BackupRunObject, rehashSource bool) error {
+func executeBackupInvocation(ctx context.Context, configFilePath string, config model.ConfigFile, rcloneFolders []sourceToDestFolder, input runbackup.BackupRunObject, options model.BackupOptions) error {
 
 	// rcloneCredentials, err := getAndValidateRcloneCredentials(config)
 	// if err != nil {
@@ -114,6 +89,22 @@ func executeBackupInvocation(config model.ConfigFile, rcloneFolders []sourceToDe
 	// }
 	switches := []string{}
 
+	concurrency, err := config.GetConcurrency(options)
+	if err != nil {
+		return err
+	}
+
+	limits, err := config.GetLimits()
+	if err != nil {
+		return err
+	}
+
+	if bwlimit := rcloneBandwidthLimitArg(limits); bwlimit != "" {
+		switches = append(switches, "--bwlimit", bwlimit)
+	}
+
+	jobs := []runbackup.FolderJob{}
+
 	for _, folderTuple := range rcloneFolders {
 
 		srcFolder, destFolder := folderTuple.source, folderTuple.dest
@@ -123,16 +114,25 @@ func executeBackupInvocation(config model.ConfigFile, rcloneFolders []sourceToDe
 			"sync",
 			srcFolder,
 			destFolder,
-			"--progress",
+		}
+
+		// The interactive progress display is not usable when multiple folders are output at once
+		if concurrency > 1 {
+			cliInvocation = append(cliInvocation, "--stats-one-line", "--stats", "30s")
+		} else {
+			cliInvocation = append(cliInvocation, "--progress")
+		}
+
+		cliInvocation = append(cliInvocation,
 			// "--dry-run",
 			"--create-empty-src-dirs",
 			"--ignore-errors",
 			// "--max-delete", "1000",
 			"--transfers", "8",
 			"--delete-excluded",
-		}
+		)
 
-		if rehashSource {
+		if options.RehashSource {
 			cliInvocation = append(cliInvocation, "--checksum")
 		}
 
@@ -142,16 +142,112 @@ func executeBackupInvocation(config model.ConfigFile, rcloneFolders []sourceToDe
 			cliInvocation = append(cliInvocation, "--exclude", globalExclude)
 		}
 
-		robocopyDI := util.DirectInvocation{
-			Args:                 cliInvocation,
-			EnvironmentVariables: map[string]string{},
+		jobs = append(jobs, runbackup.FolderJob{
+			Source: srcFolder,
+			Dest:   destFolder,
+			Run: func(outputPrefix string) error {
+
+				if err := validateSyncInvocation(cliInvocation); err != nil {
+					return err
+				}
+
+				args := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+				args = append(args, cliInvocation...)
+
+				rcloneDI := util.DirectInvocation{
+					Args:                 args,
+					EnvironmentVariables: map[string]string{},
+					OutputPrefix:         outputPrefix,
+					Retry:                config.Retry,
+					IsRetryable:          isRetryableRcloneFailure,
+				}
+
+				return rcloneDI.Execute(ctx)
+			},
+		})
+
+	}
+
+	// On failure, the error is recorded and the remaining folders are still backed up
+	runResult, err := runbackup.ExecuteFolderJobsWithCheckpoint(configFilePath, jobs, concurrency, options)
+	if err != nil {
+		return err
+	}
+
+	runResult.PrintSummary(os.Stdout)
+
+	if err := runResult.Error(); err != nil {
+		return err
+	}
+
+	sourceFolders := []string{}
+	for _, folderTuple := range rcloneFolders {
+		sourceFolders = append(sourceFolders, folderTuple.source)
+	}
+
+	// The manifest is compared with those of other backups by 'diff'
+	if err := runbackup.RecordManifest(configFilePath, sourceFolders, util.NewExcludeMatcher(input.GlobalExcludes)); err != nil {
+		fmt.Println("Warning: unable to record the backup manifest:", err)
+	}
+
+	return nil
+}
+
+// rcloneExitCodeTemporaryError is the rclone exit code for temporary errors, which may succeed on retry
+const rcloneExitCodeTemporaryError = 5
+
+// isRetryableRcloneFailure returns true if the rclone failure is transient.
+func isRetryableRcloneFailure(exitCode int, stderr string) bool {
+	return exitCode == rcloneExitCodeTemporaryError || util.ContainsAny(stderr, util.TransientNetworkErrorPatterns)
+}
+
+// rcloneBandwidthLimitArg returns the rclone '--bwlimit' value for the limits, or "" if unlimited. Each limit is
+// 'UP:DOWN' (where rclone's 'K' suffix is KiB/s); if the limits have a schedule, rclone's timetable syntax is used, so
+// that rclone changes the limit as each window starts and ends.
+func rcloneBandwidthLimitArg(limits model.Limits) string {
+
+	formatLimit := func(limit model.BandwidthLimit) string {
+		formatKiBps := func(kibps int) string {
+			if kibps == 0 {
+				return "off"
+			}
+			return fmt.Sprintf("%dK", kibps)
 		}
+		return formatKiBps(limit.UploadKiBps) + ":" + formatKiBps(limit.DownloadKiBps)
+	}
 
-		if err := robocopyDI.Execute(); err != nil {
-			fmt.Println("ERROR:", err)
-			continue
+	if len(limits.Schedule) == 0 {
+		if limits.DefaultBandwidth() == (model.BandwidthLimit{}) {
+			return ""
 		}
+		return formatLimit(limits.DefaultBandwidth())
+	}
+
+	timetable := []string{}
+	for _, change := range limits.BandwidthTimetable() {
+		timetable = append(timetable, fmt.Sprintf("%02d:%02d,%s", change.Minute/60, change.Minute%60, formatLimit(change.Limit)))
+	}
+
+	return strings.Join(timetable, " ")
+}
+
+// validateSyncInvocation ensures that the rclone invocation is a sync from a non-backup drive, to a backup drive.
+func validateSyncInvocation(args []string) error {
+
+	if len(args) < 4 || args[1] != "sync" {
+		return fmt.Errorf("arg 2 should be sync")
+	}
+
+	srcArg := args[2]
+	if strings.HasPrefix(strings.ToLower(srcArg), "b:") ||
+		strings.HasPrefix(strings.ToLower(srcArg), "m:") {
+		return fmt.Errorf("arg 2 should not be a backup drive")
+	}
 
+	destArg := args[3]
+	if !strings.HasPrefix(strings.ToLower(destArg), "b:") &&
+		!strings.HasPrefix(strings.ToLower(destArg), "m:") {
+		return fmt.Errorf("arg 3 should be a backup drive")
 	}
 
 	return nil
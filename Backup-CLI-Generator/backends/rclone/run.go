@@ -1,6 +1,7 @@
 package rclone
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -8,6 +9,6 @@ func (RcloneBackend) SupportsRun() bool {
 	return false
 }
 
-func (RcloneBackend) Run(path string, args []string) error {
+func (RcloneBackend) Run(ctx context.Context, path string, args []string) error {
 	return fmt.Errorf("unsupported")
 }
@@ -0,0 +1,16 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (RcloneBackend) SupportsSnapshots() bool {
+	return false
+}
+
+func (RcloneBackend) Snapshots(ctx context.Context, path string) ([]model.Snapshot, error) {
+	return nil, fmt.Errorf("unsupported")
+}
@@ -0,0 +1,59 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (ResticBackend) SupportsCheck() bool {
+	return true
+}
+
+func (ResticBackend) Check(ctx context.Context, path string, options model.CheckOptions) error {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	return executeCheck(ctx, config, options)
+
+}
+
+func executeCheck(ctx context.Context, config model.ConfigFile, options model.CheckOptions) error {
+
+	checkArgs, err := resticCheckArgs(options)
+	if err != nil {
+		return err
+	}
+
+	invocParams, err := generateResticDirectInvocation(config)
+	if err != nil {
+		return err
+	}
+
+	invocParams.Args = append(invocParams.Args, checkArgs...)
+
+	return invocParams.Execute(ctx)
+}
+
+// resticCheckArgs returns the 'restic check' arguments for the check level: a quick check only checks the repository
+// structure, while the other levels also read all (--read-data), or a random subset (--read-data-subset), of the data.
+func resticCheckArgs(options model.CheckOptions) ([]string, error) {
+
+	dataPercent, err := options.DataPercent()
+	if err != nil {
+		return nil, err
+	}
+
+	switch dataPercent {
+	case 0:
+		return []string{"check"}, nil
+	case 100:
+		return []string{"check", "--read-data"}, nil
+	}
+
+	return []string{"check", fmt.Sprintf("--read-data-subset=%d%%", dataPercent)}, nil
+}
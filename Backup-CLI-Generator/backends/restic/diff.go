@@ -0,0 +1,196 @@
+package restic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (ResticBackend) SupportsDiff() bool {
+	return true
+}
+
+// Diff compares two snapshots with 'restic diff'; as restic does not report the sizes of the changed files, they are read
+// from the file lists of the snapshots ('restic ls').
+func (ResticBackend) Diff(ctx context.Context, path string, options model.DiffOptions) ([]model.SnapshotDiff, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := listResticSnapshots(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := model.SelectDiffSnapshots(snapshots, options)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := executeResticWithOutput(ctx, config, "diff", "--json", from.ID, to.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := parseResticDiff(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+
+		fromSizes, err := listResticFileSizes(ctx, config, from.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		toSizes, err := listResticFileSizes(ctx, config, to.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for index := range changes {
+			change := &changes[index]
+			switch change.Change {
+			case model.DiffAdded:
+				change.Size = toSizes[change.Path]
+			case model.DiffRemoved:
+				change.Size = fromSizes[change.Path]
+			case model.DiffModified:
+				change.Size, change.OldSize = toSizes[change.Path], fromSizes[change.Path]
+			}
+		}
+	}
+
+	diff := model.SnapshotDiff{From: from, To: to, Changes: changes}
+	diff.SortChanges()
+
+	return []model.SnapshotDiff{diff}, nil
+}
+
+func executeResticWithOutput(ctx context.Context, config model.ConfigFile, args ...string) ([]byte, error) {
+
+	directInvocation, err := generateResticDirectInvocation(config)
+	if err != nil {
+		return nil, err
+	}
+
+	directInvocation.Args = append(directInvocation.Args, args...)
+
+	return directInvocation.ExecuteWithOutput(ctx)
+}
+
+// listResticFileSizes returns a map of the path of each file of the snapshot to its size.
+func listResticFileSizes(ctx context.Context, config model.ConfigFile, snapshotID string) (map[string]int64, error) {
+
+	output, err := executeResticWithOutput(ctx, config, "ls", "--json", snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResticLs(output)
+}
+
+// parseResticDiff parses the output of 'restic diff --json': each change is a line with a 'path' (with a trailing '/' for
+// folders) and a 'modifier', which is '+' (added), '-' (removed), or contains 'M' (content modified) or 'T' (type changed).
+// Metadata-only changes ('U') are ignored.
+func parseResticDiff(output []byte) ([]model.DiffEntry, error) {
+
+	res := []model.DiffEntry{}
+
+	err := forEachJSONLine(output, func(line []byte) error {
+
+		change := struct {
+			MessageType string `json:"message_type"`
+			Path        string `json:"path"`
+			Modifier    string `json:"modifier"`
+		}{}
+
+		if err := json.Unmarshal(line, &change); err != nil {
+			return fmt.Errorf("unable to parse restic diff: %w", err)
+		}
+
+		if change.MessageType != "change" {
+			return nil
+		}
+
+		entry := model.DiffEntry{Path: change.Path}
+
+		if strings.HasSuffix(entry.Path, "/") && entry.Path != "/" {
+			entry.Path = strings.TrimSuffix(entry.Path, "/")
+			entry.IsDir = true
+		}
+
+		switch {
+		case strings.Contains(change.Modifier, "+"):
+			entry.Change = model.DiffAdded
+		case strings.Contains(change.Modifier, "-"):
+			entry.Change = model.DiffRemoved
+		case strings.ContainsAny(change.Modifier, "MT"):
+			entry.Change = model.DiffModified
+		default:
+			return nil
+		}
+
+		res = append(res, entry)
+
+		return nil
+	})
+
+	return res, err
+}
+
+// parseResticLs parses the output of 'restic ls --json', returning the size of each file.
+func parseResticLs(output []byte) (map[string]int64, error) {
+
+	res := map[string]int64{}
+
+	err := forEachJSONLine(output, func(line []byte) error {
+
+		node := struct {
+			Type string `json:"type"`
+			Path string `json:"path"`
+			Size int64  `json:"size"`
+		}{}
+
+		if err := json.Unmarshal(line, &node); err != nil {
+			return fmt.Errorf("unable to parse restic file list: %w", err)
+		}
+
+		// The first line describes the snapshot, rather than a file
+		if node.Type == "file" {
+			res[node.Path] = node.Size
+		}
+
+		return nil
+	})
+
+	return res, err
+}
+
+func forEachJSONLine(output []byte, fn func(line []byte) error) error {
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
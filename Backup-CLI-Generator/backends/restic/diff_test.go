@@ -0,0 +1,52 @@
+package restic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestParseResticDiff(t *testing.T) {
+
+	output := []byte(`{"message_type":"change","path":"/home/me/new.txt","modifier":"+"}
+{"message_type":"change","path":"/home/me/old/","modifier":"-"}
+{"message_type":"change","path":"/home/me/changed.txt","modifier":"M"}
+{"message_type":"change","path":"/home/me/touched.txt","modifier":"U"}
+{"message_type":"statistics","source_snapshot":"a","target_snapshot":"b"}
+`)
+
+	expected := []model.DiffEntry{
+		{Path: "/home/me/new.txt", Change: model.DiffAdded},
+		{Path: "/home/me/old", Change: model.DiffRemoved, IsDir: true},
+		{Path: "/home/me/changed.txt", Change: model.DiffModified},
+	}
+
+	actual, err := parseResticDiff(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestParseResticLs(t *testing.T) {
+
+	output := []byte(`{"time":"2024-01-01T00:00:00Z","paths":["/home/me"],"id":"abc","struct_type":"snapshot"}
+{"name":"me","type":"dir","path":"/home/me","struct_type":"node"}
+{"name":"file.txt","type":"file","path":"/home/me/file.txt","size":42,"struct_type":"node"}
+`)
+
+	actual, err := parseResticLs(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int64{"/home/me/file.txt": 42}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,15 @@
+package restic
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (ResticBackend) SupportsFolderPairs() bool {
+	return false
+}
+
+func (ResticBackend) FolderPairs(path string) ([]model.FolderPair, error) {
+	return nil, fmt.Errorf("unsupported")
+}
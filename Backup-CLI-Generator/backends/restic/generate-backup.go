@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -44,15 +46,27 @@ func (ResticBackend) GenerateBackup(path string, outputPath string) error {
 
 func generateBackupScriptFromConfigFile(configFilePath string, config model.ConfigFile) (string, error) {
 
-	configType, err := config.GetConfigType()
-	if err != nil {
+	if err := generate.CheckMonitorFoldersForMissingChildren(configFilePath, config); err != nil {
 		return "", err
 	}
 
-	if err := generate.CheckMonitorFoldersForMissingChildren(configFilePath, config); err != nil {
+	nodes, err := generateBackupNodesFromConfigFile(configFilePath, config)
+	if err != nil {
 		return "", err
 	}
 
+	return nodes.ToString()
+}
+
+// generateBackupNodesFromConfigFile returns the text nodes of the backup script of the config file. Monitor folders are not
+// checked, so that the graph of the nodes can be generated regardless.
+func generateBackupNodesFromConfigFile(configFilePath string, config model.ConfigFile) (*util.TextNodes, error) {
+
+	configType, err := config.GetConfigType()
+	if err != nil {
+		return nil, err
+	}
+
 	nodes := util.NewTextNodes()
 
 	cmds.AddGenericPrefixNode(nodes)
@@ -62,7 +76,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		backupDateTime := nodes.NewTextNode()
 
 		if config.Metadata.Name == "" {
-			return "", fmt.Errorf("if metadata is specified, then name must be specified")
+			return nil, fmt.Errorf("if metadata is specified, then name must be specified")
 		}
 
 		if config.Metadata.AppendDateTime {
@@ -92,7 +106,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 
 			expandedValue, err := util.Expand(exclude, config.Substitutions)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			if nodes.IsWindows() {
@@ -109,7 +123,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		foldersNode := nodes.NewTextNode()
 
 		if len(config.Folders) == 0 {
-			return "", errors.New("at least one folder is required")
+			return nil, errors.New("at least one folder is required")
 		}
 
 		foldersNode.Out("")
@@ -118,7 +132,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		// processFolder is a slice of: [string (path to backup), model.Folder (folder object)]
 		processedFolders, err := generate.PopulateProcessedFolders(configType, config.Folders, config.Substitutions, map[string][]string{})
 		if err != nil {
-			return "", fmt.Errorf("unable to populateProcessedFolder: %v", err)
+			return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
 		}
 
 		for index, processedFolder := range processedFolders {
@@ -149,7 +163,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	// Uses the 'TODO' env var, generated above, to know what to backup.
 	invocationNode, err = generateGenerateBackupInvocationNode(config, nodes)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	suffixNode := nodes.NewTextNode()
@@ -158,7 +172,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	suffixNode.Out("backup-cli check \"" + configFilePath + "\" " + suffixNode.Env("SCRIPTPATH"))
 	suffixNode.AddDependency(invocationNode)
 
-	return nodes.ToString()
+	return nodes, nil
 }
 
 func generateGenerateBackupInvocationNode(config model.ConfigFile, textNodes *util.TextNodes) (*util.TextNode, error) {
@@ -218,14 +232,32 @@ func generateGenerateBackupInvocationNode(config model.ConfigFile, textNodes *ut
 		excludesSubstring = invocationTextNode.Env("EXCLUDES") + " "
 	}
 
-	cliInvocation := fmt.Sprintf("restic -r %s --verbose %s%s%s backup %s",
+	limits, err := config.GetLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	ioPrioritySubstring := ""
+	if prefix := util.IOPriorityCommandPrefix(limits, runtime.GOOS); len(prefix) > 0 {
+		ioPrioritySubstring = strings.Join(prefix, " ") + " "
+	}
+
+	limitsSubstring := ""
+	for _, arg := range resticBandwidthLimitArgs(limits.DefaultBandwidth()) {
+		limitsSubstring += arg + " "
+	}
+
+	cliInvocation := fmt.Sprintf("%srestic -r %s --verbose %s%s%s%sbackup %s",
+		ioPrioritySubstring,
 		url,
 		tagSubstring,
 		cacertSubstring,
+		limitsSubstring,
 		excludesSubstring,
 		invocationTextNode.Env("TODO"))
 
 	invocationTextNode.Out()
+	util.AddLimitsScheduleNote(invocationTextNode, limits)
 
 	if textNodes.IsWindows() {
 		invocationTextNode.Out(cliInvocation)
@@ -0,0 +1,20 @@
+package restic
+
+func (ResticBackend) SupportsGenerateGraph() bool {
+	return true
+}
+
+func (ResticBackend) GenerateGraph(path string) (string, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	nodes, err := generateBackupNodesFromConfigFile(path, config)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.ToDOT()
+}
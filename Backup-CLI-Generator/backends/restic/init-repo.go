@@ -0,0 +1,121 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (ResticBackend) SupportsInitRepo() bool {
+	return true
+}
+
+// InitRepo creates the restic repository with 'restic init', unless 'restic cat config' reports that it already exists. With
+// ApplyPolicy, the retention policy is applied immediately with 'restic forget --prune'; restic has no persistent compression
+// setting, so the compression policy is applied by each backup.
+func (ResticBackend) InitRepo(ctx context.Context, path string, options model.InitRepoOptions) error {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	policy, err := config.GetPolicy()
+	if err != nil {
+		return err
+	}
+
+	if options.ApplyPolicy && policy == nil {
+		return fmt.Errorf("config file has no 'policy' to apply")
+	}
+
+	exists, err := resticRepositoryExists(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		fmt.Println("Repository already exists; it was not modified.")
+
+	} else {
+
+		initArgs := []string{"init"}
+
+		// Compression requires the version 2 repository format
+		if policy != nil && policy.Compression != "" && policy.Compression != "off" {
+			initArgs = append(initArgs, "--repository-version", "2")
+		}
+
+		if _, err := executeResticWithOutput(ctx, config, initArgs...); err != nil {
+			return err
+		}
+
+		fmt.Println("Repository created.")
+	}
+
+	if !options.ApplyPolicy {
+		return nil
+	}
+
+	if policy.HasRetention() {
+
+		forgetArgs := append([]string{"forget", "--prune"}, resticRetentionArgs(*policy)...)
+
+		if _, err := executeResticWithOutput(ctx, config, forgetArgs...); err != nil {
+			return err
+		}
+
+		fmt.Println("Retention policy applied.")
+	}
+
+	if policy.Compression != "" {
+		fmt.Printf("Compression '%s' is applied by each backup.\n", policy.Compression)
+	}
+
+	return nil
+}
+
+// resticRepositoryExists returns true if the repository exists, false if restic reports that it does not exist, or an error if
+// the repository could not be accessed (for example, with a wrong password).
+func resticRepositoryExists(ctx context.Context, config model.ConfigFile) (bool, error) {
+
+	_, err := executeResticWithOutput(ctx, config, "cat", "config")
+	if err == nil {
+		return true, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == resticExitCodeRepositoryDoesNotExist {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unable to determine whether the repository exists: %w", err)
+}
+
+// resticRetentionArgs returns the 'restic forget' arguments of the retention policy.
+func resticRetentionArgs(policy model.RepositoryPolicy) []string {
+
+	res := []string{}
+
+	for _, keep := range []struct {
+		arg   string
+		value int
+	}{
+		{"--keep-last", policy.KeepLast},
+		{"--keep-hourly", policy.KeepHourly},
+		{"--keep-daily", policy.KeepDaily},
+		{"--keep-weekly", policy.KeepWeekly},
+		{"--keep-monthly", policy.KeepMonthly},
+		{"--keep-yearly", policy.KeepYearly},
+	} {
+		if keep.value > 0 {
+			res = append(res, keep.arg, strconv.Itoa(keep.value))
+		}
+	}
+
+	return res
+}
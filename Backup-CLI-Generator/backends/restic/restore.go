@@ -0,0 +1,52 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/restore"
+)
+
+func (ResticBackend) SupportsRestore() bool {
+	return true
+}
+
+func (ResticBackend) Restore(ctx context.Context, path string, options model.RestoreOptions) error {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := restore.PrepareTarget(options.Target, options.Force); err != nil {
+		return err
+	}
+
+	snapshots, err := listResticSnapshots(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := model.SelectSnapshot(snapshots, options.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restoring snapshot %s (%s)\n", snapshot.ID, snapshot.Time.Format(time.RFC3339))
+
+	directInvocation, err := generateResticDirectInvocation(config)
+	if err != nil {
+		return err
+	}
+
+	// restic restores each file to the target folder joined with its original absolute path
+	directInvocation.Args = append(directInvocation.Args, "restore", snapshot.ID, "--target", options.Target)
+
+	for _, includePath := range options.Paths {
+		directInvocation.Args = append(directInvocation.Args, "--include", includePath)
+	}
+
+	return directInvocation.Execute(ctx)
+}
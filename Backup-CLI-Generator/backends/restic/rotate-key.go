@@ -0,0 +1,144 @@
+package restic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (ResticBackend) SupportsRotateKey() bool {
+	return true
+}
+
+// AddKey adds the new password as a key of the repository with 'restic key add', then verifies it by opening the repository
+// with the new password.
+func (ResticBackend) AddKey(ctx context.Context, path string, newPassword string) (model.KeyRotation, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	oldKeyID, err := currentResticKeyID(ctx, config)
+	if err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	newPasswordFile, err := os.CreateTemp("", "backup-cli-password-")
+	if err != nil {
+		return model.KeyRotation{}, err
+	}
+	defer os.Remove(newPasswordFile.Name())
+
+	if _, err := newPasswordFile.WriteString(newPassword); err != nil {
+		newPasswordFile.Close()
+		return model.KeyRotation{}, err
+	}
+	if err := newPasswordFile.Close(); err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	if _, err := executeResticWithOutput(ctx, config, "key", "add", "--new-password-file", newPasswordFile.Name()); err != nil {
+		return model.KeyRotation{}, err
+	}
+
+	newKeyID, err := currentResticKeyID(ctx, withResticPassword(config, newPassword))
+	if err != nil {
+		return model.KeyRotation{}, fmt.Errorf("unable to open the repository with the new key: %w", err)
+	}
+
+	if newKeyID == oldKeyID {
+		return model.KeyRotation{}, fmt.Errorf("the new password opens the repository with the old key '%s'", oldKeyID)
+	}
+
+	fmt.Printf("Added key '%s'.\n", newKeyID)
+
+	return model.KeyRotation{OldKeyID: oldKeyID}, nil
+}
+
+// RemoveOldKey removes the old key with 'restic key remove', after verifying that the config file opens the repository with a
+// different key.
+func (ResticBackend) RemoveOldKey(ctx context.Context, path string, rotation model.KeyRotation) error {
+
+	if rotation.OldKeyID == "" {
+		return nil
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	currentKeyID, err := currentResticKeyID(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	if currentKeyID == rotation.OldKeyID {
+		return fmt.Errorf("the config file still uses the old key '%s'", rotation.OldKeyID)
+	}
+
+	if _, err := executeResticWithOutput(ctx, config, "key", "remove", rotation.OldKeyID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed key '%s'.\n", rotation.OldKeyID)
+
+	return nil
+}
+
+// currentResticKeyID returns the ID of the key that the repository is opened with, using the password of the config file.
+func currentResticKeyID(ctx context.Context, config model.ConfigFile) (string, error) {
+
+	output, err := executeResticWithOutput(ctx, config, "key", "list", "--json")
+	if err != nil {
+		return "", err
+	}
+
+	return parseResticCurrentKeyID(output)
+}
+
+// parseResticCurrentKeyID returns the ID of the current key, from the output of 'restic key list --json'.
+func parseResticCurrentKeyID(output []byte) (string, error) {
+
+	// Skip any informational output that precedes the JSON
+	if index := bytes.IndexByte(output, '['); index > 0 {
+		output = output[index:]
+	}
+
+	keys := []struct {
+		Current bool   `json:"current"`
+		ID      string `json:"id"`
+	}{}
+
+	if err := json.Unmarshal(output, &keys); err != nil {
+		return "", fmt.Errorf("unable to parse restic key list: %w", err)
+	}
+
+	for _, key := range keys {
+		if key.Current {
+			return key.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("restic key list did not report a current key")
+}
+
+// withResticPassword returns a copy of the config file whose restic credentials use the given password.
+func withResticPassword(config model.ConfigFile, password string) model.ConfigFile {
+
+	resticCredentials, err := config.GetResticCredential()
+	if err != nil {
+		return config
+	}
+
+	resticCredentials.Password, resticCredentials.PasswordFile = password, ""
+
+	config.Credentials = []model.Credentials{{Restic: &resticCredentials}}
+
+	return config
+}
@@ -1,6 +1,7 @@
 package restic
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -14,14 +15,28 @@ func (ResticBackend) SupportsBackup() bool {
 	return true
 }
 
-func (ResticBackend) Backup(path string, rehashSource bool) error {
+func (ResticBackend) Backup(ctx context.Context, path string, options model.BackupOptions) error {
+
+	if options.Parallel > 1 {
+		return fmt.Errorf("unsupported flag: parallel")
+	}
+
+	if options.Resume {
+		return fmt.Errorf("unsupported flag: resume")
+	}
 
 	config, err := extractAndValidateConfigFile(path)
 	if err != nil {
 		return err
 	}
 
-	if err := runBackupFromConfigFile(path, config, rehashSource); err != nil {
+	ctx, endBackup, err := runbackup.BeginBackup(ctx, path, config, options)
+	if err != nil {
+		return err
+	}
+	defer endBackup()
+
+	if err := runBackupFromConfigFile(ctx, path, config, options.RehashSource); err != nil {
 		return err
 	}
 
@@ -29,7 +44,7 @@ func (ResticBackend) Backup(path string, rehashSource bool) error {
 
 }
 
-func runBackupFromConfigFile(configFilePath string, config model.ConfigFile, rehashSource bool) error {
+func runBackupFromConfigFile(ctx context.Context, configFilePath string, config model.ConfigFile, rehashSource bool) error {
 
 	res := runbackup.BackupRunObject{}
 
@@ -73,7 +88,7 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile, reh
 		}
 	}
 
-	if err := executeBackupInvocation(config, res, rehashSource); err != nil {
+	if err := executeBackupInvocation(ctx, config, res, rehashSource); err != nil {
 		return err
 	}
 
@@ -85,7 +100,7 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile, reh
 
 }
 
-func executeBackupInvocation(config model.ConfigFile, input runbackup.BackupRunObject, rehashSource bool) error {
+func executeBackupInvocation(ctx context.Context, config model.ConfigFile, input runbackup.BackupRunObject, rehashSource bool) error {
 
 	directInvocation, err := generateResticDirectInvocation(config)
 	if err != nil {
@@ -125,6 +140,6 @@ func executeBackupInvocation(config model.ConfigFile, input runbackup.BackupRunO
 
 	directInvocation.Args = append(directInvocation.Args, input.Todo...)
 
-	return directInvocation.Execute()
+	return directInvocation.Execute(ctx)
 
 }
@@ -1,10 +1,12 @@
 package restic
 
+import "context"
+
 func (ResticBackend) SupportsRun() bool {
 	return true
 }
 
-func (ResticBackend) Run(path string, args []string) error {
+func (ResticBackend) Run(ctx context.Context, path string, args []string) error {
 
 	config, err := extractAndValidateConfigFile(path)
 	if err != nil {
@@ -18,6 +20,6 @@ func (ResticBackend) Run(path string, args []string) error {
 
 	invocParams.Args = append(invocParams.Args, args...)
 
-	return invocParams.Execute()
+	return invocParams.Execute(ctx)
 
 }
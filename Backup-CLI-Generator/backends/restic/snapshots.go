@@ -0,0 +1,83 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (ResticBackend) SupportsSnapshots() bool {
+	return true
+}
+
+func (ResticBackend) Snapshots(ctx context.Context, path string) ([]model.Snapshot, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResticSnapshots(ctx, config)
+}
+
+// resticSnapshot is an entry of the output of 'restic snapshots --json'.
+type resticSnapshot struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Tags     []string  `json:"tags"`
+	Paths    []string  `json:"paths"`
+
+	// Summary is only reported by restic 0.17+
+	Summary *struct {
+		TotalBytesProcessed int64 `json:"total_bytes_processed"`
+	} `json:"summary"`
+}
+
+func listResticSnapshots(ctx context.Context, config model.ConfigFile) ([]model.Snapshot, error) {
+
+	directInvocation, err := generateResticDirectInvocation(config)
+	if err != nil {
+		return nil, err
+	}
+
+	directInvocation.Args = append(directInvocation.Args, "snapshots", "--json")
+
+	output, err := directInvocation.ExecuteWithOutput(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResticSnapshots(output)
+}
+
+func parseResticSnapshots(output []byte) ([]model.Snapshot, error) {
+
+	resticSnapshots := []resticSnapshot{}
+	if err := json.Unmarshal(output, &resticSnapshots); err != nil {
+		return nil, fmt.Errorf("unable to parse restic snapshot list: %w", err)
+	}
+
+	res := []model.Snapshot{}
+	for _, resticSnapshot := range resticSnapshots {
+
+		snapshot := model.Snapshot{
+			ID:    resticSnapshot.ID,
+			Time:  resticSnapshot.Time,
+			Host:  resticSnapshot.Hostname,
+			Tags:  resticSnapshot.Tags,
+			Paths: resticSnapshot.Paths,
+		}
+
+		if resticSnapshot.Summary != nil {
+			snapshot.Size = resticSnapshot.Summary.TotalBytesProcessed
+		}
+
+		res = append(res, snapshot)
+	}
+
+	return res, nil
+}
@@ -3,6 +3,9 @@ package restic
 import (
 	"errors"
 	"fmt"
+	"runtime"
+	"strconv"
+	"time"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -62,6 +65,14 @@ func generateResticDirectInvocation(config model.ConfigFile) (util.DirectInvocat
 
 	}
 
+	compression, err := resticCompression(config)
+	if err != nil {
+		return util.DirectInvocation{}, err
+	}
+	if compression != "" {
+		env["RESTIC_COMPRESSION"] = compression
+	}
+
 	url := ""
 	if resticCredential.S3 != nil {
 		url = "s3:" + resticCredential.S3.URL
@@ -80,16 +91,29 @@ func generateResticDirectInvocation(config model.ConfigFile) (util.DirectInvocat
 		cacertSubstring = append(cacertSubstring, "--cacert", expandedPath)
 	}
 
-	execInvocation := []string{
+	limits, err := config.GetLimits()
+	if err != nil {
+		return util.DirectInvocation{}, err
+	}
+
+	execInvocation := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+
+	execInvocation = append(execInvocation,
 		"restic",
 		"-r",
 		url,
 		"--verbose",
-	}
+	)
 
 	execInvocation = append(execInvocation, cacertSubstring...)
-
-	return util.DirectInvocation{Args: execInvocation, EnvironmentVariables: env}, nil
+	execInvocation = append(execInvocation, resticBandwidthLimitArgs(limits.BandwidthAt(time.Now()))...)
+
+	return util.DirectInvocation{
+		Args:                 execInvocation,
+		EnvironmentVariables: env,
+		Retry:                config.Retry,
+		IsRetryable:          isRetryableResticFailure,
+	}, nil
 }
 
 func sharedGenerateResticCredentials(config model.ConfigFile, node *util.TextNode) error {
@@ -120,6 +144,88 @@ func sharedGenerateResticCredentials(config model.ConfigFile, node *util.TextNod
 		return errors.New("no restic password found")
 	}
 
+	compression, err := resticCompression(config)
+	if err != nil {
+		return err
+	}
+	if compression != "" {
+		node.SetEnv("RESTIC_COMPRESSION", compression)
+	}
+
 	return nil
 
 }
+
+// resticCompression returns the compression of the policy of the config file, or "" if not specified.
+func resticCompression(config model.ConfigFile) (string, error) {
+
+	policy, err := config.GetPolicy()
+	if err != nil || policy == nil {
+		return "", err
+	}
+
+	switch policy.Compression {
+	case "", "auto", "off", "max":
+		return policy.Compression, nil
+	default:
+		return "", fmt.Errorf("unrecognized restic compression '%s': expected 'auto', 'off' or 'max'", policy.Compression)
+	}
+}
+
+// resticBandwidthLimitArgs returns the restic arguments for the bandwidth limit (restic's limits are also in KiB/s).
+func resticBandwidthLimitArgs(limit model.BandwidthLimit) []string {
+
+	res := []string{}
+
+	if limit.UploadKiBps > 0 {
+		res = append(res, "--limit-upload", strconv.Itoa(limit.UploadKiBps))
+	}
+
+	if limit.DownloadKiBps > 0 {
+		res = append(res, "--limit-download", strconv.Itoa(limit.DownloadKiBps))
+	}
+
+	return res
+}
+
+// Restic exit codes (restic 0.17+) that identify the cause of a failure
+const (
+	resticExitCodeRepositoryDoesNotExist = 10
+	resticExitCodeFailedToLockRepository = 11
+	resticExitCodeWrongPassword          = 12
+)
+
+// resticPermanentErrorPatterns are stderr substrings of failures that will not succeed on retry, such as authentication failures.
+var resticPermanentErrorPatterns = []string{
+	"wrong password",
+	"no key found",
+	"AccessDenied",
+	"Access Denied",
+	"InvalidAccessKeyId",
+	"SignatureDoesNotMatch",
+	"Is there a repository at the following location?",
+}
+
+// resticTransientErrorPatterns are stderr substrings of restic-specific failures that may succeed on retry.
+var resticTransientErrorPatterns = []string{
+	"repository is already locked",
+	"unable to create lock",
+}
+
+// isRetryableResticFailure returns true if the restic failure is transient (for example, lock contention or a network error),
+// rather than permanent (for example, an authentication failure).
+func isRetryableResticFailure(exitCode int, stderr string) bool {
+
+	switch exitCode {
+	case resticExitCodeFailedToLockRepository:
+		return true
+	case resticExitCodeRepositoryDoesNotExist, resticExitCodeWrongPassword:
+		return false
+	}
+
+	if util.ContainsAny(stderr, resticPermanentErrorPatterns) {
+		return false
+	}
+
+	return util.ContainsAny(stderr, resticTransientErrorPatterns) || util.ContainsAny(stderr, util.TransientNetworkErrorPatterns)
+}
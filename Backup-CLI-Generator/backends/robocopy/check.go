@@ -0,0 +1,113 @@
+package robocopy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	runbackup "github.com/jgwest/backup-cli/util/cmds/run-backup"
+)
+
+func (RobocopyBackend) SupportsCheck() bool {
+	return true
+}
+
+// Check compares each source folder with its destination folder, by running robocopy in list-only mode ('/L'), which reports
+// the files that a backup would copy. Robocopy compares file sizes and timestamps, but not file content, so quick and standard
+// checks are the same, and deep checks are not supported ('backup-cli verify' compares the content of a sample of files).
+func (RobocopyBackend) Check(ctx context.Context, path string, options model.CheckOptions) error {
+
+	if _, err := options.DataPercent(); err != nil {
+		return err
+	}
+
+	if options.Level == model.CheckLevelDeep {
+		return fmt.Errorf("robocopy does not support deep checks: use 'verify' to compare the content of a sample of files")
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	robocopyCredentials, err := getAndValidateRobocopyCredentials(config)
+	if err != nil {
+		return err
+	}
+
+	robocopyFolders, err := robocopyFolderPairs(config)
+	if err != nil {
+		return err
+	}
+
+	concurrency, err := config.GetConcurrency(model.BackupOptions{})
+	if err != nil {
+		return err
+	}
+
+	excludes := runbackup.BackupRunObject{}
+	if err := populateRobocopyExcludes(config, &excludes); err != nil {
+		return err
+	}
+
+	// The same switches and excludes as the backup, in list-only mode, without progress or folder output
+	switches := strings.Fields(robocopyCredentials.Switches)
+	switches = append(switches, "/L", "/NP", "/NDL")
+
+	for _, file := range excludes.RobocopyFileExcludes {
+		switches = append(switches, "/XF", file)
+	}
+
+	for _, folder := range excludes.RobocopyFolderExcludes {
+		switches = append(switches, "/XD", folder)
+	}
+
+	jobs := []runbackup.FolderJob{}
+
+	for _, folderTuple := range robocopyFolders {
+
+		srcFolder, destFolder := folderTuple[0], folderTuple[1]
+
+		cliInvocation := append([]string{"robocopy", srcFolder, destFolder}, switches...)
+
+		jobs = append(jobs, runbackup.FolderJob{
+			Source: srcFolder,
+			Dest:   destFolder,
+			Run: func(outputPrefix string) error {
+
+				robocopyDI := util.DirectInvocation{
+					Args:                 cliInvocation,
+					EnvironmentVariables: map[string]string{},
+					OutputPrefix:         outputPrefix,
+					ClassifyExitCode:     classifyRobocopyCheckExitCode,
+				}
+
+				return robocopyDI.Execute(ctx)
+			},
+		})
+	}
+
+	runResult := runbackup.ExecuteFolderJobs(jobs, concurrency)
+
+	runResult.PrintSummary(os.Stdout)
+
+	return runResult.FailureError("check")
+}
+
+// classifyRobocopyCheckExitCode interprets the exit code of a list-only ('/L') robocopy invocation: files that would be copied
+// ('files copied', 'mismatched files') mean that the destination is out of date. Extra destination files (such as files that
+// have since been deleted from the source) are reported as information.
+func classifyRobocopyCheckExitCode(exitCode int) util.ExitCodeResult {
+
+	result := classifyRobocopyExitCode(exitCode)
+
+	if result.Success && exitCode&(1|4) != 0 {
+		result.Success = false
+		result.Description = "destination differs from source: " + result.Description
+	}
+
+	return result
+}
@@ -0,0 +1,59 @@
+package robocopy
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+)
+
+func (RobocopyBackend) SupportsFolderPairs() bool {
+	return true
+}
+
+func (RobocopyBackend) FolderPairs(path string) ([]model.FolderPair, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	robocopyFolders, err := robocopyFolderPairs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []model.FolderPair{}
+	for _, folderTuple := range robocopyFolders {
+		res = append(res, model.FolderPair{Source: folderTuple[0], Dest: folderTuple[1]})
+	}
+
+	return res, nil
+}
+
+// robocopyFolderPairs returns a slice of:
+// - source folder path
+// - destination folder (with basename of source folder appended)
+// Example:
+// - [C:\Users] -> [B:\backup\C-Users]
+// - [D:\Users] -> [B:\backup\D-Users]
+// - [C:\To-Backup] -> [B:\backup\To-Backup]
+func robocopyFolderPairs(config model.ConfigFile) ([][]string, error) {
+
+	processedFolders, err := generate.PopulateProcessedFolders(model.Robocopy, config.Folders, config.Substitutions, map[string][]string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
+	}
+
+	// Ensure that none of the folders share a basename
+	if err := robocopyValidateBasenames(processedFolders); err != nil {
+		return nil, err
+	}
+
+	robocopyCredentials, err := config.GetRobocopyCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	return robocopyGenerateTargetPaths(processedFolders, robocopyCredentials)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/jgwest/backup-cli/model"
@@ -50,6 +51,18 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		return "", err
 	}
 
+	nodes, err := generateBackupNodesFromConfigFile(configFilePath, config)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.ToString()
+}
+
+// generateBackupNodesFromConfigFile returns the text nodes of the backup script of the config file. Monitor folders are not
+// checked, so that the graph of the nodes can be generated regardless.
+func generateBackupNodesFromConfigFile(configFilePath string, config model.ConfigFile) (*util.TextNodes, error) {
+
 	nodes := util.NewTextNodes()
 
 	cmds.AddGenericPrefixNode(nodes)
@@ -59,7 +72,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		backupDateTime := nodes.NewTextNode()
 
 		if config.Metadata.Name == "" {
-			return "", fmt.Errorf("if metadata is specified, then name must be specified")
+			return nil, fmt.Errorf("if metadata is specified, then name must be specified")
 		}
 
 		if config.Metadata.AppendDateTime {
@@ -77,7 +90,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	// Populate EXCLUDES var, by processing Global Excludes
 	if len(config.GlobalExcludes) > 0 {
 
-		return "", errors.New("robocopy does not support global excludes")
+		return nil, errors.New("robocopy does not support global excludes")
 
 	}
 
@@ -85,7 +98,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	if config.RobocopySettings != nil {
 
 		if !nodes.IsWindows() {
-			return "", errors.New("robocopy settings not supported for non-windows")
+			return nil, errors.New("robocopy settings not supported for non-windows")
 		}
 
 		excludesNode.Out()
@@ -103,7 +116,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 
 			expandedValue, err := util.Expand(excludeFile, config.Substitutions)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			excludesNode.SetEnv("EXCLUDES", substring+"/XF \""+expandedValue+"\"")
@@ -121,11 +134,11 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 
 			expandedValue, err := util.Expand(excludeDir, config.Substitutions)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			if strings.Contains(expandedValue, "*") {
-				return "", fmt.Errorf("wildcards may not be supported in directories with robocopy: %s", expandedValue)
+				return nil, fmt.Errorf("wildcards may not be supported in directories with robocopy: %s", expandedValue)
 			}
 
 			excludesNode.SetEnv("EXCLUDES", substring+"/XD \""+expandedValue+"\"")
@@ -150,7 +163,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		foldersNode := nodes.NewTextNode()
 
 		if len(config.Folders) == 0 {
-			return "", errors.New("at least one folder is required")
+			return nil, errors.New("at least one folder is required")
 		}
 
 		foldersNode.Out("")
@@ -160,30 +173,30 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		// - This function also updates kopiaPolicyExcludes, if applicable.
 		processedFolders, err := generate.PopulateProcessedFolders(model.Robocopy, config.Folders, config.Substitutions, map[string][]string{})
 		if err != nil {
-			return "", fmt.Errorf("unable to populateProcessedFolder: %v", err)
+			return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
 		}
 
 		// Ensure that none of the folders share a basename
 		if err := robocopyValidateBasenames(processedFolders); err != nil {
-			return "", err
+			return nil, err
 		}
 
 		if robocopyCredentials, err := config.GetRobocopyCredential(); err == nil {
 
 			robocopyFolders, err = robocopyGenerateTargetPaths(processedFolders, robocopyCredentials)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 		} else {
-			return "", err
+			return nil, err
 		}
 
 	} // end 'process folders' section
 
 	invocationNode, err := generateBackupInvocationNode(config, robocopyFolders, nodes)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	suffixNode := nodes.NewTextNode()
@@ -192,7 +205,17 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	suffixNode.Out("backup-cli check \"" + configFilePath + "\" " + suffixNode.Env("SCRIPTPATH"))
 	suffixNode.AddDependency(invocationNode)
 
-	return nodes.ToString()
+	// Exit with an error if any of the robocopy invocations failed (set in the invocation node)
+	failureNode := nodes.NewTextNode()
+	failureNode.Out()
+	if nodes.IsWindows() {
+		failureNode.Out(fmt.Sprintf("if defined BACKUP_FAILED exit /b %d", util.ExitCodeFolderFailure))
+	} else {
+		failureNode.Out(fmt.Sprintf("if [ -n \"${BACKUP_FAILED:-}\" ]; then exit %d; fi", util.ExitCodeFolderFailure))
+	}
+	failureNode.AddDependency(suffixNode)
+
+	return nodes, nil
 }
 
 func getAndValidateRobocopyCredentials(config model.ConfigFile) (*model.RobocopyCredentials, error) {
@@ -228,10 +251,21 @@ func generateBackupInvocationNode(config model.ConfigFile, robocopyFolders [][]s
 		return nil, err
 	}
 
+	limits, err := config.GetLimits()
+	if err != nil {
+		return nil, err
+	}
+
 	textNode := textNodes.NewTextNode()
 
+	util.AddLimitsScheduleNote(textNode, limits)
+
 	envSwitch := ""
 
+	for _, arg := range robocopyInterPacketGapArgs(limits.DefaultBandwidth()) {
+		envSwitch += " " + arg
+	}
+
 	if config.RobocopySettings != nil && (len(config.RobocopySettings.ExcludeFiles) > 0 || len(config.RobocopySettings.ExcludeFolders) > 0) {
 		envSwitch += " " + textNode.Env("EXCLUDES")
 	}
@@ -241,7 +275,20 @@ func generateBackupInvocationNode(config model.ConfigFile, robocopyFolders [][]s
 	for _, folderTuple := range robocopyFolders {
 		srcFolder := util.FixWindowsPathSuffix("\"" + folderTuple[0] + "\"")
 		destFolder := util.FixWindowsPathSuffix("\"" + folderTuple[1] + "\"")
-		textNode.Out(fmt.Sprintf("robocopy %s %s %s", srcFolder, destFolder, textNode.Env("SWITCHES")))
+		robocopyInvocation := fmt.Sprintf("robocopy %s %s %s", srcFolder, destFolder, textNode.Env("SWITCHES"))
+
+		if prefix := util.IOPriorityCommandPrefix(limits, runtime.GOOS); len(prefix) > 0 {
+			robocopyInvocation = strings.Join(prefix, " ") + " " + robocopyInvocation
+		}
+
+		// Robocopy exit codes 0-7 indicate success, 8 and above indicate failure: on failure, continue with
+		// the remaining folders, then exit with an error at the end of the script.
+		if textNodes.IsWindows() {
+			textNode.Out(robocopyInvocation)
+			textNode.Out(fmt.Sprintf("if ERRORLEVEL %d set BACKUP_FAILED=1", robocopyFailureExitCode))
+		} else {
+			textNode.Out(fmt.Sprintf("%s || if [ $? -ge %d ]; then BACKUP_FAILED=1; fi", robocopyInvocation, robocopyFailureExitCode))
+		}
 	}
 
 	return textNode, nil
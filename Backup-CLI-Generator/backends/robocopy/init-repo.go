@@ -0,0 +1,16 @@
+package robocopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (RobocopyBackend) SupportsInitRepo() bool {
+	return false
+}
+
+func (RobocopyBackend) InitRepo(ctx context.Context, path string, options model.InitRepoOptions) error {
+	return fmt.Errorf("unsupported")
+}
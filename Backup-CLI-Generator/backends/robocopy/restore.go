@@ -0,0 +1,90 @@
+package robocopy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/restore"
+)
+
+func (RobocopyBackend) SupportsRestore() bool {
+	return true
+}
+
+// Restore copies the folders back from the destination folder: as robocopy mirrors the source folders, only the latest
+// state of each folder is available.
+func (RobocopyBackend) Restore(ctx context.Context, path string, options model.RestoreOptions) error {
+
+	if options.Snapshot.Type != model.SnapshotSelectLatest {
+		return fmt.Errorf("robocopy only supports restoring the latest snapshot")
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	robocopyFolders, err := robocopyFolderPairs(config)
+	if err != nil {
+		return err
+	}
+
+	limits, err := config.GetLimits()
+	if err != nil {
+		return err
+	}
+
+	if err := restore.PrepareTarget(options.Target, options.Force); err != nil {
+		return err
+	}
+
+	restored := 0
+
+	for _, folderTuple := range robocopyFolders {
+
+		srcFolder, destFolder := folderTuple[0], folderTuple[1]
+
+		for _, relPath := range restore.RelativePathsUnder(srcFolder, options.Paths) {
+
+			from := filepath.Join(destFolder, relPath)
+			to := restore.TargetPath(options.Target, filepath.Join(srcFolder, relPath))
+
+			fileInfo, err := os.Stat(from)
+			if err != nil {
+				return fmt.Errorf("unable to locate '%s' in the destination folder: %w", relPath, err)
+			}
+
+			cliInvocation := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+
+			if fileInfo.IsDir() {
+				// Copy (rather than mirror) the folder, so that existing files in the target are never deleted
+				cliInvocation = append(cliInvocation, "robocopy", from, to, "/E")
+			} else {
+				cliInvocation = append(cliInvocation, "robocopy", filepath.Dir(from), filepath.Dir(to), filepath.Base(from))
+			}
+
+			robocopyDI := util.DirectInvocation{
+				Args:                 cliInvocation,
+				EnvironmentVariables: map[string]string{},
+				ClassifyExitCode:     classifyRobocopyExitCode,
+			}
+
+			if err := robocopyDI.Execute(ctx); err != nil {
+				return err
+			}
+
+			restored++
+		}
+	}
+
+	if restored == 0 {
+		return fmt.Errorf("none of the paths are contained in the backed up folders")
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package robocopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (RobocopyBackend) SupportsRotateKey() bool {
+	return false
+}
+
+func (RobocopyBackend) AddKey(ctx context.Context, path string, newPassword string) (model.KeyRotation, error) {
+	return model.KeyRotation{}, fmt.Errorf("unsupported")
+}
+
+func (RobocopyBackend) RemoveOldKey(ctx context.Context, path string, rotation model.KeyRotation) error {
+	return fmt.Errorf("unsupported")
+}
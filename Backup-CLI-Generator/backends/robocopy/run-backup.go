@@ -1,10 +1,14 @@
 package robocopy
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -16,9 +20,9 @@ func (RobocopyBackend) SupportsBackup() bool {
 	return true
 }
 
-func (RobocopyBackend) Backup(path string, rehashSource bool) error {
+func (RobocopyBackend) Backup(ctx context.Context, path string, options model.BackupOptions) error {
 
-	if rehashSource {
+	if options.RehashSource {
 		return fmt.Errorf("unsupported flag: rehash source")
 	}
 
@@ -27,7 +31,13 @@ func (RobocopyBackend) Backup(path string, rehashSource bool) error {
 		return err
 	}
 
-	if err := runBackupFromConfigFile(path, config); err != nil {
+	ctx, endBackup, err := runbackup.BeginBackup(ctx, path, config, options)
+	if err != nil {
+		return err
+	}
+	defer endBackup()
+
+	if err := runBackupFromConfigFile(ctx, path, config, options); err != nil {
 		return err
 	}
 
@@ -35,12 +45,10 @@ func (RobocopyBackend) Backup(path string, rehashSource bool) error {
 
 }
 
-func runBackupFromConfigFile(configFilePath string, config model.ConfigFile) error {
+func runBackupFromConfigFile(ctx context.Context, configFilePath string, config model.ConfigFile, options model.BackupOptions) error {
 
 	res := runbackup.BackupRunObject{}
 
-	isWindows := runtime.GOOS == "windows"
-
 	backupDateTime, err := runbackup.GetCurrentTimeTag()
 	if err != nil {
 		return err
@@ -52,6 +60,31 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile) err
 	}
 
 	// Robocopy only: Populate EXCLUDES
+	if err := populateRobocopyExcludes(config, &res); err != nil {
+		return err
+	}
+
+	robocopyFolders, err := robocopyFolderPairs(config)
+	if err != nil {
+		return err
+	}
+
+	if err := executeBackupInvocation(ctx, configFilePath, config, robocopyFolders, res, options); err != nil {
+		return err
+	}
+
+	if err := generate.CheckMonitorFoldersForMissingChildren(configFilePath, config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// populateRobocopyExcludes adds the (expanded) file and folder excludes of the robocopy settings of the config file to res.
+func populateRobocopyExcludes(config model.ConfigFile, res *runbackup.BackupRunObject) error {
+
+	isWindows := runtime.GOOS == "windows"
+
 	if config.RobocopySettings != nil {
 
 		if !isWindows {
@@ -85,67 +118,33 @@ func runBackupFromConfigFile(configFilePath string, config model.ConfigFile) err
 
 	}
 
-	// robocopyFolders contains a slice of:
-	// - source folder path
-	// - destination folder (with basename of source folder appended)
-	// Example:
-	// - [C:\Users] -> [B:\backup\C-Users]
-	// - [D:\Users] -> [B:\backup\D-Users]
-	// - [C:\To-Backup] -> [B:\backup\To-Backup]
-	var robocopyFolders [][]string
-
-	// Process folders
-	// - Populate TODO env var, for everything except robocopy
-	// - For robocopy, populate robocopyFolders
-	{
-
-		// processFolder is a slice of: [string (path to backup), model.Folder (folder object)]
-		// - This function also updates kopiaPolicyExcludes, if applicable.
-		processedFolders, err := generate.PopulateProcessedFolders(model.Robocopy, config.Folders, config.Substitutions, map[string][]string{})
-		if err != nil {
-			return fmt.Errorf("unable to populateProcessedFolder: %v", err)
-		}
-
-		// Ensure that none of the folders share a basename
-		if err := robocopyValidateBasenames(processedFolders); err != nil {
-			return err
-		}
-
-		if robocopyCredentials, err := config.GetRobocopyCredential(); err == nil {
-
-			robocopyFolders, err = robocopyGenerateTargetPaths(processedFolders, robocopyCredentials)
-			if err != nil {
-				return err
-			}
-
-		} else {
-			return err
-		}
+	return nil
+}
 
-	}
+func executeBackupInvocation(ctx context.Context, configFilePath string, config model.ConfigFile, robocopyFolders [][]string, input runbackup.BackupRunObject, options model.BackupOptions) error {
 
-	if err := executeBackupInvocation(config, robocopyFolders, res); err != nil {
+	robocopyCredentials, err := getAndValidateRobocopyCredentials(config)
+	if err != nil {
 		return err
 	}
 
-	if err := generate.CheckMonitorFoldersForMissingChildren(configFilePath, config); err != nil {
+	concurrency, err := config.GetConcurrency(options)
+	if err != nil {
 		return err
 	}
-
-	return nil
-}
-
-func executeBackupInvocation(config model.ConfigFile, robocopyFolders [][]string, input runbackup.BackupRunObject) error {
-
-	robocopyCredentials, err := getAndValidateRobocopyCredentials(config)
+	limits, err := config.GetLimits()
 	if err != nil {
 		return err
 	}
+
 	switches := []string{}
 
 	// Add switches from config file
 	switches = append(switches, strings.Fields(robocopyCredentials.Switches)...)
 
+	// Limit the bandwidth to the limit that applies at the start of the backup
+	switches = append(switches, robocopyInterPacketGapArgs(limits.BandwidthAt(time.Now()))...)
+
 	// Add file and folder excludes
 	for _, file := range input.RobocopyFileExcludes {
 		switches = append(switches, "/XF", file)
@@ -155,26 +154,64 @@ func executeBackupInvocation(config model.ConfigFile, robocopyFolders [][]string
 		switches = append(switches, "/XD", folder)
 	}
 
+	jobs := []runbackup.FolderJob{}
+
 	for _, folderTuple := range robocopyFolders {
 
 		srcFolder, destFolder := folderTuple[0], folderTuple[1]
 
-		cliInvocation := []string{
+		cliInvocation := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+		cliInvocation = append(cliInvocation,
 			"robocopy",
 			srcFolder,
 			destFolder,
-		}
+		)
 		cliInvocation = append(cliInvocation, switches...)
 
-		robocopyDI := util.DirectInvocation{
-			Args:                 cliInvocation,
-			EnvironmentVariables: map[string]string{},
-		}
+		jobs = append(jobs, runbackup.FolderJob{
+			Source: srcFolder,
+			Dest:   destFolder,
+			Run: func(outputPrefix string) error {
 
-		if err := robocopyDI.Execute(); err != nil {
-			return err
-		}
+				robocopyDI := util.DirectInvocation{
+					Args:                 cliInvocation,
+					EnvironmentVariables: map[string]string{},
+					OutputPrefix:         outputPrefix,
+					ClassifyExitCode:     classifyRobocopyExitCode,
+				}
+
+				return robocopyDI.Execute(ctx)
+			},
+		})
+
+	}
+
+	// On failure, the error is recorded and the remaining folders are still backed up
+	runResult, err := runbackup.ExecuteFolderJobsWithCheckpoint(configFilePath, jobs, concurrency, options)
+	if err != nil {
+		return err
+	}
+
+	runResult.PrintSummary(os.Stdout)
+
+	if err := runResult.Error(); err != nil {
+		return err
+	}
+
+	sourceFolders := []string{}
+	for _, folderTuple := range robocopyFolders {
+		sourceFolders = append(sourceFolders, folderTuple[0])
+	}
+
+	// Robocopy folder excludes ('/XD') only match folders
+	excludes := append([]string{}, input.RobocopyFileExcludes...)
+	for _, folder := range input.RobocopyFolderExcludes {
+		excludes = append(excludes, folder+string(filepath.Separator))
+	}
 
+	// The manifest is compared with those of other backups by 'diff'
+	if err := runbackup.RecordManifest(configFilePath, sourceFolders, util.NewExcludeMatcher(excludes)); err != nil {
+		fmt.Println("Warning: unable to record the backup manifest:", err)
 	}
 
 	return nil
@@ -1,6 +1,7 @@
 package robocopy
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -8,6 +9,6 @@ func (RobocopyBackend) SupportsRun() bool {
 	return false
 }
 
-func (RobocopyBackend) Run(path string, args []string) error {
+func (RobocopyBackend) Run(ctx context.Context, path string, args []string) error {
 	return fmt.Errorf("unsupported")
 }
@@ -0,0 +1,16 @@
+package robocopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (RobocopyBackend) SupportsSnapshots() bool {
+	return false
+}
+
+func (RobocopyBackend) Snapshots(ctx context.Context, path string) ([]model.Snapshot, error) {
+	return nil, fmt.Errorf("unsupported")
+}
@@ -2,8 +2,10 @@ package robocopy
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
 )
 
 func extractAndValidateConfigFile(path string) (model.ConfigFile, error) {
@@ -24,3 +26,62 @@ func extractAndValidateConfigFile(path string) (model.ConfigFile, error) {
 
 	return config, nil
 }
+
+// robocopyExitCodeBits are the bit flags of a robocopy exit code, in ascending order.
+// - Exit codes 0-7 indicate success; 8 and above indicate at least one failure.
+var robocopyExitCodeBits = []struct {
+	bit         int
+	description string
+}{
+	{1, "files copied"},
+	{2, "extra files or directories detected"},
+	{4, "mismatched files or directories detected"},
+	{8, "some files or directories could not be copied"},
+	{16, "serious error, no files were copied"},
+}
+
+// robocopyFailureExitCode is the lowest robocopy exit code that indicates a failure.
+const robocopyFailureExitCode = 8
+
+// classifyRobocopyExitCode interprets a robocopy exit code: codes 0-7 are reported as information, 8 and above are failures.
+func classifyRobocopyExitCode(exitCode int) util.ExitCodeResult {
+
+	if exitCode < 0 {
+		return util.ExitCodeResult{Success: false, Description: "unknown exit code"}
+	}
+
+	if exitCode == 0 {
+		return util.ExitCodeResult{Success: true, Description: "no files copied, source and destination are in sync"}
+	}
+
+	descriptions := []string{}
+	for _, exitCodeBit := range robocopyExitCodeBits {
+		if exitCode&exitCodeBit.bit != 0 {
+			descriptions = append(descriptions, exitCodeBit.description)
+		}
+	}
+
+	return util.ExitCodeResult{
+		Success:     exitCode < robocopyFailureExitCode,
+		Description: strings.Join(descriptions, ", "),
+	}
+}
+
+// robocopyBytesPerPacket is the size of the blocks that robocopy sends, between which the /IPG inter-packet gap is inserted.
+const robocopyBytesPerPacket = 64 * 1024
+
+// robocopyInterPacketGapArgs returns the robocopy /IPG argument that approximates the upload limit: waiting 64*1000/KiBps
+// milliseconds between each 64 KiB block limits the rate to (at most) the given KiB/s.
+func robocopyInterPacketGapArgs(limit model.BandwidthLimit) []string {
+
+	if limit.UploadKiBps <= 0 {
+		return []string{}
+	}
+
+	gapMilliseconds := (robocopyBytesPerPacket / 1024) * 1000 / limit.UploadKiBps
+	if gapMilliseconds < 1 {
+		gapMilliseconds = 1
+	}
+
+	return []string{fmt.Sprintf("/IPG:%d", gapMilliseconds)}
+}
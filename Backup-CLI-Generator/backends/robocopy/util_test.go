@@ -0,0 +1,93 @@
+package robocopy
+
+import (
+	"testing"
+)
+
+func TestClassifyRobocopyExitCode(t *testing.T) {
+
+	for _, c := range []struct {
+		name                string
+		exitCode            int
+		expectSuccess       bool
+		expectedDescription string
+	}{
+		{
+			name:                "no change",
+			exitCode:            0,
+			expectSuccess:       true,
+			expectedDescription: "no files copied, source and destination are in sync",
+		},
+		{
+			name:                "files copied",
+			exitCode:            1,
+			expectSuccess:       true,
+			expectedDescription: "files copied",
+		},
+		{
+			name:                "copied, extra and mismatched",
+			exitCode:            7,
+			expectSuccess:       true,
+			expectedDescription: "files copied, extra files or directories detected, mismatched files or directories detected",
+		},
+		{
+			name:                "copy failures",
+			exitCode:            8,
+			expectSuccess:       false,
+			expectedDescription: "some files or directories could not be copied",
+		},
+		{
+			name:                "copied with copy failures",
+			exitCode:            9,
+			expectSuccess:       false,
+			expectedDescription: "files copied, some files or directories could not be copied",
+		},
+		{
+			name:                "serious error",
+			exitCode:            16,
+			expectSuccess:       false,
+			expectedDescription: "serious error, no files were copied",
+		},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+			result := classifyRobocopyExitCode(c.exitCode)
+
+			if result.Success != c.expectSuccess {
+				t.Errorf("Success values do not match: %v %v", result.Success, c.expectSuccess)
+			}
+
+			if result.Description != c.expectedDescription {
+				t.Errorf("Descriptions do not match: '%v' '%v'", result.Description, c.expectedDescription)
+			}
+		})
+
+	}
+
+}
+
+func TestClassifyRobocopyCheckExitCode(t *testing.T) {
+
+	for _, c := range []struct {
+		name          string
+		exitCode      int
+		expectSuccess bool
+	}{
+		{name: "in sync", exitCode: 0, expectSuccess: true},
+		{name: "files would be copied", exitCode: 1, expectSuccess: false},
+		{name: "extra files only", exitCode: 2, expectSuccess: true},
+		{name: "mismatched files", exitCode: 4, expectSuccess: false},
+		{name: "failure", exitCode: 8, expectSuccess: false},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+			result := classifyRobocopyCheckExitCode(c.exitCode)
+
+			if result.Success != c.expectSuccess {
+				t.Errorf("Success values do not match: %v %v (%s)", result.Success, c.expectSuccess, result.Description)
+			}
+		})
+
+	}
+
+}
@@ -0,0 +1,16 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsCheck() bool {
+	return false
+}
+
+func (SampleBackend) Check(ctx context.Context, path string, options model.CheckOptions) error {
+	return fmt.Errorf("unsupported")
+}
@@ -0,0 +1,16 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsDiff() bool {
+	return false
+}
+
+func (SampleBackend) Diff(ctx context.Context, path string, options model.DiffOptions) ([]model.SnapshotDiff, error) {
+	return nil, fmt.Errorf("unsupported")
+}
@@ -0,0 +1,15 @@
+package sample
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsFolderPairs() bool {
+	return false
+}
+
+func (SampleBackend) FolderPairs(path string) ([]model.FolderPair, error) {
+	return nil, fmt.Errorf("unsupported")
+}
@@ -0,0 +1,13 @@
+package sample
+
+import (
+	"fmt"
+)
+
+func (SampleBackend) SupportsGenerateGraph() bool {
+	return false
+}
+
+func (SampleBackend) GenerateGraph(path string) (string, error) {
+	return "", fmt.Errorf("unsupported")
+}
@@ -0,0 +1,16 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsInitRepo() bool {
+	return false
+}
+
+func (SampleBackend) InitRepo(ctx context.Context, path string, options model.InitRepoOptions) error {
+	return fmt.Errorf("unsupported")
+}
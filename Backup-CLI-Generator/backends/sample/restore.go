@@ -0,0 +1,16 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsRestore() bool {
+	return false
+}
+
+func (SampleBackend) Restore(ctx context.Context, path string, options model.RestoreOptions) error {
+	return fmt.Errorf("unsupported")
+}
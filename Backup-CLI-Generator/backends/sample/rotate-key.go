@@ -0,0 +1,20 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsRotateKey() bool {
+	return false
+}
+
+func (SampleBackend) AddKey(ctx context.Context, path string, newPassword string) (model.KeyRotation, error) {
+	return model.KeyRotation{}, fmt.Errorf("unsupported")
+}
+
+func (SampleBackend) RemoveOldKey(ctx context.Context, path string, rotation model.KeyRotation) error {
+	return fmt.Errorf("unsupported")
+}
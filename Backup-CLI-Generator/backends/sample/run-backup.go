@@ -1,16 +1,19 @@
 package sample
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
 )
 
 func (SampleBackend) SupportsBackup() bool {
 	return false
 }
 
-func (SampleBackend) Backup(path string, rehashSource bool) error {
+func (SampleBackend) Backup(ctx context.Context, path string, options model.BackupOptions) error {
 
-	if rehashSource {
+	if options.RehashSource {
 		return fmt.Errorf("unsupported flag: rehash source")
 	}
 	return fmt.Errorf("unsupported")
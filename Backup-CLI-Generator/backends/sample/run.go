@@ -1,6 +1,7 @@
 package sample
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -8,6 +9,6 @@ func (SampleBackend) SupportsRun() bool {
 	return false
 }
 
-func (SampleBackend) Run(path string, args []string) error {
+func (SampleBackend) Run(ctx context.Context, path string, args []string) error {
 	return fmt.Errorf("unsupported")
 }
@@ -0,0 +1,16 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (SampleBackend) SupportsSnapshots() bool {
+	return false
+}
+
+func (SampleBackend) Snapshots(ctx context.Context, path string) ([]model.Snapshot, error) {
+	return nil, fmt.Errorf("unsupported")
+}
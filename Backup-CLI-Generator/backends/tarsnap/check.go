@@ -0,0 +1,79 @@
+package tarsnap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (TarsnapBackend) SupportsCheck() bool {
+	return true
+}
+
+// Check verifies the tarsnap metadata with 'tarsnap --fsck'. As tarsnap cannot read a subset of the data, a standard check
+// is the same as a quick check, and a deep check additionally reads the whole of the newest archive (with 'tarsnap -t').
+func (TarsnapBackend) Check(ctx context.Context, path string, options model.CheckOptions) error {
+
+	dataPercent, err := options.DataPercent()
+	if err != nil {
+		return err
+	}
+
+	if options.Level == model.CheckLevelDeep && dataPercent != 100 {
+		return fmt.Errorf("tarsnap does not support reading a percentage of the data")
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	tarsnapCredentials, err := config.GetTarsnapCredential()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(tarsnapCredentials.ConfigFilePath); os.IsNotExist(err) {
+		return fmt.Errorf("tarsnap config path does not exist: '%s'", tarsnapCredentials.ConfigFilePath)
+	}
+
+	fsckDI := newTarsnapDirectInvocation(config, []string{"tarsnap", "--configfile", tarsnapCredentials.ConfigFilePath, "--fsck"})
+	if err := fsckDI.Execute(ctx); err != nil {
+		return err
+	}
+
+	if options.Level != model.CheckLevelDeep {
+		return nil
+	}
+
+	archives, err := listTarsnapArchives(ctx, config, tarsnapCredentials.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	if len(archives) == 0 {
+		return fmt.Errorf("no archives found")
+	}
+
+	archive, err := model.SelectSnapshot(archives, model.SnapshotSelector{Type: model.SnapshotSelectLatest})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Reading archive:", archive.ID)
+
+	// The file list is not output, as it may be very large
+	readDI := newTarsnapDirectInvocation(config, []string{"tarsnap", "--configfile", tarsnapCredentials.ConfigFilePath, "-t", "-f", archive.ID})
+
+	output, err := readDI.ExecuteWithOutput(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Read %d entries of archive %s\n", bytes.Count(output, []byte("\n")), archive.ID)
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package tarsnap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (TarsnapBackend) SupportsDiff() bool {
+	return false
+}
+
+func (TarsnapBackend) Diff(ctx context.Context, path string, options model.DiffOptions) ([]model.SnapshotDiff, error) {
+	return nil, fmt.Errorf("unsupported")
+}
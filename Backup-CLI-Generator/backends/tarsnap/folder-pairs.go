@@ -0,0 +1,15 @@
+package tarsnap
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (TarsnapBackend) SupportsFolderPairs() bool {
+	return false
+}
+
+func (TarsnapBackend) FolderPairs(path string) ([]model.FolderPair, error) {
+	return nil, fmt.Errorf("unsupported")
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -48,6 +50,18 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		return "", err
 	}
 
+	nodes, err := generateBackupNodesFromConfigFile(configFilePath, config, dryRun)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.ToString()
+}
+
+// generateBackupNodesFromConfigFile returns the text nodes of the backup script of the config file. Monitor folders are not
+// checked, so that the graph of the nodes can be generated regardless.
+func generateBackupNodesFromConfigFile(configFilePath string, config model.ConfigFile, dryRun bool) (*util.TextNodes, error) {
+
 	nodes := util.NewTextNodes()
 
 	cmds.AddGenericPrefixNode(nodes)
@@ -57,7 +71,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		backupDateTime := nodes.NewTextNode()
 
 		if config.Metadata.Name == "" {
-			return "", fmt.Errorf("if metadata is specified, then name must be specified")
+			return nil, fmt.Errorf("if metadata is specified, then name must be specified")
 		}
 
 		if config.Metadata.AppendDateTime {
@@ -87,7 +101,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 
 			expandedValue, err := util.Expand(exclude, config.Substitutions)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			if nodes.IsWindows() {
@@ -104,7 +118,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		foldersNode := nodes.NewTextNode()
 
 		if len(config.Folders) == 0 {
-			return "", errors.New("at least one folder is required")
+			return nil, errors.New("at least one folder is required")
 		}
 
 		foldersNode.Out("")
@@ -114,7 +128,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 		// - This function also updates kopiaPolicyExcludes, if applicable.
 		processedFolders, err := generate.PopulateProcessedFolders(model.Tarsnap, config.Folders, config.Substitutions, map[string][]string{})
 		if err != nil {
-			return "", fmt.Errorf("unable to populateProcessedFolder: %v", err)
+			return nil, fmt.Errorf("unable to populateProcessedFolder: %v", err)
 		}
 
 		for index, processedFolder := range processedFolders {
@@ -143,7 +157,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	// Uses TODO, EXCLUDES, BACKUP_DATE_TIME, from above
 	invocationNode, err := generateBackupInvocationNode(config, dryRun, nodes)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	suffixNode := nodes.NewTextNode()
 	suffixNode.Out()
@@ -151,7 +165,7 @@ func generateBackupScriptFromConfigFile(configFilePath string, config model.Conf
 	suffixNode.Out("backup-cli check \"" + configFilePath + "\" " + suffixNode.Env("SCRIPTPATH"))
 	suffixNode.AddDependency(invocationNode)
 
-	return nodes.ToString()
+	return nodes, nil
 }
 
 func generateBackupInvocationNode(config model.ConfigFile, dryRun bool, textNodes *util.TextNodes) (*util.TextNode, error) {
@@ -186,15 +200,33 @@ func generateBackupInvocationNode(config model.ConfigFile, dryRun bool, textNode
 		excludesSubstring = textNode.Env("EXCLUDES") + " "
 	}
 
+	limits, err := config.GetLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	ioPrioritySubstring := ""
+	if prefix := util.IOPriorityCommandPrefix(limits, runtime.GOOS); len(prefix) > 0 {
+		ioPrioritySubstring = strings.Join(prefix, " ") + " "
+	}
+
+	limitsSubstring := ""
+	for _, arg := range tarsnapBandwidthLimitArgs(limits.DefaultBandwidth()) {
+		limitsSubstring += arg + " "
+	}
+
 	cliInvocation := fmt.Sprintf(
-		"tarsnap --humanize-numbers --configfile \"%s\" -c %s%s -f \"%s\" %s",
+		"%starsnap --humanize-numbers --configfile \"%s\" %s-c %s%s -f \"%s\" %s",
+		ioPrioritySubstring,
 		tarsnapCredentials.ConfigFilePath,
+		limitsSubstring,
 		dryRunSubstring,
 		excludesSubstring,
 		backupName,
 		textNode.Env("TODO"))
 
 	textNode.Out()
+	util.AddLimitsScheduleNote(textNode, limits)
 
 	if textNodes.IsWindows() {
 		textNode.Out(cliInvocation)
@@ -0,0 +1,20 @@
+package tarsnap
+
+func (TarsnapBackend) SupportsGenerateGraph() bool {
+	return true
+}
+
+func (TarsnapBackend) GenerateGraph(path string) (string, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	nodes, err := generateBackupNodesFromConfigFile(path, config, false)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.ToDOT()
+}
@@ -0,0 +1,92 @@
+package tarsnap
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (TarsnapBackend) SupportsInitRepo() bool {
+	return true
+}
+
+// InitRepo verifies that the key file of the tarsnap config file exists. Tarsnap has no repository to create: the key file is
+// created (and the machine registered) with 'tarsnap-keygen', which requires the password of the tarsnap account.
+func (TarsnapBackend) InitRepo(ctx context.Context, path string, options model.InitRepoOptions) error {
+
+	if options.ApplyPolicy {
+		return fmt.Errorf("tarsnap does not support retention or compression policies")
+	}
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	tarsnapCredentials, err := config.GetTarsnapCredential()
+	if err != nil {
+		return err
+	}
+
+	configFile, err := os.Open(tarsnapCredentials.ConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to read tarsnap config path: %w", err)
+	}
+	defer configFile.Close()
+
+	keyFilePath, err := parseTarsnapKeyFilePath(configFile)
+	if err != nil {
+		return fmt.Errorf("unable to read tarsnap config path '%s': %w", tarsnapCredentials.ConfigFilePath, err)
+	}
+
+	if keyFilePath == "" {
+		return fmt.Errorf("tarsnap config path '%s' does not specify a 'keyfile'", tarsnapCredentials.ConfigFilePath)
+	}
+
+	if strings.HasPrefix(keyFilePath, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		keyFilePath = filepath.Join(homeDir, keyFilePath[2:])
+	}
+
+	if _, err := os.Stat(keyFilePath); os.IsNotExist(err) {
+		return fmt.Errorf("tarsnap key file '%s' does not exist: create it with 'tarsnap-keygen --keyfile %s --user (account email) --machine (machine name)'", keyFilePath, keyFilePath)
+	} else if err != nil {
+		return err
+	}
+
+	fmt.Printf("Tarsnap key file '%s' exists; nothing to initialize.\n", keyFilePath)
+
+	return nil
+}
+
+// parseTarsnapKeyFilePath returns the value of the 'keyfile' option of the tarsnap config file content, or "" if not specified.
+func parseTarsnapKeyFilePath(reader io.Reader) (string, error) {
+
+	res := ""
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		option, value, _ := strings.Cut(line, " ")
+		if option == "keyfile" {
+			// As with tarsnap, a later value overrides an earlier value
+			res = strings.TrimSpace(value)
+		}
+	}
+
+	return res, scanner.Err()
+}
@@ -0,0 +1,33 @@
+package tarsnap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTarsnapKeyFilePath(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"keyfile", "cachedir /var/cache/tarsnap\nkeyfile /root/tarsnap.key\nnodump\n", "/root/tarsnap.key"},
+		{"commented out", "# keyfile /root/old.key\nkeyfile ~/tarsnap.key\n", "~/tarsnap.key"},
+		{"no keyfile", "cachedir /var/cache/tarsnap\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			actual, err := parseTarsnapKeyFilePath(strings.NewReader(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != test.expected {
+				t.Errorf("expected '%s', got '%s'", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package tarsnap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/restore"
+)
+
+func (TarsnapBackend) SupportsRestore() bool {
+	return true
+}
+
+func (TarsnapBackend) Restore(ctx context.Context, path string, options model.RestoreOptions) error {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	tarsnapCredentials, err := config.GetTarsnapCredential()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(tarsnapCredentials.ConfigFilePath); os.IsNotExist(err) {
+		return fmt.Errorf("tarsnap config path does not exist: '%s'", tarsnapCredentials.ConfigFilePath)
+	}
+
+	if err := restore.PrepareTarget(options.Target, options.Force); err != nil {
+		return err
+	}
+
+	archives, err := listTarsnapArchives(ctx, config, tarsnapCredentials.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	archive, err := model.SelectSnapshot(archives, options.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restoring archive %s (%s)\n", archive.ID, archive.Time.Format(time.RFC3339))
+
+	// tar strips the leading '/' of paths when archiving, so extracting relative to the target folder restores each file to
+	// the target folder joined with its original absolute path
+	execInvocation := []string{
+		"tarsnap",
+		"--humanize-numbers",
+		"--configfile",
+		tarsnapCredentials.ConfigFilePath,
+		"-x",
+		"-f", archive.ID,
+		"-C", options.Target,
+	}
+
+	for _, includePath := range options.Paths {
+		execInvocation = append(execInvocation, strings.TrimPrefix(filepath.ToSlash(includePath), "/"))
+	}
+
+	return newTarsnapDirectInvocation(config, execInvocation).Execute(ctx)
+}
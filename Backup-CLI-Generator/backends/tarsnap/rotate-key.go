@@ -0,0 +1,20 @@
+package tarsnap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (TarsnapBackend) SupportsRotateKey() bool {
+	return false
+}
+
+func (TarsnapBackend) AddKey(ctx context.Context, path string, newPassword string) (model.KeyRotation, error) {
+	return model.KeyRotation{}, fmt.Errorf("unsupported")
+}
+
+func (TarsnapBackend) RemoveOldKey(ctx context.Context, path string, rotation model.KeyRotation) error {
+	return fmt.Errorf("unsupported")
+}
@@ -1,8 +1,11 @@
 package tarsnap
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"runtime"
+	"time"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
@@ -14,17 +17,31 @@ func (TarsnapBackend) SupportsBackup() bool {
 	return true
 }
 
-func (TarsnapBackend) Backup(path string, rehashSource bool) error {
+func (TarsnapBackend) Backup(ctx context.Context, path string, options model.BackupOptions) error {
 
-	if rehashSource {
+	if options.RehashSource {
 		return fmt.Errorf("unsupported flag: rehash source")
 	}
 
+	if options.Parallel > 1 {
+		return fmt.Errorf("unsupported flag: parallel")
+	}
+
+	if options.Resume {
+		return fmt.Errorf("unsupported flag: resume")
+	}
+
 	config, err := extractAndValidateConfigFile(path)
 	if err != nil {
 		return err
 	}
 
+	_, endBackup, err := runbackup.BeginBackup(ctx, path, config, options)
+	if err != nil {
+		return err
+	}
+	defer endBackup()
+
 	if err := runBackupFromConfigFile(path, config, false); err != nil {
 		return err
 	}
@@ -122,14 +139,22 @@ func executeBackupInvocation(config model.ConfigFile, dryRun bool, input runback
 		}
 	}
 
-	execInvocation := []string{
+	limits, err := config.GetLimits()
+	if err != nil {
+		return err
+	}
+
+	execInvocation := util.IOPriorityCommandPrefix(limits, runtime.GOOS)
+
+	execInvocation = append(execInvocation,
 		"tarsnap",
 		"--humanize-numbers",
 		"--configfile",
 		tarsnapCredentials.ConfigFilePath,
 		"-c",
-	}
+	)
 
+	execInvocation = append(execInvocation, tarsnapBandwidthLimitArgs(limits.BandwidthAt(time.Now()))...)
 	execInvocation = append(execInvocation, dryRunSubstring...)
 	execInvocation = append(execInvocation, excludesSubstring...)
 
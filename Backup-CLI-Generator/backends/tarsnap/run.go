@@ -1,6 +1,7 @@
 package tarsnap
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -8,6 +9,6 @@ func (TarsnapBackend) SupportsRun() bool {
 	return false
 }
 
-func (TarsnapBackend) Run(path string, args []string) error {
+func (TarsnapBackend) Run(ctx context.Context, path string, args []string) error {
 	return fmt.Errorf("unsupported")
 }
@@ -0,0 +1,88 @@
+package tarsnap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func (TarsnapBackend) SupportsSnapshots() bool {
+	return true
+}
+
+// Snapshots returns the archives of the tarsnap config file; archive sizes are not reported, as tarsnap can only report them
+// by requesting the statistics of each archive.
+func (TarsnapBackend) Snapshots(ctx context.Context, path string) ([]model.Snapshot, error) {
+
+	config, err := extractAndValidateConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tarsnapCredentials, err := config.GetTarsnapCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(tarsnapCredentials.ConfigFilePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("tarsnap config path does not exist: '%s'", tarsnapCredentials.ConfigFilePath)
+	}
+
+	return listTarsnapArchives(ctx, config, tarsnapCredentials.ConfigFilePath)
+}
+
+// tarsnapArchiveTimeFormat is the format of the archive creation time in the output of 'tarsnap --list-archives -v'
+const tarsnapArchiveTimeFormat = "2006-01-02 15:04:05"
+
+// listTarsnapArchives returns the archives of the tarsnap config file; the ID and tag of each archive is the archive name.
+func listTarsnapArchives(ctx context.Context, config model.ConfigFile, tarsnapConfigFilePath string) ([]model.Snapshot, error) {
+
+	listDI := newTarsnapDirectInvocation(config, []string{"tarsnap", "--configfile", tarsnapConfigFilePath, "--list-archives", "-v"})
+
+	output, err := listDI.ExecuteWithOutput(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTarsnapArchives(output)
+}
+
+// parseTarsnapArchives parses the output of 'tarsnap --list-archives -v'.
+func parseTarsnapArchives(output []byte) ([]model.Snapshot, error) {
+
+	res := []model.Snapshot{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		// Each line is: (archive name) TAB (creation time)
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("unexpected tarsnap archive list line: '%s'", line)
+		}
+
+		creationTime, err := time.ParseInLocation(tarsnapArchiveTimeFormat, fields[1], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse tarsnap archive time '%s': %w", fields[1], err)
+		}
+
+		res = append(res, model.Snapshot{
+			ID:   fields[0],
+			Time: creationTime,
+			Tags: []string{fields[0]},
+		})
+	}
+
+	return res, scanner.Err()
+}
@@ -0,0 +1,29 @@
+package tarsnap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTarsnapArchives(t *testing.T) {
+
+	output := "home-2024-01-01\t2024-01-01 02:00:13\r\nhome-2024-01-02\t2024-01-02 02:00:09\n\n"
+
+	archives, err := parseTarsnapArchives([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archives) != 2 {
+		t.Fatalf("unexpected number of archives: %d", len(archives))
+	}
+
+	expectedTime := time.Date(2024, 1, 2, 2, 0, 9, 0, time.Local)
+	if archives[1].ID != "home-2024-01-02" || !archives[1].Time.Equal(expectedTime) || archives[1].Tags[0] != archives[1].ID {
+		t.Errorf("unexpected archive: %v", archives[1])
+	}
+
+	if _, err := parseTarsnapArchives([]byte("no-time-column\n")); err == nil {
+		t.Errorf("expected malformed output to be rejected")
+	}
+}
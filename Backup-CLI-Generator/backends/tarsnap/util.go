@@ -2,8 +2,10 @@ package tarsnap
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
 )
 
 func extractAndValidateConfigFile(path string) (model.ConfigFile, error) {
@@ -28,3 +30,31 @@ func extractAndValidateConfigFile(path string) (model.ConfigFile, error) {
 
 	return config, nil
 }
+
+// tarsnapBandwidthLimitArgs returns the tarsnap arguments for the bandwidth limit; tarsnap's limits are in bytes per second.
+func tarsnapBandwidthLimitArgs(limit model.BandwidthLimit) []string {
+
+	res := []string{}
+
+	if limit.UploadKiBps > 0 {
+		res = append(res, "--maxbw-rate-up", strconv.Itoa(limit.UploadKiBps*1024))
+	}
+
+	if limit.DownloadKiBps > 0 {
+		res = append(res, "--maxbw-rate-down", strconv.Itoa(limit.DownloadKiBps*1024))
+	}
+
+	return res
+}
+
+// newTarsnapDirectInvocation returns a tarsnap invocation with the given arguments, which uses the retry policy of the config file.
+func newTarsnapDirectInvocation(config model.ConfigFile, args []string) util.DirectInvocation {
+	return util.DirectInvocation{
+		Args:                 args,
+		EnvironmentVariables: map[string]string{},
+		Retry:                config.Retry,
+		IsRetryable: func(exitCode int, stderr string) bool {
+			return util.ContainsAny(stderr, util.TransientNetworkErrorPatterns)
+		},
+	}
+}
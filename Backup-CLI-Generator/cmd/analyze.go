@@ -1,365 +1,307 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
-	"slices"
-	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/analyze"
 	"github.com/spf13/cobra"
-	"golang.org/x/exp/maps"
 )
 
 var analyzeCmd = &cobra.Command{
-	Use:   "analyze",
-	Short: "...",
-	Long:  "...",
+	Use:   "analyze [config files, folders of config files, or globs]",
+	Short: "Report which files and folders are backed up by which config files",
+	Long: `Report which files and folders are backed up by which config files (and to which repositories or destination folders),
+honouring the global, folder and robocopy excludes of each config file. If no config files are specified, the config files of
+the current folder are analyzed.
+
+Each root folder is walked down to '--depth' levels: by default, the roots are the folders and monitor folders of the config
+files, or the folders specified with '--root'.
+
+Filters (--filter):
+- all: all files and folders (the default)
+- uncovered: files and folders that are not backed up by any config file
+- single-copy: files and folders that are backed up to only one repository or destination folder
+With '--min-copies N', only files and folders that are backed up to at least N repositories or destination folders are reported.
+
+The output is a tree by default, or CSV or JSON with '--output csv' or '--output json'.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		newAnalysis(args)
-		// simpleAnalysis()
-	},
-}
 
-func newAnalysis(configFiles []string) {
-
-	isAbsolutePath := func(in string) bool {
-		if runtime.GOOS == "windows" {
-			indexOfColonSlash := strings.Index(in, ":\\")
-			if indexOfColonSlash == 1 {
-				return true
-			} else {
-				return false
-			}
-		} else {
-			return strings.HasPrefix(in, "/")
+		if len(args) == 0 {
+			args = []string{"."}
 		}
-	}
-
-	isExcludedPath := func(filename string, exclude string) bool {
 
-		if isAbsolutePath(exclude) {
-			reportCLIErrorAndExit(fmt.Errorf("absolute path in global exclude not supported"))
-			return false
+		if analyzeOutput != "tree" && analyzeOutput != "csv" && analyzeOutput != "json" {
+			reportCLIErrorAndExit(fmt.Errorf("unrecognized output format '%s': expected 'tree', 'csv' or 'json'", analyzeOutput))
+			return
 		}
 
-		exclude, _ = strings.CutSuffix(exclude, string(os.PathSeparator))
-
-		exclude, _ = strings.CutSuffix(exclude, "/")
-
-		if exclude == filename {
-			fmt.Println(filename, "is excluded")
-			return true
+		matches, err := newCoverageFilter(analyzeFilter, analyzeMinCopies)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
 		}
 
-		return false
-
-	}
-
-	type expandedFolderPath struct {
-		folderPath string
-		excludes   []string
-	}
-
-	type NewConfigFileEntry struct {
-		cfe                 ConfigFileEntry
-		expandedFolderPaths []expandedFolderPath
-	}
-
-	backupFolders := map[string][]NewConfigFileEntry{}
-
-	// var configFileStructs []NewConfigFileEntry
-
-	for _, configFile := range configFiles {
+		configFiles, err := discoverConfigFiles(args)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
 
-		model, err := model.ReadConfigFile(configFile)
+		sources, defaultRoots, err := readCoverageSources(configFiles)
 		if err != nil {
 			reportCLIErrorAndExit(err)
 			return
 		}
 
-		ncfe := NewConfigFileEntry{
-			cfe: ConfigFileEntry{
-				path:       configFile,
-				configFile: model,
-			},
+		roots := analyzeRoots
+		if len(roots) == 0 {
+			roots = defaultRoots
 		}
 
-		for _, folder := range ncfe.cfe.configFile.Folders {
+		coverages, err := analyze.ComputeCoverage(sources, roots, analyzeDepth)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
 
-			expandedPath, err := util.Expand(folder.Path, ncfe.cfe.configFile.Substitutions)
-			if err != nil {
-				reportCLIErrorAndExit(err)
-				return
+		matched := []analyze.PathCoverage{}
+		for _, coverage := range coverages {
+			if matches(coverage) {
+				matched = append(matched, coverage)
 			}
-			if expandedPath == "" {
-				reportCLIErrorAndExit(fmt.Errorf("expanded path is empty"))
-				return
-			}
-
-			ncfe.expandedFolderPaths = append(ncfe.expandedFolderPaths, expandedFolderPath{
-				folderPath: expandedPath,
-				excludes:   folder.Excludes,
-			})
 		}
 
-		// configFileStructs = append(configFileStructs, ncfe)
-
-		for _, expandedFolder := range ncfe.expandedFolderPaths {
-
-			if _, err := os.Stat(expandedFolder.folderPath); err != nil {
+		switch analyzeOutput {
+		case "json":
+			content, err := json.MarshalIndent(matched, "", "  ")
+			if err != nil {
 				reportCLIErrorAndExit(err)
 				return
 			}
+			fmt.Println(string(content))
 
-			fileEntries, err := os.ReadDir(expandedFolder.folderPath)
-			if err != nil {
+		case "csv":
+			if err := printCoverageCSV(matched); err != nil {
 				reportCLIErrorAndExit(err)
 				return
 			}
 
-		outer:
-			for _, fileEntry := range fileEntries {
-
-				for _, globalExclude := range ncfe.cfe.configFile.GlobalExcludes {
-
-					if isExcludedPath(fileEntry.Name(), globalExclude) {
-						continue outer
-					}
-				}
-
-				if ncfe.cfe.configFile.RobocopySettings != nil {
-					reportCLIErrorAndExit(fmt.Errorf("robocopy not supported"))
-					return
-				}
-
-				for _, exclude := range expandedFolder.excludes {
-
-					if isExcludedPath(fileEntry.Name(), exclude) {
-						continue outer
-					}
-
-				}
+		default:
+			printCoverageTree(coverages, matches)
+		}
+	},
+}
 
-				filePath := filepath.Join(expandedFolder.folderPath, fileEntry.Name())
+var (
+	analyzeDepth     int
+	analyzeRoots     []string
+	analyzeFilter    string
+	analyzeMinCopies int
+	analyzeOutput    string
+)
 
-				allFilePaths := []string{}
-				recursePath(filePath, 6, &allFilePaths)
+// readCoverageSources returns the coverage sources of the config files, and their folders and monitor folders (the default
+// roots to analyze).
+func readCoverageSources(configFiles []string) ([]analyze.CoverageSource, []string, error) {
 
-				for _, filePathEntry := range allFilePaths {
+	sources := []analyze.CoverageSource{}
+	roots := []string{}
 
-					backupFolders[normalizePath(filePathEntry)] = append(backupFolders[normalizePath(filePathEntry)], ncfe)
-				}
+	for _, configFile := range configFiles {
 
-			}
+		config, err := model.ReadConfigFile(configFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read '%s': %w", configFile, err)
 		}
-	}
 
-	backupFolderKeys := maps.Keys(backupFolders)
-	sort.Slice(backupFolderKeys, func(i, j int) bool {
-		return strings.ToLower(backupFolderKeys[i]) < strings.ToLower(backupFolderKeys[j])
-	})
-
-	for _, key := range backupFolderKeys {
+		backend, err := findBackendForConfigFile(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to locate backend implementation for '%s': %w", configFile, err)
+		}
 
-		v, exists := backupFolders[key]
-		if !exists {
-			reportCLIErrorAndExit(fmt.Errorf("key not found"))
-			return
+		folderPairs := []model.FolderPair{}
+		if backend.SupportsFolderPairs() {
+			if folderPairs, err = backend.FolderPairs(configFile); err != nil {
+				return nil, nil, fmt.Errorf("unable to read the folders of '%s': %w", configFile, err)
+			}
 		}
 
-		if len(v) != 1 {
-			continue
+		configSources, err := analyze.NewCoverageSources(configFile, config, folderPairs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read the folders of '%s': %w", configFile, err)
 		}
 
-		fmt.Print(key + " | ")
+		sources = append(sources, configSources...)
 
-		for _, ve := range v {
-			fmt.Print(ve.cfe.path + " ")
+		for _, source := range configSources {
+			roots = append(roots, source.Folder)
 		}
-		fmt.Println()
 
+		for _, monitorFolder := range config.MonitorFolders {
+			monitorFolderPath, err := util.Expand(monitorFolder.Path, config.Substitutions)
+			if err != nil {
+				return nil, nil, err
+			}
+			roots = append(roots, monitorFolderPath)
+		}
 	}
 
-	fmt.Println("backup keys size", len(backupFolderKeys))
+	return sources, roots, nil
 }
 
-func recursePath(absPath string, maxSlashes int, res *[]string) {
+// newCoverageFilter returns a function that returns true for the coverages that match the filter and minimum copies.
+func newCoverageFilter(filter string, minCopies int) (func(analyze.PathCoverage) bool, error) {
 
-	if len(strings.Split(absPath, "\\")) >= maxSlashes {
-		return
+	if minCopies < 0 {
+		return nil, fmt.Errorf("--min-copies must not be negative")
 	}
 
-	if fileInfo, err := os.Stat(absPath); err != nil {
-		reportCLIErrorAndExit(err)
-		return
-	} else if !fileInfo.IsDir() {
-		fullPath := absPath
-		*res = append(*res, fullPath)
-		return
+	if minCopies > 0 && filter != "all" {
+		return nil, fmt.Errorf("--min-copies may not be combined with --filter %s", filter)
 	}
 
-	fileEntries, err := os.ReadDir(absPath)
-	if err != nil {
-		fmt.Println("Warning: Unable to read:", absPath)
-		// reportCLIErrorAndExit(err)
-		return
-	}
-
-	for _, fileEntry := range fileEntries {
-
-		fullPath := filepath.Join(absPath, fileEntry.Name())
-
-		if fileEntry.IsDir() {
-			recursePath(fullPath, maxSlashes, res)
-		} else {
-			// Only add files to the list
-			*res = append(*res, fullPath)
-		}
+	switch filter {
+	case "all":
+		return func(coverage analyze.PathCoverage) bool {
+			return coverage.Copies() >= minCopies
+		}, nil
+	case "uncovered":
+		return func(coverage analyze.PathCoverage) bool {
+			return coverage.Copies() == 0 && !coverage.Partial
+		}, nil
+	case "single-copy":
+		return func(coverage analyze.PathCoverage) bool {
+			return coverage.Copies() == 1
+		}, nil
 	}
 
+	return nil, fmt.Errorf("unrecognized filter '%s': expected 'all', 'uncovered' or 'single-copy'", filter)
 }
 
-func normalizePath(in string) string {
-
-	if runtime.GOOS == "windows" {
-
-		indexOfColon := strings.Index(in, ":")
-		if indexOfColon == -1 {
-			return in
-		}
-
-		preColon := in[0:indexOfColon]
-		postColon := in[indexOfColon+1:]
-
-		return fmt.Sprintf("%s:%s", strings.ToLower(preColon), postColon)
-
+// coverageStatus returns a description of the number of copies of the path.
+func coverageStatus(coverage analyze.PathCoverage) string {
+
+	switch copies := coverage.Copies(); {
+	case copies == 1:
+		return "1 copy"
+	case copies > 1:
+		return fmt.Sprintf("%d copies", copies)
+	case coverage.Partial:
+		return "partial"
+	default:
+		return "UNCOVERED"
 	}
-
-	return in
-}
-
-type ConfigFileEntry struct {
-	path            string
-	configFile      model.ConfigFile
-	expandedFolders []string
 }
 
-func simpleAnalysis(configFiles []string) {
+// printCoverageTree prints the coverages that match (and, for context, the folders that contain them) as an indented tree
+// under each root.
+func printCoverageTree(coverages []analyze.PathCoverage, matches func(analyze.PathCoverage) bool) {
 
-	var configFileStructs []ConfigFileEntry
+	// The matching paths, and the folders that contain them
+	printed := map[string]bool{}
+	matchCount, uncoveredCount, singleCopyCount := 0, 0, 0
 
-	for _, configFile := range configFiles {
+	for _, coverage := range coverages {
 
-		model, err := model.ReadConfigFile(configFile)
-		if err != nil {
-			reportCLIErrorAndExit(err)
-			return
+		if !matches(coverage) {
+			continue
 		}
 
-		cfe := ConfigFileEntry{
-			path:       configFile,
-			configFile: model,
+		matchCount++
+		if coverage.Copies() == 0 && !coverage.Partial {
+			uncoveredCount++
+		} else if coverage.Copies() == 1 {
+			singleCopyCount++
 		}
 
-		for _, folder := range cfe.configFile.Folders {
-
-			expandedPath, err := util.Expand(folder.Path, cfe.configFile.Substitutions)
-			if err != nil {
-				reportCLIErrorAndExit(err)
-				return
-			}
-			if expandedPath == "" {
-				reportCLIErrorAndExit(fmt.Errorf("expanded path is empty"))
-				return
+		for path := coverage.Path; !printed[path]; path = filepath.Dir(path) {
+			printed[path] = true
+			if path == coverage.Root || path == filepath.Dir(path) {
+				break
 			}
-			cfe.expandedFolders = append(cfe.expandedFolders, expandedPath)
-
 		}
-
-		configFileStructs = append(configFileStructs, cfe)
-
 	}
 
-	folderToConfigFile := map[string][]ConfigFileEntry{}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tCOPIES\tCONFIG FILES (TARGETS)")
 
-	for _, config := range configFileStructs {
+	for _, coverage := range coverages {
 
-		for _, expandedFolder := range config.expandedFolders {
-
-			if _, err := os.Stat(expandedFolder); err != nil {
-				reportCLIErrorAndExit(err)
-				return
-			}
+		if !printed[coverage.Path] {
+			continue
+		}
 
-			folderToConfigFile[normalizePath(expandedFolder)] = append(folderToConfigFile[expandedFolder], config)
+		name := coverage.Path
+		if coverage.Depth > 0 {
+			name = strings.Repeat("  ", coverage.Depth) + filepath.Base(coverage.Path)
+		}
+		if coverage.IsDir && coverage.Depth > 0 {
+			name += string(filepath.Separator)
 		}
-	}
 
-	var mapKeys []string
+		configs := []string{}
+		for _, entry := range coverage.Coverage {
+			configs = append(configs, fmt.Sprintf("%s (%s)", filepath.Base(entry.ConfigPath), entry.Target))
+		}
 
-	for key := range folderToConfigFile {
-		mapKeys = append(mapKeys, key)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, coverageStatus(coverage), strings.Join(configs, ", "))
 	}
 
-	sort.Slice(mapKeys, func(i, j int) bool { return strings.ToLower(mapKeys[i]) < strings.ToLower(mapKeys[j]) })
-	// sort.Strings(mapKeys)
+	tw.Flush()
 
-	for _, child := range mapKeys {
-		for _, parent := range mapKeys {
-
-			if child == parent {
-				continue
-			}
-
-			normalizedParent, _ := strings.CutSuffix(parent, string(filepath.Separator))
-			normalizedParent = strings.ToLower(normalizedParent)
-			normalizedChild := strings.ToLower(child)
-
-			if strings.HasPrefix(normalizedChild, normalizedParent+string(filepath.Separator)) {
+	fmt.Printf("\n%d path(s): %d uncovered, %d single copy\n", matchCount, uncoveredCount, singleCopyCount)
+}
 
-				fmt.Println("! parent:", parent, "child:", child)
+func printCoverageCSV(coverages []analyze.PathCoverage) error {
 
-				folderToConfigFile[child] = append(folderToConfigFile[child], folderToConfigFile[parent]...)
-			}
+	writer := csv.NewWriter(os.Stdout)
 
-		}
+	if err := writer.Write([]string{"path", "isDir", "copies", "status", "configFiles", "targets"}); err != nil {
+		return err
 	}
 
-	for _, key := range mapKeys {
-		entries := folderToConfigFile[key]
-		fmt.Println()
-		fmt.Println(key + ":")
+	for _, coverage := range coverages {
 
-		pathMap := map[string]bool{}
-		for _, entry := range entries {
-			pathMap[entry.path] = true
+		configs, targets := []string{}, []string{}
+		for _, entry := range coverage.Coverage {
+			configs = append(configs, entry.ConfigPath)
+			targets = append(targets, entry.Target)
 		}
 
-		keys := maps.Keys(pathMap)
-		sort.Strings(keys)
-		keys = slices.Compact(keys)
-
-		for _, key := range keys {
-			fmt.Println("-", key)
+		if err := writer.Write([]string{
+			coverage.Path,
+			strconv.FormatBool(coverage.IsDir),
+			strconv.Itoa(coverage.Copies()),
+			coverageStatus(coverage),
+			strings.Join(configs, ";"),
+			strings.Join(targets, ";"),
+		}); err != nil {
+			return err
 		}
 	}
 
+	writer.Flush()
+
+	return writer.Error()
 }
 
 func init() {
-	rootCmd.AddCommand(analyzeCmd)
 
-	analyzeCmd.Args = func(cmd *cobra.Command, args []string) error {
+	analyzeCmd.Flags().IntVar(&analyzeDepth, "depth", 3, "Number of folder levels below each root to report")
+	analyzeCmd.Flags().StringArrayVar(&analyzeRoots, "root", nil, "Root folder to analyze (may be repeated); by default, the folders and monitor folders of the config files")
+	analyzeCmd.Flags().StringVar(&analyzeFilter, "filter", "all", "Report 'all', 'uncovered' or 'single-copy' files and folders")
+	analyzeCmd.Flags().IntVar(&analyzeMinCopies, "min-copies", 0, "Only report files and folders that are backed up to at least this many targets")
+	analyzeCmd.Flags().StringVarP(&analyzeOutput, "output", "o", "tree", "Output format: 'tree', 'csv' or 'json'")
 
-		// if len(args) != 1 {
-		// 	return fmt.Errorf("one argument required: (config file path)")
-		// }
+	rootCmd.AddCommand(analyzeCmd)
 
-		return nil
-	}
 }
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/analyze"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit --rules (rules file) [config files, folders of config files, or globs]",
+	Short: "Check that the config files back up paths to the required number of (offsite) repositories",
+	Long: `Check that the config files back up the paths of the audit rules file to the required number of repositories or
+destination folders, and list the files and folders that do not. If no config files are specified, the config files of the
+current folder are audited.
+
+Example rules file, requiring that everything under /home is backed up to at least 2 targets, at least 1 of them offsite:
+
+rules:
+- name: home
+  path: /home
+  minCopies: 2
+  minOffsiteCopies: 1
+  depth: 3           # number of folder levels below the path that are evaluated (default 3)
+  excludes:
+  - "*.tmp"
+
+Restic and kopia S3 repositories, tarsnap, and rclone remotes are offsite; restic REST repositories, robocopy, and rclone
+local destinations are not.
+
+Exits with code 7 if any path violates a rule.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if auditRulesFile == "" {
+			reportCLIErrorAndExit(fmt.Errorf("an audit rules file must be specified with --rules"))
+			return
+		}
+
+		if auditOutput != "table" && auditOutput != "json" {
+			reportCLIErrorAndExit(fmt.Errorf("unrecognized output format '%s': expected 'table' or 'json'", auditOutput))
+			return
+		}
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		rules, err := model.ReadAuditRules(auditRulesFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		configFiles, err := discoverConfigFiles(args)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		sources, _, err := readCoverageSources(configFiles)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		violations := []analyze.AuditViolation{}
+
+		for _, rule := range rules.Rules {
+			ruleViolations, err := analyze.EvaluateAuditRule(sources, rule)
+			if err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+			violations = append(violations, ruleViolations...)
+		}
+
+		if auditOutput == "json" {
+			content, err := json.MarshalIndent(violations, "", "  ")
+			if err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+			fmt.Println(string(content))
+
+		} else {
+			printAuditViolations(violations)
+			fmt.Printf("\n%d rule(s) evaluated against %d config file(s): %d violation(s)\n", len(rules.Rules), len(configFiles), len(violations))
+		}
+
+		if len(violations) > 0 {
+			reportCLIErrorAndExit(&util.ExitCodeError{Code: util.ExitCodeAuditViolation, Err: fmt.Errorf("%d path(s) violate the audit rules", len(violations))})
+			return
+		}
+	},
+}
+
+var (
+	auditRulesFile string
+	auditOutput    string
+)
+
+func printAuditViolations(violations []analyze.AuditViolation) {
+
+	if len(violations) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tPATH\tCOPIES\tOFFSITE\tREASON")
+
+	for _, violation := range violations {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", violation.Rule, violation.Path, violation.Copies, violation.OffsiteCopies, violation.Reason)
+	}
+
+	tw.Flush()
+}
+
+func init() {
+
+	auditCmd.Flags().StringVar(&auditRulesFile, "rules", "", "Audit rules file (YAML)")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "table", "Output format: 'table' or 'json'")
+
+	rootCmd.AddCommand(auditCmd)
+
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	backupall "github.com/jgwest/backup-cli/util/cmds/backup-all"
+	"github.com/spf13/cobra"
+)
+
+// backupAllCmd represents the backup-all command
+var backupAllCmd = &cobra.Command{
+	Use:   "backup-all (directory or glob)...",
+	Short: "Run the backup of every config file in a directory, or matching a glob",
+	Long: `Run the backup of every config file in a directory, or matching a glob.
+
+Config files are started in order of their 'priority' field (highest first), and a config
+file is not started until the config files listed in its 'after' field have succeeded.
+Config files that back up to the same repository are never run concurrently.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFilePaths, err := discoverConfigFiles(args)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		entries := []backupall.ConfigEntry{}
+		for _, configFilePath := range configFilePaths {
+
+			entry, err := newBackupAllConfigEntry(configFilePath)
+			if err != nil {
+				reportCLIErrorAndExit(fmt.Errorf("unable to process '%s': %w", configFilePath, err))
+				return
+			}
+
+			entries = append(entries, entry)
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		ctx := cmd.Context()
+
+		results, err := backupall.RunConfigs(ctx, entries, backupAllParallel, func(entry backupall.ConfigEntry) error {
+			return runBackupInSubprocess(ctx, executable, entry.Path)
+		})
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		backupall.PrintReport(os.Stdout, results)
+
+		if err := backupall.ResultsError(results); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+	},
+}
+
+var backupAllParallel int
+
+// discoverConfigFiles returns the absolute paths of the YAML files in the directories, or matching the globs, of args.
+func discoverConfigFiles(args []string) ([]string, error) {
+
+	pathMap := map[string]bool{}
+
+	for _, arg := range args {
+
+		if fileInfo, err := os.Stat(arg); err == nil && fileInfo.IsDir() {
+
+			dirEntries, err := os.ReadDir(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, dirEntry := range dirEntries {
+				if dirEntry.IsDir() || !strings.HasSuffix(strings.ToLower(dirEntry.Name()), ".yaml") {
+					continue
+				}
+				pathMap[filepath.Join(arg, dirEntry.Name())] = true
+			}
+
+			continue
+		}
+
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no config files match '%s'", arg)
+		}
+
+		for _, match := range matches {
+			pathMap[match] = true
+		}
+	}
+
+	res := []string{}
+	for path := range pathMap {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, absPath)
+	}
+	sort.Strings(res)
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no config files found")
+	}
+
+	return res, nil
+}
+
+// newBackupAllConfigEntry reads the config file, and returns its ordering and repository information.
+func newBackupAllConfigEntry(configFilePath string) (backupall.ConfigEntry, error) {
+
+	config, err := model.ReadConfigFile(configFilePath)
+	if err != nil {
+		return backupall.ConfigEntry{}, err
+	}
+
+	repositoryID, err := config.GetRepositoryID()
+	if err != nil {
+		return backupall.ConfigEntry{}, err
+	}
+
+	entry := backupall.ConfigEntry{
+		Path:         configFilePath,
+		Priority:     config.Priority,
+		RepositoryID: repositoryID,
+	}
+
+	// 'after' paths are relative to the directory containing the config file
+	for _, after := range config.After {
+		if !filepath.IsAbs(after) {
+			after = filepath.Join(filepath.Dir(configFilePath), after)
+		}
+		entry.After = append(entry.After, filepath.Clean(after))
+	}
+
+	return entry, nil
+}
+
+// runBackupInSubprocess runs 'backup (config file)' in a new process, prefixing the output with the config file name. When the
+// context is done, the process is interrupted.
+func runBackupInSubprocess(ctx context.Context, executable string, configFilePath string) error {
+
+	outputPrefix := "[" + filepath.Base(configFilePath) + "] "
+
+	stdout, stderr := util.NewPrefixWriter(os.Stdout, outputPrefix), util.NewPrefixWriter(os.Stderr, outputPrefix)
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	cmd := exec.CommandContext(ctx, executable, "backup", configFilePath)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	util.ConfigureGracefulCancellation(cmd)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	return nil
+}
+
+func init() {
+
+	backupAllCmd.Flags().IntVarP(&backupAllParallel, "parallel", "p", 1, "Maximum number of config files to backup concurrently")
+
+	rootCmd.AddCommand(backupAllCmd)
+
+	backupAllCmd.Args = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) == 0 {
+			return fmt.Errorf("at least one argument required: (directory or glob)")
+		}
+
+		return nil
+	}
+}
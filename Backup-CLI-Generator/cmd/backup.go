@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/jgwest/backup-cli/model"
+	runbackup "github.com/jgwest/backup-cli/util/cmds/run-backup"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +31,31 @@ to quickly create a Cobra application.`,
 			return
 		}
 
-		if err := backend.Backup(pathToConfigFile, rehashSource); err != nil {
+		options := model.BackupOptions{
+			RehashSource:    rehashSource,
+			Parallel:        parallel,
+			WaitForLock:     waitForLock,
+			LockWaitTimeout: lockWaitTimeout,
+			Resume:          resume,
+			ResumeWindow:    resumeWindow,
+		}
+
+		ctx := cmd.Context()
+		if backupTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeoutCause(ctx, backupTimeout, fmt.Errorf("backup exceeded --timeout of %v", backupTimeout))
+			defer cancel()
+		}
+
+		startTime := time.Now()
+
+		err := backend.Backup(ctx, pathToConfigFile, options)
+
+		if recordErr := runbackup.RecordLastRun(pathToConfigFile, startTime, err); recordErr != nil {
+			fmt.Println("Warning: unable to record the result of the backup:", recordErr)
+		}
+
+		if err != nil {
 			reportCLIErrorAndExit(err)
 			return
 		}
@@ -36,10 +64,22 @@ to quickly create a Cobra application.`,
 }
 
 var rehashSource bool
+var parallel int
+var waitForLock bool
+var lockWaitTimeout time.Duration
+var backupTimeout time.Duration
+var resume bool
+var resumeWindow time.Duration
 
 func init() {
 
 	backupCmd.Flags().BoolVarP(&rehashSource, "rehash-source", "r", false, "When deciding what files to backup, rehash the source files")
+	backupCmd.Flags().BoolVar(&waitForLock, "wait", false, "If a previous backup of the config file or repository is still running, wait for it to complete rather than failing")
+	backupCmd.Flags().DurationVar(&lockWaitTimeout, "wait-timeout", 0, "Maximum time to wait for a running backup to complete, with --wait (e.g. '30m'); 0 waits indefinitely")
+	backupCmd.Flags().DurationVar(&backupTimeout, "timeout", 0, "Maximum duration of the backup (e.g. '6h'), after which it is cancelled (the 'maxDuration' config file setting also applies, if shorter)")
+	backupCmd.Flags().BoolVar(&resume, "resume", false, "Skip folder pairs that were successfully backed up by a previous run, within the resume window (rclone and robocopy only)")
+	backupCmd.Flags().DurationVar(&resumeWindow, "resume-window", 24*time.Hour, "With --resume, the maximum age of a previous folder pair completion for it to be skipped")
+	backupCmd.Flags().IntVarP(&parallel, "parallel", "p", 0, "Maximum number of folder pairs to backup concurrently (overrides the 'concurrency' config file setting)")
 
 	rootCmd.AddCommand(backupCmd)
 
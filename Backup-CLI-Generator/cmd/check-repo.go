@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	checkrepo "github.com/jgwest/backup-cli/util/cmds/check-repo"
+	"github.com/spf13/cobra"
+)
+
+// checkRepoCmd represents the check-repo command
+var checkRepoCmd = &cobra.Command{
+	Use:     "check-repo [config file path]",
+	Aliases: []string{"quick-check"},
+	Short:   "Check the integrity of the repository (or destination folders) of a config file",
+	Long: `Check the integrity of the repository (or, for mirror backends, the destination folders) of a config file.
+
+Check levels (--level):
+- quick: check the repository metadata, without reading file data (restic check, kopia snapshot
+  verify, tarsnap --fsck); mirrors compare file sizes (rclone check --size-only, robocopy /L)
+- standard: additionally read a random 5% of the file data; mirrors compare hashes (rclone check)
+  or timestamps (robocopy /L)
+- deep: read all of the file data, or --percent of it (restic check --read-data, kopia snapshot
+  verify --verify-files-percent, tarsnap -t of the newest archive, rclone check --download)
+
+The result is recorded, and reported by 'status'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFile := getOptionalConfigFilePath(args)
+
+		backend := retrieveBackendFromConfigFile(configFile)
+
+		if !backend.SupportsCheck() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support check", backend.ConfigType()))
+			return
+		}
+
+		options := model.CheckOptions{Level: model.CheckLevel(checkLevel), ReadDataPercent: checkPercent}
+
+		if options.ReadDataPercent != 0 && options.Level != model.CheckLevelDeep {
+			reportCLIErrorAndExit(fmt.Errorf("--percent may only be specified with --level %s", model.CheckLevelDeep))
+			return
+		}
+
+		if _, err := options.DataPercent(); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		startTime := time.Now()
+
+		err := backend.Check(cmd.Context(), configFile, options)
+
+		if recordErr := checkrepo.RecordCheckResult(configFile, options.Level, startTime, err); recordErr != nil {
+			fmt.Println("Warning: unable to record the result of the check:", recordErr)
+		}
+
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+	},
+}
+
+var checkLevel string
+var checkPercent int
+
+func init() {
+
+	checkRepoCmd.Flags().StringVarP(&checkLevel, "level", "l", string(model.CheckLevelQuick), "Check level: 'quick', 'standard' or 'deep'")
+	checkRepoCmd.Flags().IntVar(&checkPercent, "percent", 0, "With --level deep, the percentage of the file data to read (default: all)")
+
+	rootCmd.AddCommand(checkRepoCmd)
+
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/jgwest/backup-cli/util/cmds/generate"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +24,11 @@ var checkCmd = &cobra.Command{
 			return
 		}
 
+		// The script is regenerated by 'rotate-key'
+		if err := generate.RecordGeneratedScript(pathToConfigFile, generate.GeneratedScript{Path: scriptPath}); err != nil {
+			fmt.Println("Warning: unable to record the script:", err)
+		}
+
 		if err := backend.BackupShellScriptDiffCheck(pathToConfigFile, scriptPath); err != nil {
 			reportCLIErrorAndExit(err)
 			return
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [config file path] [snapshot A] [snapshot B]",
+	Short: "List the files that were added, removed or modified between two snapshots",
+	Long: `List the files that were added, removed or modified between two snapshots, identified by ID (or ID prefix, as listed by
+'snapshots'). If no snapshots are specified, the previous snapshot is compared with the latest snapshot.
+
+Kopia creates a separate snapshot for each folder, so by default the previous and latest snapshots of each folder are compared.
+Mirror backends (robocopy, rclone) compare the manifests of the source folders that are recorded after each successful backup.
+
+The output is a list of paths by default, or JSON with '--output json'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configArgs, snapshotArgs := args, []string{}
+		if len(args) > 0 && !strings.HasSuffix(args[0], ".yaml") {
+			configArgs, snapshotArgs = []string{}, args
+		} else if len(args) > 1 {
+			configArgs, snapshotArgs = args[0:1], args[1:]
+		}
+
+		if len(snapshotArgs) != 0 && len(snapshotArgs) != 2 {
+			reportCLIErrorAndExit(fmt.Errorf("expected two snapshots to compare, or none"))
+			return
+		}
+
+		pathToConfigFile := getOptionalConfigFilePath(configArgs)
+
+		backend := retrieveBackendFromConfigFile(pathToConfigFile)
+
+		if !backend.SupportsDiff() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support diff", backend.ConfigType()))
+			return
+		}
+
+		if diffOutput != "text" && diffOutput != "json" {
+			reportCLIErrorAndExit(fmt.Errorf("unrecognized output format '%s': expected 'text' or 'json'", diffOutput))
+			return
+		}
+
+		options := model.DiffOptions{}
+		if len(snapshotArgs) == 2 {
+			options.From, options.To = snapshotArgs[0], snapshotArgs[1]
+		}
+
+		diffs, err := backend.Diff(cmd.Context(), pathToConfigFile, options)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if diffOutput == "json" {
+			content, err := json.MarshalIndent(diffs, "", "  ")
+			if err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+			fmt.Println(string(content))
+		} else {
+			printDiffs(diffs)
+		}
+
+	},
+}
+
+var diffOutput string
+
+func printDiffs(diffs []model.SnapshotDiff) {
+
+	for index, diff := range diffs {
+
+		if index > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("Comparing %s (%s) to %s (%s):\n", diff.From.ID, diff.From.Time.Local().Format(time.DateTime),
+			diff.To.ID, diff.To.Time.Local().Format(time.DateTime))
+
+		counts := map[model.DiffChange]int{}
+
+		for _, change := range diff.Changes {
+
+			counts[change.Change]++
+
+			path := change.Path
+			if change.IsDir {
+				path += "/"
+			}
+
+			switch change.Change {
+			case model.DiffAdded:
+				fmt.Printf("+ %s%s\n", path, formatDiffSize(change))
+			case model.DiffRemoved:
+				fmt.Printf("- %s%s\n", path, formatDiffSize(change))
+			case model.DiffModified:
+				fmt.Printf("M %s%s\n", path, formatDiffSize(change))
+			}
+		}
+
+		fmt.Printf("%d added, %d removed, %d modified\n", counts[model.DiffAdded], counts[model.DiffRemoved], counts[model.DiffModified])
+	}
+}
+
+// formatDiffSize returns the size of the changed file (or, for a modified file, its old and new sizes) in parentheses, or "" for
+// folders.
+func formatDiffSize(change model.DiffEntry) string {
+
+	if change.IsDir {
+		return ""
+	}
+
+	if change.Change == model.DiffModified {
+		return fmt.Sprintf(" (%s -> %s)", util.FormatBytes(change.OldSize), util.FormatBytes(change.Size))
+	}
+
+	return fmt.Sprintf(" (%s)", util.FormatBytes(change.Size))
+}
+
+func init() {
+
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "text", "Output format: 'text' or 'json'")
+
+	rootCmd.AddCommand(diffCmd)
+
+}
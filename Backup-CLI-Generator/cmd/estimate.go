@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/estimate"
+	"github.com/spf13/cobra"
+)
+
+var estimateCmd = &cobra.Command{
+	Use:   "estimate [config file path]",
+	Short: "Report the size and number of files of each folder of a config file, once excludes are applied",
+	Long: `Report the size and number of files of each folder of a config file, once the global, folder, and robocopy excludes of
+the config file are applied, along with the largest folders (directly under each folder) and the largest files.
+
+The folders are walked concurrently, with at most '--workers' folders read at once. Each estimate is saved in the backup-cli
+state directory: with '--compare', the growth since the previous estimate is also reported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFile := getOptionalConfigFilePath(args)
+
+		if estimateOutput != "text" && estimateOutput != "json" {
+			reportCLIErrorAndExit(fmt.Errorf("unrecognized output format '%s': expected 'text' or 'json'", estimateOutput))
+			return
+		}
+
+		if estimateTop < 0 {
+			reportCLIErrorAndExit(fmt.Errorf("--top must not be negative"))
+			return
+		}
+
+		config, err := model.ReadConfigFile(configFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		// The previous estimate is read before the new estimate replaces it
+		var previous *estimate.Estimate
+		if estimateCompare {
+			previousEstimate, err := estimate.ReadEstimate(configFile)
+			if err == nil {
+				previous = &previousEstimate
+			} else if !errors.Is(err, os.ErrNotExist) {
+				reportCLIErrorAndExit(err)
+				return
+			}
+		}
+
+		current, err := estimate.NewEstimate(configFile, config, estimateWorkers, estimateTop)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if err := estimate.RecordEstimate(configFile, current); err != nil {
+			fmt.Println("Warning: unable to save the estimate:", err)
+		}
+
+		if estimateOutput == "json" {
+			content, err := json.MarshalIndent(current, "", "  ")
+			if err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+			fmt.Println(string(content))
+			return
+		}
+
+		if estimateCompare && previous == nil {
+			fmt.Println("No previous estimate to compare with.")
+			fmt.Println()
+		}
+
+		printEstimate(current, previous)
+	},
+}
+
+var (
+	estimateWorkers int
+	estimateTop     int
+	estimateCompare bool
+	estimateOutput  string
+)
+
+// printEstimate prints the estimate of each folder, and if previous is non-nil, the growth since the previous estimate.
+func printEstimate(current estimate.Estimate, previous *estimate.Estimate) {
+
+	for _, folder := range current.Folders {
+
+		fmt.Printf("%s: %s in %d file(s)", folder.Folder, util.FormatBytes(folder.Bytes), folder.Files)
+		if previous != nil {
+			if previousFolder, exists := previous.FindFolder(folder.Folder); exists {
+				fmt.Printf(" (%s)", formatGrowth(folder.Bytes-previousFolder.Bytes, folder.Files-previousFolder.Files))
+			} else {
+				fmt.Print(" (new)")
+			}
+		}
+		fmt.Println()
+
+		printSizeEntries("Largest folders", folder.Folder, folder.LargestSubfolders)
+		printSizeEntries("Largest files", folder.Folder, folder.LargestFiles)
+		fmt.Println()
+	}
+
+	fmt.Printf("Total: %s in %d file(s)", util.FormatBytes(current.TotalBytes()), current.TotalFiles())
+	if previous != nil {
+		fmt.Printf(" (%s since %s)", formatGrowth(current.TotalBytes()-previous.TotalBytes(), current.TotalFiles()-previous.TotalFiles()),
+			previous.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Println()
+}
+
+func printSizeEntries(header string, folder string, entries []estimate.SizeEntry) {
+
+	if len(entries) == 0 {
+		return
+	}
+
+	fmt.Printf("  %s:\n", header)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, entry := range entries {
+		name := entry.Path
+		if relPath, err := filepath.Rel(folder, entry.Path); err == nil {
+			name = relPath
+		}
+		fmt.Fprintf(tw, "    %s\t  %s\n", util.FormatBytes(entry.Bytes), name)
+	}
+	tw.Flush()
+}
+
+// formatGrowth returns the change in size and number of files, e.g. '+1.5 GiB, +120 files'.
+func formatGrowth(bytes int64, files int64) string {
+
+	sign := "+"
+	if bytes < 0 {
+		sign, bytes = "-", -bytes
+	}
+
+	return fmt.Sprintf("%s%s, %+d file(s)", sign, util.FormatBytes(bytes), files)
+}
+
+func init() {
+
+	estimateCmd.Flags().IntVar(&estimateWorkers, "workers", runtime.NumCPU(), "Maximum number of folders to read at once")
+	estimateCmd.Flags().IntVar(&estimateTop, "top", 10, "Number of largest folders and files to report for each folder")
+	estimateCmd.Flags().BoolVar(&estimateCompare, "compare", false, "Report the growth since the previous estimate")
+	estimateCmd.Flags().StringVarP(&estimateOutput, "output", "o", "text", "Output format: 'text' or 'json'")
+
+	rootCmd.AddCommand(estimateCmd)
+
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/jgwest/backup-cli/util/cmds/generate"
 	"github.com/spf13/cobra"
 )
 
@@ -38,6 +39,10 @@ to quickly create a Cobra application.`,
 			return
 		}
 
+		if err := generate.RecordGeneratedScript(pathToConfigFile, generate.GeneratedScript{Path: outputPath, Generic: true}); err != nil {
+			fmt.Println("Warning: unable to record the generated script:", err)
+		}
+
 	},
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/jgwest/backup-cli/util/cmds/generate"
 	"github.com/spf13/cobra"
 )
 
@@ -19,10 +20,28 @@ to quickly create a Cobra application.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		pathToConfigFile := args[0]
-		outputPath := args[1]
 
 		backend := retrieveBackendFromConfigFile(pathToConfigFile)
 
+		if generateGraph {
+
+			if !backend.SupportsGenerateGraph() {
+				reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support generating a dependency graph", backend.ConfigType()))
+				return
+			}
+
+			graph, err := backend.GenerateGraph(pathToConfigFile)
+			if err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+
+			fmt.Print(graph)
+			return
+		}
+
+		outputPath := args[1]
+
 		if !backend.SupportsGenerateBackup() {
 			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support generating backup files", backend.ConfigType()))
 			return
@@ -33,14 +52,29 @@ to quickly create a Cobra application.`,
 			return
 		}
 
+		if err := generate.RecordGeneratedScript(pathToConfigFile, generate.GeneratedScript{Path: outputPath}); err != nil {
+			fmt.Println("Warning: unable to record the generated script:", err)
+		}
+
 	},
 }
 
+var generateGraph bool
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
+	generateCmd.Flags().BoolVar(&generateGraph, "graph", false, "Print the dependency graph of the script's text nodes, in DOT format, rather than generating the script")
+
 	generateCmd.Args = func(cmd *cobra.Command, args []string) error {
 
+		if generateGraph {
+			if len(args) != 1 {
+				return fmt.Errorf("arguments required with --graph: (path to yaml file)")
+			}
+			return nil
+		}
+
 		if len(args) != 2 {
 			return fmt.Errorf("arguments required: (path to yaml file) (output path)")
 		}
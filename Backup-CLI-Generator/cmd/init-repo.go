@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/spf13/cobra"
+)
+
+// initRepoCmd represents the init-repo command
+var initRepoCmd = &cobra.Command{
+	Use:   "init-repo [config file path]",
+	Short: "Create the repository of a config file from its credentials, unless it already exists",
+	Long: `Create the repository of a config file from its credentials, unless it already exists:
+- restic: 'restic init', unless 'restic cat config' finds an existing repository
+- kopia: 'kopia repository create s3', unless 'kopia repository connect s3' succeeds
+- tarsnap: verify that the key file of the tarsnap config file exists (it is created with 'tarsnap-keygen')
+
+With '--apply-policy', the 'policy' of the config file is then applied:
+- restic: the retention policy is applied with 'restic forget --prune'; compression is applied by each backup
+- kopia: the retention and compression policy is set as the global policy of the repository`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFile := getOptionalConfigFilePath(args)
+
+		backend := retrieveBackendFromConfigFile(configFile)
+
+		if !backend.SupportsInitRepo() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support init-repo", backend.ConfigType()))
+			return
+		}
+
+		if err := backend.InitRepo(cmd.Context(), configFile, model.InitRepoOptions{ApplyPolicy: initRepoApplyPolicy}); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+	},
+}
+
+var initRepoApplyPolicy bool
+
+func init() {
+
+	initRepoCmd.Flags().BoolVar(&initRepoApplyPolicy, "apply-policy", false, "Apply the retention and compression 'policy' of the config file to the repository")
+
+	rootCmd.AddCommand(initRepoCmd)
+
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+	"github.com/jgwest/backup-cli/util/cmds/monitor"
+	"github.com/spf13/cobra"
+)
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor [config file path]",
+	Short: "Report the files and folders under the monitor folders of a config file that are not backed up",
+	Long: `Report the files and folders under the monitor folders of a config file that are not backed up.
+
+With '--fix', for each of them, either add it to 'folders', or add it to the 'excludes' of its monitor folder: the action is
+prompted for, or with '--yes', '--default-action' is used. The config file is updated in place: its comments, ordering, and
+formatting are preserved.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFile := getOptionalConfigFilePath(args)
+
+		if monitorDefaultAction != "folders" && monitorDefaultAction != "excludes" {
+			reportCLIErrorAndExit(fmt.Errorf("unrecognized default action '%s': expected 'folders' or 'excludes'", monitorDefaultAction))
+			return
+		}
+
+		if monitorYes && !monitorFix {
+			reportCLIErrorAndExit(fmt.Errorf("--yes may only be specified with --fix"))
+			return
+		}
+
+		config, err := model.ReadConfigFile(configFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if !monitorFix {
+			if err := generate.CheckMonitorFoldersForMissingChildren(configFile, config); err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+			fmt.Println("All files and folders under the monitor folders are backed up.")
+			return
+		}
+
+		monitorFolderResults, err := generate.FindUnbackedUpMonitorPaths(config)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if len(monitorFolderResults) == 0 {
+			fmt.Println("All files and folders under the monitor folders are backed up.")
+			return
+		}
+
+		content, err := os.ReadFile(configFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		patch, err := monitor.NewConfigFilePatch(content)
+		if err != nil {
+			reportCLIErrorAndExit(fmt.Errorf("unable to parse '%s': %w", configFile, err))
+			return
+		}
+
+		changes, err := fixUnbackedUpMonitorPaths(patch, monitorFolderResults, bufio.NewReader(os.Stdin))
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if changes == 0 {
+			fmt.Println("No changes made.")
+			return
+		}
+
+		if err := monitor.WriteConfigFile(configFile, patch.Bytes()); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		fmt.Printf("Updated '%s' with %d change(s).\n", configFile, changes)
+	},
+}
+
+var (
+	monitorFix           bool
+	monitorYes           bool
+	monitorDefaultAction string
+)
+
+// fixUnbackedUpMonitorPaths applies the action chosen for each of the un-backed-up paths to the patch (prompting for it, unless
+// --yes is specified), and returns the number of changes.
+func fixUnbackedUpMonitorPaths(patch *monitor.ConfigFilePatch, monitorFolderResults []generate.UnbackedUpMonitorPaths, input *bufio.Reader) (int, error) {
+
+	changes := 0
+
+	for _, monitorFolderResult := range monitorFolderResults {
+		for _, path := range monitorFolderResult.Paths {
+
+			folderPath, err := monitor.FolderPath(monitorFolderResult.MonitorFolder, monitorFolderResult.MonitorPath, path)
+			if err != nil {
+				return 0, err
+			}
+
+			excludePattern, err := monitor.ExcludePattern(monitorFolderResult.MonitorPath, path)
+			if err != nil {
+				return 0, err
+			}
+
+			action := monitorDefaultAction
+
+			fmt.Printf("%s (monitor folder '%s')\n", path, monitorFolderResult.MonitorFolder.Path)
+
+			if !monitorYes {
+				if action, err = promptMonitorAction(input, action); err != nil {
+					return 0, err
+				}
+			}
+
+			switch action {
+			case "folders":
+				if err := patch.AddFolder(folderPath); err != nil {
+					return 0, err
+				}
+				fmt.Printf("  Added to folders: %s\n", folderPath)
+				changes++
+
+			case "excludes":
+				if err := patch.AddMonitorFolderExclude(monitorFolderResult.Index, excludePattern); err != nil {
+					return 0, err
+				}
+				fmt.Printf("  Added to monitor folder excludes: %s\n", excludePattern)
+				changes++
+
+			default:
+				fmt.Println("  Skipped.")
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// promptMonitorAction prompts for the action to take for a path: 'folders', 'excludes', or 'skip'.
+func promptMonitorAction(input *bufio.Reader, defaultAction string) (string, error) {
+
+	choices := "[F/e/s]"
+	if defaultAction == "excludes" {
+		choices = "[f/E/s]"
+	}
+
+	for {
+		fmt.Printf("  Add to [f]olders, add to monitor folder [e]xcludes, or [s]kip? %s: ", choices)
+
+		line, err := input.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("unable to read the action: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "":
+			return defaultAction, nil
+		case "f", "folders":
+			return "folders", nil
+		case "e", "excludes":
+			return "excludes", nil
+		case "s", "skip":
+			return "skip", nil
+		}
+	}
+}
+
+func init() {
+
+	monitorCmd.Flags().BoolVar(&monitorFix, "fix", false, "Add each un-backed-up file or folder to 'folders', or to the excludes of its monitor folder")
+	monitorCmd.Flags().BoolVarP(&monitorYes, "yes", "y", false, "With --fix, apply the default action without prompting")
+	monitorCmd.Flags().StringVar(&monitorDefaultAction, "default-action", "folders", "With --fix, the default action: 'folders' or 'excludes'")
+
+	rootCmd.AddCommand(monitorCmd)
+
+}
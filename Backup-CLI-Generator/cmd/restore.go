@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/spf13/cobra"
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore [config file path] --target (folder)",
+	Short: "Restore a snapshot of the backup of a config file",
+	Long: `Restore a snapshot of the backup of a config file.
+
+By default, the latest snapshot is restored; use --id, --tag or --time to select a
+different snapshot. Each file is restored to the target folder joined with its
+original absolute path (for example, '/home/me/file' is restored to
+'(target)/home/me/file'). Use --path to only restore specific files or folders.
+
+The mirror backends (rclone, robocopy) only support restoring the latest snapshot,
+which is copied back from the destination folder.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		pathToConfigFile := getOptionalConfigFilePath(args)
+
+		backend := retrieveBackendFromConfigFile(pathToConfigFile)
+
+		if !backend.SupportsRestore() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support restore", backend.ConfigType()))
+			return
+		}
+
+		selector, err := parseSnapshotSelector(restoreSnapshotID, restoreSnapshotTag, restoreSnapshotTime)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		options := model.RestoreOptions{
+			Snapshot: selector,
+			Target:   restoreTarget,
+			Force:    restoreForce,
+		}
+
+		for _, path := range restorePaths {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+			options.Paths = append(options.Paths, absPath)
+		}
+
+		if err := backend.Restore(cmd.Context(), pathToConfigFile, options); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+	},
+}
+
+var restoreTarget string
+var restoreForce bool
+var restorePaths []string
+var restoreSnapshotID string
+var restoreSnapshotTag string
+var restoreSnapshotTime string
+
+// snapshotTimeFormats are the accepted formats of the --time snapshot selector
+var snapshotTimeFormats = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// parseSnapshotSelector returns the snapshot selector for the --id, --tag and --time flags, of which at most one may be
+// specified; if none are specified, the latest snapshot is selected.
+func parseSnapshotSelector(id string, tag string, timeValue string) (model.SnapshotSelector, error) {
+
+	specified := 0
+	for _, value := range []string{id, tag, timeValue} {
+		if value != "" {
+			specified++
+		}
+	}
+
+	if specified > 1 {
+		return model.SnapshotSelector{}, fmt.Errorf("only one of --id, --tag and --time may be specified")
+	}
+
+	if id != "" {
+		return model.SnapshotSelector{Type: model.SnapshotSelectID, Value: id}, nil
+	}
+
+	if tag != "" {
+		return model.SnapshotSelector{Type: model.SnapshotSelectTag, Value: tag}, nil
+	}
+
+	if timeValue != "" {
+		for _, format := range snapshotTimeFormats {
+			if parsed, err := time.ParseInLocation(format, timeValue, time.Local); err == nil {
+				return model.SnapshotSelector{Type: model.SnapshotSelectTime, Time: parsed}, nil
+			}
+		}
+		return model.SnapshotSelector{}, fmt.Errorf("unable to parse time '%s': expected YYYY-MM-DD, 'YYYY-MM-DD HH:MM[:SS]' or RFC 3339", timeValue)
+	}
+
+	return model.SnapshotSelector{Type: model.SnapshotSelectLatest}, nil
+}
+
+func init() {
+
+	restoreCmd.Flags().StringVarP(&restoreTarget, "target", "t", "", "Folder to restore to (required)")
+	restoreCmd.Flags().BoolVarP(&restoreForce, "force", "f", false, "Restore even if the target folder is not empty")
+	restoreCmd.Flags().StringArrayVar(&restorePaths, "path", nil, "Only restore this file or folder (may be specified multiple times)")
+	restoreCmd.Flags().StringVar(&restoreSnapshotID, "id", "", "Restore the snapshot with this ID (or ID prefix)")
+	restoreCmd.Flags().StringVar(&restoreSnapshotTag, "tag", "", "Restore the newest snapshot with a tag (restic), description (kopia) or archive name (tarsnap) starting with this value, such as the metadata name")
+	restoreCmd.Flags().StringVar(&restoreSnapshotTime, "time", "", "Restore the newest snapshot taken at or before this time")
+
+	restoreCmd.MarkFlagRequired("target")
+
+	rootCmd.AddCommand(restoreCmd)
+
+}
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/jgwest/backup-cli/backends"
 	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
 	"github.com/spf13/cobra"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -34,8 +38,14 @@ to quickly create a Cobra application.`,
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+//
+// On SIGINT/SIGTERM, the context of the command is cancelled: running backend processes are then interrupted, and
+// given a grace period to exit before they are killed.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cobra.CheckErr(rootCmd.ExecuteContext(ctx))
 }
 
 func init() {
@@ -77,7 +87,8 @@ func initConfig() {
 
 func reportCLIErrorAndExit(err error) {
 	fmt.Println(err)
-	os.Exit(1)
+
+	os.Exit(util.ExitCodeFor(err))
 }
 
 func retrieveBackendFromConfigFile(pathToConfigFile string) model.Backend {
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+	rotatekey "github.com/jgwest/backup-cli/util/cmds/rotate-key"
+	"github.com/spf13/cobra"
+)
+
+// rotateKeyCmd represents the rotate-key command
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key [config file path]",
+	Short: "Replace the repository password of a config file with a new password",
+	Long: `Replace the repository password (restic, kopia) of a config file with a new password, which is generated unless
+'--new-password-file' is specified. The steps are:
+1) add the new key (restic key add), or change the password (kopia repository change-password)
+2) verify that the repository can be opened with the new password
+3) update the password of the config file (or the restic password file that it references)
+4) regenerate the scripts that were generated from the config file ('generate', 'generate generic'), or checked against it
+   ('check')
+5) remove the old key (restic key remove)
+
+If a step fails, the following steps are not run; in particular, the old key is not removed. Until the rotation completes, the
+new password is also saved in the backup-cli state directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configFile := getOptionalConfigFilePath(args)
+
+		backend := retrieveBackendFromConfigFile(configFile)
+
+		if !backend.SupportsRotateKey() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support rotate-key", backend.ConfigType()))
+			return
+		}
+
+		config, err := model.ReadConfigFile(configFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		newPassword, err := readOrGenerateNewPassword(rotateKeyNewPasswordFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		pendingPasswordFilePath, err := rotatekey.SavePendingPassword(configFile, newPassword)
+		if err != nil {
+			reportCLIErrorAndExit(fmt.Errorf("unable to save the new password: %w", err))
+			return
+		}
+
+		rotation, err := backend.AddKey(cmd.Context(), configFile, newPassword)
+		if err != nil {
+			reportCLIErrorAndExit(fmt.Errorf("unable to add the new key (the new password is saved in '%s'): %w", pendingPasswordFilePath, err))
+			return
+		}
+
+		if err := rotatekey.UpdateConfigPassword(configFile, config, newPassword); err != nil {
+			reportCLIErrorAndExit(fmt.Errorf("the new key was added, but the config file could not be updated, so the old key was not removed (the new password is saved in '%s'): %w", pendingPasswordFilePath, err))
+			return
+		}
+		fmt.Println("Updated the password of the config file.")
+
+		if err := regenerateScripts(backend, configFile); err != nil {
+			reportCLIErrorAndExit(fmt.Errorf("unable to regenerate scripts, so the old key was not removed: %w", err))
+			return
+		}
+
+		if err := backend.RemoveOldKey(cmd.Context(), configFile, rotation); err != nil {
+			reportCLIErrorAndExit(fmt.Errorf("the config file uses the new key, but the old key could not be removed: %w", err))
+			return
+		}
+
+		if err := os.Remove(pendingPasswordFilePath); err != nil {
+			fmt.Println("Warning: unable to remove the saved new password:", err)
+		}
+
+		fmt.Println("Key rotation complete.")
+	},
+}
+
+var rotateKeyNewPasswordFile string
+
+// readOrGenerateNewPassword returns the content of the password file (without trailing newlines), or, if not specified, a newly
+// generated password.
+func readOrGenerateNewPassword(passwordFilePath string) (string, error) {
+
+	if passwordFilePath == "" {
+		return rotatekey.GeneratePassword()
+	}
+
+	content, err := os.ReadFile(passwordFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("new password file is empty: %s", passwordFilePath)
+	}
+
+	return password, nil
+}
+
+// regenerateScripts regenerates each of the scripts of the config file (that still exists) from the updated config file.
+func regenerateScripts(backend model.Backend, configFile string) error {
+
+	scripts, err := generate.ReadGeneratedScripts(configFile)
+	if err != nil {
+		return err
+	}
+
+	for _, script := range scripts {
+
+		if _, err := os.Stat(script.Path); errors.Is(err, os.ErrNotExist) {
+			fmt.Println("Skipping script that no longer exists:", script.Path)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		// Scripts are not generated over existing files, so the old script is moved aside until the new script is generated
+		oldScriptPath := script.Path + ".old"
+		if err := os.Rename(script.Path, oldScriptPath); err != nil {
+			return err
+		}
+
+		if script.Generic {
+			err = backend.GenerateGeneric(configFile, script.Path)
+		} else {
+			err = backend.GenerateBackup(configFile, script.Path)
+		}
+
+		if err != nil {
+			if restoreErr := os.Rename(oldScriptPath, script.Path); restoreErr != nil {
+				return fmt.Errorf("unable to regenerate '%s': %w (and unable to restore the old script: %v)", script.Path, err, restoreErr)
+			}
+			return fmt.Errorf("unable to regenerate '%s': %w", script.Path, err)
+		}
+
+		if err := os.Remove(oldScriptPath); err != nil {
+			return err
+		}
+
+		fmt.Println("Regenerated script:", script.Path)
+	}
+
+	return nil
+}
+
+func init() {
+
+	rotateKeyCmd.Flags().StringVar(&rotateKeyNewPasswordFile, "new-password-file", "", "Read the new password from a file, rather than generating it")
+
+	rootCmd.AddCommand(rotateKeyCmd)
+
+}
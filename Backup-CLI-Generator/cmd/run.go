@@ -43,7 +43,7 @@ to quickly create a Cobra application.`,
 			return
 		}
 
-		if err := backend.Run(configFile, params); err != nil {
+		if err := backend.Run(cmd.Context(), configFile, params); err != nil {
 			reportCLIErrorAndExit(err)
 			return
 
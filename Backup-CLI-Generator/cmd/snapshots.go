@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/spf13/cobra"
+)
+
+// snapshotsCmd represents the snapshots command
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots [config file path]",
+	Short: "List the snapshots (or archives) of the repository of a config file",
+	Long: `List the snapshots (or archives) of the repository of a config file, oldest first.
+
+The output is a table by default, or JSON with '--output json'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		pathToConfigFile := getOptionalConfigFilePath(args)
+
+		backend := retrieveBackendFromConfigFile(pathToConfigFile)
+
+		if !backend.SupportsSnapshots() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support snapshots", backend.ConfigType()))
+			return
+		}
+
+		if snapshotsOutput != "table" && snapshotsOutput != "json" {
+			reportCLIErrorAndExit(fmt.Errorf("unrecognized output format '%s': expected 'table' or 'json'", snapshotsOutput))
+			return
+		}
+
+		snapshots, err := backend.Snapshots(cmd.Context(), pathToConfigFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		sort.SliceStable(snapshots, func(i, j int) bool {
+			return snapshots[i].Time.Before(snapshots[j].Time)
+		})
+
+		if snapshotsOutput == "json" {
+			if err := printSnapshotsJSON(snapshots); err != nil {
+				reportCLIErrorAndExit(err)
+				return
+			}
+		} else {
+			printSnapshotsTable(snapshots)
+		}
+
+	},
+}
+
+var snapshotsOutput string
+
+// configSnapshots returns the expanded source folders of the config file, and the snapshots of its repository that were taken by
+// the config file, as the repository may be shared with other config files (see model.ConfigSnapshots).
+func configSnapshots(ctx context.Context, backend model.Backend, configFilePath string, config model.ConfigFile) ([]string, []model.Snapshot, error) {
+
+	sourceFolders := []string{}
+	for _, folder := range config.Folders {
+		folderPath, err := util.Expand(folder.Path, config.Substitutions)
+		if err != nil {
+			return nil, nil, err
+		}
+		sourceFolders = append(sourceFolders, filepath.Clean(folderPath))
+	}
+
+	snapshots, err := backend.Snapshots(ctx, configFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sourceFolders, model.ConfigSnapshots(snapshots, sourceFolders, configMetadataName(config)), nil
+}
+
+// configMetadataName returns the metadata name of the config file, with which its snapshots are tagged, or "" if none.
+func configMetadataName(config model.ConfigFile) string {
+	if config.Metadata == nil {
+		return ""
+	}
+	return config.Metadata.Name
+}
+
+func printSnapshotsJSON(snapshots []model.Snapshot) error {
+
+	content, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(content))
+
+	return nil
+}
+
+func printSnapshotsTable(snapshots []model.Snapshot) {
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTIME\tHOST\tTAGS\tSIZE\tPATHS")
+
+	for _, snapshot := range snapshots {
+
+		size := ""
+		if snapshot.Size > 0 {
+			size = util.FormatBytes(snapshot.Size)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			snapshot.ID,
+			snapshot.Time.Local().Format(time.DateTime),
+			snapshot.Host,
+			strings.Join(snapshot.Tags, ","),
+			size,
+			strings.Join(snapshot.Paths, ", "))
+	}
+
+	tw.Flush()
+
+	fmt.Printf("\n%d snapshot(s)\n", len(snapshots))
+}
+
+func init() {
+
+	snapshotsCmd.Flags().StringVarP(&snapshotsOutput, "output", "o", "table", "Output format: 'table' or 'json'")
+
+	rootCmd.AddCommand(snapshotsCmd)
+
+}
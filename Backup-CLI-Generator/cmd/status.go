@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	checkrepo "github.com/jgwest/backup-cli/util/cmds/check-repo"
+	runbackup "github.com/jgwest/backup-cli/util/cmds/run-backup"
+	"github.com/jgwest/backup-cli/util/runlock"
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status [config files, directories or globs...]",
+	Short: "Report whether the backups of config files are up to date",
+	Long: `Report, for each config file, the age of the newest backup against the 'maxAge' of the config file (default 48h),
+whether a backup is currently running (lock state), the result of the last 'backup' run, and the result of the last
+'check-repo' at each check level.
+
+The age of the newest backup is:
+- for restic, kopia and tarsnap: the age of the oldest of the newest snapshots (or archives) of each folder of the config
+  file, so that a single stale folder makes the config file stale. Only the snapshots taken by the config file are
+  considered (those tagged with its metadata name, and of its folders), as the repository may be shared with other
+  config files.
+- for robocopy and rclone: the age of the last successful 'backup' run recorded in the state directory or, if no run
+  has been recorded (for example, the backups are run by a generated script), the age of the least recently modified
+  destination folder
+
+If the age of the newest backup cannot be determined, the config file is reported as stale. If any config file is stale,
+the exit code is 5.
+
+If no arguments are specified, the config file in the current directory is used.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) == 0 {
+			args = []string{getOptionalConfigFilePath(args)}
+		}
+
+		configFilePaths, err := discoverConfigFiles(args)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		statuses := []configStatus{}
+		for _, configFilePath := range configFilePaths {
+			statuses = append(statuses, getConfigStatus(cmd.Context(), configFilePath, time.Now()))
+		}
+
+		printStatusTable(statuses)
+
+		stale := 0
+		for _, status := range statuses {
+			if status.isStale() {
+				stale++
+			}
+		}
+
+		if stale > 0 {
+			reportCLIErrorAndExit(&util.ExitCodeError{Code: util.ExitCodeStale, Err: fmt.Errorf("%d of %d config file(s) are stale", stale, len(statuses))})
+			return
+		}
+
+	},
+}
+
+// configStatus is the status of the backups of a single config file
+type configStatus struct {
+	configFilePath string
+	configType     model.ConfigType
+
+	// lastBackup is the time of the newest backup; zero if unknown
+	lastBackup time.Time
+	age        time.Duration
+	maxAge     time.Duration
+
+	locks   []runlock.LockInfo
+	lastRun *runbackup.LastRun
+	checks  []checkrepo.CheckResult
+
+	// err is set if the status could not be fully determined
+	err error
+}
+
+func (cs configStatus) isStale() bool {
+	return cs.err != nil || cs.lastBackup.IsZero() || cs.age > cs.maxAge
+}
+
+func getConfigStatus(ctx context.Context, configFilePath string, now time.Time) configStatus {
+
+	res := configStatus{configFilePath: configFilePath}
+
+	config, err := model.ReadConfigFile(configFilePath)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	if res.configType, err = config.GetConfigType(); err != nil {
+		res.err = err
+		return res
+	}
+
+	if res.maxAge, err = config.GetMaxAge(); err != nil {
+		res.err = err
+		return res
+	}
+
+	if res.locks, err = runlock.HeldLocks(configFilePath, config); err != nil {
+		res.err = err
+		return res
+	}
+
+	if lastRun, err := runbackup.ReadLastRun(configFilePath); err == nil {
+		res.lastRun = &lastRun
+	} else if !errors.Is(err, os.ErrNotExist) {
+		res.err = err
+		return res
+	}
+
+	if res.checks, err = checkrepo.ReadCheckResults(configFilePath); err != nil {
+		res.err = err
+		return res
+	}
+
+	backend, err := findBackendForConfigFile(config)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	if res.lastBackup, err = newestBackupTime(ctx, backend, configFilePath, config); err != nil {
+		res.err = err
+		return res
+	}
+
+	if !res.lastBackup.IsZero() {
+		res.age = now.Sub(res.lastBackup)
+	}
+
+	return res
+}
+
+// newestBackupTime returns, of the newest snapshot of each folder that was taken by the config file, the time of the oldest
+// (so that a single stale folder makes the config file stale) or, for mirror backends, the start time of the last successful
+// backup recorded in the state dir. If no backup has been recorded (for example, the mirror was created by a generated script),
+// the oldest modification time of the destination folders is used instead: a folder's modification time only changes when its
+// direct entries are added or removed, so this is an approximation. If a folder has no backup, the zero time is returned.
+func newestBackupTime(ctx context.Context, backend model.Backend, configFilePath string, config model.ConfigFile) (time.Time, error) {
+
+	if backend.SupportsSnapshots() {
+
+		sourceFolders, snapshots, err := configSnapshots(ctx, backend, configFilePath, config)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		latest, err := model.LatestSnapshotsByFolder(snapshots, sourceFolders)
+		if err != nil {
+			// A folder that has never been backed up
+			return time.Time{}, nil
+		}
+
+		var oldest time.Time
+
+		for _, snapshot := range latest {
+			if oldest.IsZero() || snapshot.Time.Before(oldest) {
+				oldest = snapshot.Time
+			}
+		}
+
+		return oldest, nil
+	}
+
+	if backend.SupportsFolderPairs() {
+
+		lastSuccess, err := runbackup.LastSuccessfulBackupTime(configFilePath)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if !lastSuccess.IsZero() {
+			return lastSuccess, nil
+		}
+
+		folderPairs, err := backend.FolderPairs(configFilePath)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		var oldest time.Time
+
+		for _, folderPair := range folderPairs {
+
+			fileInfo, err := os.Stat(folderPair.Dest)
+			if os.IsNotExist(err) {
+				// A folder that has never been mirrored
+				return time.Time{}, nil
+			} else if err != nil {
+				return time.Time{}, fmt.Errorf("unable to read destination folder '%s': %w", folderPair.Dest, err)
+			}
+
+			if oldest.IsZero() || fileInfo.ModTime().Before(oldest) {
+				oldest = fileInfo.ModTime()
+			}
+		}
+
+		return oldest, nil
+	}
+
+	return time.Time{}, fmt.Errorf("backend '%v' does not support reporting the time of the newest backup", backend.ConfigType())
+}
+
+func printStatusTable(statuses []configStatus) {
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONFIG\tTYPE\tLAST BACKUP\tAGE\tMAX AGE\tSTATUS\tLOCK\tLAST RUN\tLAST CHECK")
+
+	for _, status := range statuses {
+
+		lastBackup, age, maxAge := "-", "-", "-"
+		if !status.lastBackup.IsZero() {
+			lastBackup = status.lastBackup.Local().Format(time.DateTime)
+			age = formatAge(status.age)
+		}
+		if status.maxAge > 0 {
+			maxAge = formatAge(status.maxAge)
+		}
+
+		statusValue := "OK"
+		if status.err != nil {
+			statusValue = "ERROR"
+		} else if status.isStale() {
+			statusValue = "STALE"
+		}
+
+		lock := "-"
+		if len(status.locks) > 0 {
+			lock = fmt.Sprintf("running (PID %d on %s)", status.locks[0].PID, status.locks[0].Host)
+		}
+
+		lastRun := "-"
+		if status.lastRun != nil {
+			result := "succeeded"
+			if !status.lastRun.Success {
+				result = "failed"
+			}
+			lastRun = fmt.Sprintf("%s at %s", result, status.lastRun.EndTime.Local().Format(time.DateTime))
+		}
+
+		lastChecks := []string{}
+		for _, check := range status.checks {
+			result := "ok"
+			if !check.Success {
+				result = "failed"
+			}
+			lastChecks = append(lastChecks, fmt.Sprintf("%s %s %s", check.Level, result, check.EndTime.Local().Format(time.DateOnly)))
+		}
+		lastCheck := "-"
+		if len(lastChecks) > 0 {
+			lastCheck = strings.Join(lastChecks, ", ")
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			filepath.Base(status.configFilePath),
+			status.configType,
+			lastBackup,
+			age,
+			maxAge,
+			statusValue,
+			lock,
+			lastRun,
+			lastCheck)
+	}
+
+	tw.Flush()
+
+	notes := []string{}
+	for _, status := range statuses {
+		if status.err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", filepath.Base(status.configFilePath), status.err))
+		} else if status.lastRun != nil && !status.lastRun.Success {
+			notes = append(notes, fmt.Sprintf("%s: last run failed: %s", filepath.Base(status.configFilePath), status.lastRun.Error))
+		}
+		for _, check := range status.checks {
+			if !check.Success {
+				notes = append(notes, fmt.Sprintf("%s: last %s check failed: %s", filepath.Base(status.configFilePath), check.Level, check.Error))
+			}
+		}
+	}
+
+	if len(notes) > 0 {
+		fmt.Println()
+		for _, note := range notes {
+			fmt.Println(note)
+		}
+	}
+}
+
+// formatAge formats a duration in days and hours (or hours and minutes, if less than a day)
+func formatAge(duration time.Duration) string {
+
+	if duration < 0 {
+		duration = 0
+	}
+
+	if duration >= 24*time.Hour {
+		return fmt.Sprintf("%dd%dh", int(duration/(24*time.Hour)), int(duration%(24*time.Hour)/time.Hour))
+	}
+
+	return fmt.Sprintf("%dh%dm", int(duration/time.Hour), int(duration%time.Hour/time.Minute))
+}
+
+func init() {
+
+	rootCmd.AddCommand(statusCmd)
+
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/runlock"
+	"github.com/spf13/cobra"
+)
+
+// unlockCmd represents the unlock command
+var unlockCmd = &cobra.Command{
+	Use:   "unlock [config file path]",
+	Short: "Remove the backup locks of a config file, and of its repository",
+	Long: `Remove the backup locks of a config file, and of its repository.
+
+Locks held by a process that is still running are only removed with --force.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		pathToConfigFile := getOptionalConfigFilePath(args)
+
+		config, err := model.ReadConfigFile(pathToConfigFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if err := runlock.Unlock(pathToConfigFile, config, forceUnlock); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+	},
+}
+
+var forceUnlock bool
+
+func init() {
+
+	unlockCmd.Flags().BoolVarP(&forceUnlock, "force", "f", false, "Remove locks even if the process holding them may still be running")
+
+	rootCmd.AddCommand(unlockCmd)
+
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/restore"
+	"github.com/jgwest/backup-cli/util/cmds/verify"
+	"github.com/spf13/cobra"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [config file path]",
+	Short: "Verify that a sample of files can be restored from the latest backup",
+	Long: `Verify that a sample of files can be restored from the latest backup of a config file.
+
+A sample of files is selected from the folders of the config file (skipping excluded files),
+restored to a temporary folder from the latest snapshot of each folder (of the snapshots taken
+by the config file, when the repository is shared with other config files), and compared (by
+SHA-256) with the live files. Files that were created or modified since the backup of their
+folder are reported as 'changed since backup', rather than as failures.
+
+With '--strategy random' (the default), files are sampled uniformly from all folders; with
+'--strategy stratified', the same number of files is sampled from each folder.
+
+If any sampled file is missing from the backup, or does not match, the exit code is 6.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		pathToConfigFile := getOptionalConfigFilePath(args)
+
+		backend := retrieveBackendFromConfigFile(pathToConfigFile)
+
+		if !backend.SupportsRestore() {
+			reportCLIErrorAndExit(fmt.Errorf("backend '%v' does not support restore, which is required to verify", backend.ConfigType()))
+			return
+		}
+
+		config, err := model.ReadConfigFile(pathToConfigFile)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		seed := verifySeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+
+		sample, err := verify.SampleSourceFiles(pathToConfigFile, config, verifySampleSize, verifyStrategy, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		if len(sample) == 0 {
+			reportCLIErrorAndExit(fmt.Errorf("no files to verify were found in the folders of the config file"))
+			return
+		}
+
+		fmt.Printf("Sampled %d file(s) with seed %d (use --seed to repeat this sample)\n", len(sample), seed)
+
+		results, err := runVerify(cmd.Context(), backend, pathToConfigFile, config, sample)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		printVerifyResults(results)
+
+		failures := 0
+		for _, result := range results {
+			if result.IsFailure() {
+				failures++
+			}
+		}
+
+		if failures > 0 {
+			reportCLIErrorAndExit(&util.ExitCodeError{Code: util.ExitCodeVerifyFailure, Err: fmt.Errorf("%d of %d sampled file(s) failed verification", failures, len(results))})
+			return
+		}
+
+	},
+}
+
+var verifySampleSize int
+var verifyStrategy string
+var verifySeed int64
+
+// runVerify restores the sampled files from the latest backup to a temporary folder, and compares them with the live files.
+func runVerify(ctx context.Context, backend model.Backend, pathToConfigFile string, config model.ConfigFile, sample []verify.SourceFile) ([]verify.FileResult, error) {
+
+	// For snapshot backends, restore the newest snapshot of each folder that was taken by this config file; each file is compared
+	// against the time of the snapshot of its folder
+	selector := model.SnapshotSelector{Type: model.SnapshotSelectLatest}
+	folderSnapshots := map[string]model.Snapshot{}
+
+	if backend.SupportsSnapshots() {
+
+		sourceFolders, snapshots, err := configSnapshots(ctx, backend, pathToConfigFile, config)
+		if err != nil {
+			return nil, err
+		}
+
+		if selector, folderSnapshots, err = verify.SelectBackupSnapshots(snapshots, sourceFolders, configMetadataName(config)); err != nil {
+			return nil, err
+		}
+
+		fmt.Printf("Verifying %d file(s) against:\n", len(sample))
+		for _, sourceFolder := range sourceFolders {
+			snapshot := folderSnapshots[sourceFolder]
+			fmt.Printf("- snapshot %s (%s) of '%s'\n", snapshot.ID, snapshot.Time.Local().Format(time.DateTime), sourceFolder)
+		}
+
+	} else {
+		fmt.Printf("Verifying %d file(s) against the destination folders\n", len(sample))
+	}
+
+	tempDir, err := os.MkdirTemp("", "backup-cli-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	paths := []string{}
+	for _, file := range sample {
+		paths = append(paths, file.Path)
+	}
+
+	options := model.RestoreOptions{Snapshot: selector, Paths: paths, Target: tempDir, Force: true}
+
+	if err := backend.Restore(ctx, pathToConfigFile, options); err != nil {
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		// Some backends fail the whole restore if any path is missing from the backup, so restore each file individually, so
+		// that the other files may still be verified
+		fmt.Println("Unable to restore the sampled files together, so restoring them individually:", err)
+
+		for _, path := range paths {
+			options.Paths = []string{path}
+			if err := backend.Restore(ctx, pathToConfigFile, options); err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				fmt.Printf("Unable to restore '%s': %v\n", path, err)
+			}
+		}
+	}
+
+	results := []verify.FileResult{}
+	for _, file := range sample {
+		// Zero (no snapshot time) for mirror backends
+		backupTime := folderSnapshots[file.Folder].Time
+		results = append(results, verify.CompareFile(file, restore.TargetPath(tempDir, file.Path), backupTime))
+	}
+
+	return results, nil
+}
+
+func printVerifyResults(results []verify.FileResult) {
+
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tFILE\tDETAIL")
+
+	counts := map[verify.FileStatus]int{}
+
+	for _, result := range results {
+		counts[result.Status]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Status, result.Path, result.Detail)
+	}
+
+	tw.Flush()
+
+	fmt.Println()
+	fmt.Printf("%d file(s) verified: %d OK, %d changed since backup, %d mismatched, %d missing, %d errors\n",
+		len(results),
+		counts[verify.FileStatusOK],
+		counts[verify.FileStatusChanged],
+		counts[verify.FileStatusMismatch],
+		counts[verify.FileStatusMissing],
+		counts[verify.FileStatusError])
+}
+
+func init() {
+
+	verifyCmd.Flags().IntVarP(&verifySampleSize, "sample", "n", 20, "Number of files to sample")
+	verifyCmd.Flags().StringVar(&verifyStrategy, "strategy", verify.SampleRandom, "Sample strategy: 'random' or 'stratified' (the same number of files from each folder)")
+	verifyCmd.Flags().Int64Var(&verifySeed, "seed", 0, "Random seed of the sample, to repeat a previous sample; 0 uses a different sample each run")
+
+	rootCmd.AddCommand(verifyCmd)
+
+}
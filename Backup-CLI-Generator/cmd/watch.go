@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [config files, folders of config files, or globs]",
+	Short: "Watch the monitor folders of config files for new folders that are not backed up",
+	Long: `Watch the monitor folders of config files (by default, the config files of the current folder), and report new files and
+folders under them that are not backed up (and not excluded), and monitor folders that disappear. Each problem is logged, and
+sent to the 'notifications' of the config file, if specified:
+
+notifications:
+  command: notify-send "backup-cli" "$BACKUP_CLI_MESSAGE"   # also: BACKUP_CLI_EVENT, BACKUP_CLI_CONFIG, BACKUP_CLI_PATH
+  webhook: https://example.com/hooks/backup                 # the problem is POSTed as JSON
+
+The monitor folders are checked once no change has been seen for '--debounce', and every '--rescan-interval'. Existing problems
+are listed on startup, but only new problems are sent as notifications. Use 'monitor --fix' to resolve them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		if watchDebounce <= 0 || watchRescanInterval <= 0 {
+			reportCLIErrorAndExit(fmt.Errorf("--debounce and --rescan-interval must be positive"))
+			return
+		}
+
+		configFiles, err := discoverConfigFiles(args)
+		if err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+
+		// The notifications of each config file are validated before watching starts
+		for _, configFile := range configFiles {
+			config, err := model.ReadConfigFile(configFile)
+			if err != nil {
+				reportCLIErrorAndExit(fmt.Errorf("unable to read '%s': %w", configFile, err))
+				return
+			}
+			if _, err := config.GetNotifications(); err != nil {
+				reportCLIErrorAndExit(fmt.Errorf("'%s': %w", configFile, err))
+				return
+			}
+		}
+
+		watcher := &watch.Watcher{
+			ConfigPaths:    configFiles,
+			Debounce:       watchDebounce,
+			RescanInterval: watchRescanInterval,
+			OnEvent: func(config model.ConfigFile, event watch.Event) {
+
+				fmt.Printf("%s %s\n", event.Time.Format("2006-01-02 15:04:05"), event.Message())
+
+				notifications, err := config.GetNotifications()
+				if err != nil || notifications == nil {
+					return
+				}
+
+				if err := watch.SendNotification(cmd.Context(), *notifications, event); err != nil {
+					fmt.Println("Warning: unable to send notification:", err)
+				}
+			},
+		}
+
+		fmt.Printf("Watching the monitor folders of %d config file(s).\n", len(configFiles))
+
+		if err := watcher.Run(cmd.Context()); err != nil {
+			reportCLIErrorAndExit(err)
+			return
+		}
+	},
+}
+
+var (
+	watchDebounce       time.Duration
+	watchRescanInterval time.Duration
+)
+
+func init() {
+
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 5*time.Second, "Time without changes after which the monitor folders are checked")
+	watchCmd.Flags().DurationVar(&watchRescanInterval, "rescan-interval", 10*time.Minute, "Interval at which the monitor folders are checked, regardless of changes")
+
+	rootCmd.AddCommand(watchCmd)
+
+}
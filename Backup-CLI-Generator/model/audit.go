@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultAuditRuleDepth is the number of folder levels below the path of an audit rule that are evaluated, when 'depth' is not
+// specified.
+const DefaultAuditRuleDepth = 3
+
+// AuditRules is an audit rules file, which describes the coverage that is required of the config files passed to 'audit'
+type AuditRules struct {
+	Rules []AuditRule `yaml:"rules"`
+}
+
+// AuditRule requires that every file and folder under Path (down to Depth levels, and not matching Excludes) is backed up to at
+// least MinCopies repositories or destination folders, at least MinOffsiteCopies of which are offsite.
+type AuditRule struct {
+	Name             string   `yaml:"name,omitempty"`
+	Path             string   `yaml:"path"`
+	MinCopies        int      `yaml:"minCopies,omitempty"`
+	MinOffsiteCopies int      `yaml:"minOffsiteCopies,omitempty"`
+	Depth            *int     `yaml:"depth,omitempty"`
+	Excludes         []string `yaml:"excludes,omitempty"`
+}
+
+// ReadAuditRules reads and validates an audit rules file.
+func ReadAuditRules(path string) (AuditRules, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return AuditRules{}, err
+	}
+
+	rules := AuditRules{}
+	if err := yaml.UnmarshalStrict(content, &rules); err != nil {
+		return AuditRules{}, fmt.Errorf("unable to parse audit rules '%s': %w", path, err)
+	}
+
+	if len(rules.Rules) == 0 {
+		return AuditRules{}, fmt.Errorf("audit rules '%s' has no rules", path)
+	}
+
+	for index, rule := range rules.Rules {
+
+		if rule.Path == "" {
+			return AuditRules{}, fmt.Errorf("audit rule %d has no path", index+1)
+		}
+
+		if rule.MinCopies < 0 || rule.MinOffsiteCopies < 0 || (rule.Depth != nil && *rule.Depth < 0) {
+			return AuditRules{}, fmt.Errorf("audit rule '%s': minCopies, minOffsiteCopies and depth must not be negative", rule.Description())
+		}
+
+		if rule.MinCopies == 0 && rule.MinOffsiteCopies == 0 {
+			return AuditRules{}, fmt.Errorf("audit rule '%s' requires neither minCopies nor minOffsiteCopies", rule.Description())
+		}
+
+		if rule.MinOffsiteCopies > rule.MinCopies {
+			rules.Rules[index].MinCopies = rule.MinOffsiteCopies
+		}
+	}
+
+	return rules, nil
+}
+
+// GetDepth returns the depth of the rule, or DefaultAuditRuleDepth if not specified.
+func (ar AuditRule) GetDepth() int {
+
+	if ar.Depth == nil {
+		return DefaultAuditRuleDepth
+	}
+
+	return *ar.Depth
+}
+
+// Description returns the name of the rule, or if not specified its path.
+func (ar AuditRule) Description() string {
+
+	if ar.Name != "" {
+		return ar.Name
+	}
+
+	return ar.Path
+}
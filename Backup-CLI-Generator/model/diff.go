@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DiffOptions are the command line options of a diff invocation
+type DiffOptions struct {
+	// From and To are the IDs (or ID prefixes) of the snapshots to compare; if both are empty, the previous snapshot is
+	// compared with the latest snapshot
+	From string
+	To   string
+}
+
+type DiffChange string
+
+const (
+	DiffAdded    DiffChange = "added"
+	DiffRemoved  DiffChange = "removed"
+	DiffModified DiffChange = "modified"
+)
+
+// DiffEntry is a path that differs between two snapshots
+type DiffEntry struct {
+	Path   string     `json:"path"`
+	Change DiffChange `json:"change"`
+	IsDir  bool       `json:"isDir,omitempty"`
+
+	// Size is the size in bytes of the file in the newer snapshot (or, if removed, in the older snapshot); OldSize is the size
+	// of a modified file in the older snapshot. Sizes are 0 for folders, or if not reported by the backend.
+	Size    int64 `json:"size"`
+	OldSize int64 `json:"oldSize,omitempty"`
+}
+
+// SnapshotDiff is the list of paths that differ between two snapshots, sorted by path
+type SnapshotDiff struct {
+	From    Snapshot    `json:"from"`
+	To      Snapshot    `json:"to"`
+	Changes []DiffEntry `json:"changes"`
+}
+
+// SortChanges sorts the changes of the diff by path.
+func (sd *SnapshotDiff) SortChanges() {
+	sort.SliceStable(sd.Changes, func(i, j int) bool {
+		return sd.Changes[i].Path < sd.Changes[j].Path
+	})
+}
+
+// SelectDiffSnapshots returns the snapshots identified by the From and To IDs of the options, or, if neither is specified, the
+// previous and latest snapshots.
+func SelectDiffSnapshots(snapshots []Snapshot, options DiffOptions) (Snapshot, Snapshot, error) {
+
+	if options.From == "" && options.To == "" {
+
+		if len(snapshots) < 2 {
+			return Snapshot{}, Snapshot{}, fmt.Errorf("at least two snapshots are required to compare, but %d found", len(snapshots))
+		}
+
+		sorted := append([]Snapshot{}, snapshots...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Time.Before(sorted[j].Time)
+		})
+
+		return sorted[len(sorted)-2], sorted[len(sorted)-1], nil
+	}
+
+	if options.From == "" || options.To == "" {
+		return Snapshot{}, Snapshot{}, fmt.Errorf("both snapshots to compare must be specified, or neither")
+	}
+
+	from, err := SelectSnapshot(snapshots, SnapshotSelector{Type: SnapshotSelectID, Value: options.From})
+	if err != nil {
+		return Snapshot{}, Snapshot{}, err
+	}
+
+	to, err := SelectSnapshot(snapshots, SnapshotSelector{Type: SnapshotSelectID, Value: options.To})
+	if err != nil {
+		return Snapshot{}, Snapshot{}, err
+	}
+
+	return from, to, nil
+}
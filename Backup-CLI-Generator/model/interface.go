@@ -1,5 +1,11 @@
 package model
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 type Backend interface {
 	ConfigType() ConfigType
 
@@ -11,22 +17,194 @@ type Backend interface {
 	GenerateBackup(path string, outputPath string) error
 	GenerateGeneric(path string, outputPath string) error
 
+	SupportsGenerateGraph() bool
+
+	// GenerateGraph returns the dependency graph of the text nodes of the backup script of the config file, in DOT format
+	GenerateGraph(path string) (string, error)
+
 	// direct invocation
 
 	SupportsBackup() bool
-	SupportsQuickCheck() bool
+	SupportsCheck() bool
 	SupportsRun() bool
 
-	QuickCheck(path string) error
-	Run(path string, args []string) error
+	// Check verifies the integrity of the repository (or, for mirror backends, the destination folders) of the config file
+	Check(ctx context.Context, path string, options CheckOptions) error
+	Run(ctx context.Context, path string, args []string) error
+
+	Backup(ctx context.Context, path string, options BackupOptions) error
+
+	SupportsRestore() bool
+
+	Restore(ctx context.Context, path string, options RestoreOptions) error
+
+	SupportsSnapshots() bool
+
+	// Snapshots returns the snapshots (or archives) of the repository of the config file
+	Snapshots(ctx context.Context, path string) ([]Snapshot, error)
+
+	SupportsDiff() bool
+
+	// Diff compares two snapshots of the repository of the config file (for mirror backends, the manifests recorded by two
+	// backups), returning one diff for each pair of snapshots compared
+	Diff(ctx context.Context, path string, options DiffOptions) ([]SnapshotDiff, error)
+
+	SupportsInitRepo() bool
 
-	Backup(path string, rehashSource bool) error
+	// InitRepo creates the repository of the config file from its credentials, unless it already exists
+	InitRepo(ctx context.Context, path string, options InitRepoOptions) error
+
+	SupportsRotateKey() bool
+
+	// AddKey adds the new password as a key of the repository of the config file (or, if the repository has a single password,
+	// changes it), and verifies that the repository can be opened with the new password
+	AddKey(ctx context.Context, path string, newPassword string) (KeyRotation, error)
+
+	// RemoveOldKey removes the key that the new key was added alongside; the config file must already use the new password
+	RemoveOldKey(ctx context.Context, path string, rotation KeyRotation) error
+
+	SupportsFolderPairs() bool
+
+	// FolderPairs returns the source folders of a mirror backend, and the destination folder that each is mirrored to
+	FolderPairs(path string) ([]FolderPair, error)
 
 	SupportsBackupShellScriptDiffCheck() bool
 
 	BackupShellScriptDiffCheck(configFilePath string, shellScriptPath string) error
 }
 
+// BackupOptions are the command line options of a backup invocation
+type BackupOptions struct {
+	// RehashSource: when deciding what files to backup, rehash the source files
+	RehashSource bool
+
+	// Parallel is the maximum number of folder pairs to backup concurrently; if 0, the 'concurrency' value of the config file is used.
+	Parallel int
+
+	// WaitForLock: if another process holds the lock of the config file or repository, wait for it to be released rather than failing
+	WaitForLock bool
+
+	// LockWaitTimeout is the maximum time to wait for a lock, when WaitForLock is set; if 0, wait indefinitely.
+	LockWaitTimeout time.Duration
+
+	// Resume: skip the folder pairs that were successfully backed up by a previous run, within ResumeWindow
+	Resume bool
+
+	// ResumeWindow is the maximum age of a previous folder pair completion for it to be skipped, with Resume
+	ResumeWindow time.Duration
+}
+
+// InitRepoOptions are the command line options of an init-repo invocation
+type InitRepoOptions struct {
+	// ApplyPolicy: apply the retention and compression 'policy' of the config file to the repository, whether or not it was
+	// just created
+	ApplyPolicy bool
+}
+
+// KeyRotation is the result of adding a new repository key, which identifies the old key to remove
+type KeyRotation struct {
+	// OldKeyID is the ID of the old key, or "" if the password was changed in place (there is no old key to remove)
+	OldKeyID string
+}
+
+// RestoreOptions are the command line options of a restore invocation
+type RestoreOptions struct {
+	// Snapshot selects the snapshot to restore
+	Snapshot SnapshotSelector
+
+	// Paths, if non-empty, restricts the restore to these files/folders (absolute paths, as they were backed up)
+	Paths []string
+
+	// Target is the folder to restore to: each file is restored to the target folder joined with the original absolute path
+	// of the file (without the volume name)
+	Target string
+
+	// Force: restore even if the target folder is not empty
+	Force bool
+}
+
+// CheckLevel is the thoroughness of an integrity check
+type CheckLevel string
+
+const (
+	// CheckLevelQuick checks the repository metadata (or, for mirror backends, compares file sizes), without reading file data
+	CheckLevelQuick CheckLevel = "quick"
+	// CheckLevelStandard additionally reads a small subset of the file data (or, for mirror backends, compares hashes or
+	// modification times)
+	CheckLevelStandard CheckLevel = "standard"
+	// CheckLevelDeep reads all of the file data, or CheckOptions.ReadDataPercent of it
+	CheckLevelDeep CheckLevel = "deep"
+)
+
+// CheckOptions are the command line options of a check invocation
+type CheckOptions struct {
+	Level CheckLevel
+
+	// ReadDataPercent, if non-zero, is the percentage of the file data that a deep check reads
+	ReadDataPercent int
+}
+
+// StandardCheckReadDataPercent is the percentage of the file data that a standard check reads
+const StandardCheckReadDataPercent = 5
+
+// DataPercent returns the percentage of the file data that the check should read: none for a quick check,
+// StandardCheckReadDataPercent for a standard check, and ReadDataPercent (or all) for a deep check.
+func (co CheckOptions) DataPercent() (int, error) {
+
+	switch co.Level {
+	case CheckLevelQuick:
+		return 0, nil
+	case CheckLevelStandard:
+		return StandardCheckReadDataPercent, nil
+	case CheckLevelDeep:
+		if co.ReadDataPercent < 0 || co.ReadDataPercent > 100 {
+			return 0, fmt.Errorf("the percentage of data to read must be between 1 and 100")
+		}
+		if co.ReadDataPercent == 0 {
+			return 100, nil
+		}
+		return co.ReadDataPercent, nil
+	}
+
+	return 0, fmt.Errorf("unrecognized check level '%s': expected one of %s, %s, %s", co.Level, CheckLevelQuick, CheckLevelStandard, CheckLevelDeep)
+}
+
+// FolderPair is a source folder, and the destination folder that it is mirrored to
+type FolderPair struct {
+	Source string
+	Dest   string
+}
+
+type SnapshotSelectorType string
+
+const (
+	// SnapshotSelectLatest selects the newest snapshot
+	SnapshotSelectLatest SnapshotSelectorType = "latest"
+	// SnapshotSelectTag selects the newest snapshot with a tag (or description/archive name) that equals, or starts with, Value
+	SnapshotSelectTag SnapshotSelectorType = "tag"
+	// SnapshotSelectID selects the snapshot with an ID that equals, or starts with, Value
+	SnapshotSelectID SnapshotSelectorType = "id"
+	// SnapshotSelectTime selects the newest snapshot taken at or before Time
+	SnapshotSelectTime SnapshotSelectorType = "time"
+)
+
+// SnapshotSelector identifies a snapshot of a repository
+type SnapshotSelector struct {
+	Type  SnapshotSelectorType
+	Value string
+	Time  time.Time
+}
+
+func (ss SnapshotSelector) String() string {
+	switch ss.Type {
+	case SnapshotSelectTag, SnapshotSelectID:
+		return fmt.Sprintf("%s '%s'", ss.Type, ss.Value)
+	case SnapshotSelectTime:
+		return fmt.Sprintf("time '%s'", ss.Time.Format(time.RFC3339))
+	}
+	return string(ss.Type)
+}
+
 type BackendStruct struct {
 	ConfigType func() ConfigType
 
@@ -36,9 +214,9 @@ type BackendStruct struct {
 	GenerateGeneric func(path string, outputPath string) error
 
 	// direct invocation
-	Run        func(path string, args []string) error
-	Backup     func(path string) error
-	QuickCheck func(path string) error
+	Run    func(path string, args []string) error
+	Backup func(path string) error
+	Check  func(path string) error
 
 	// misc
 
@@ -0,0 +1,199 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Limits restricts the network bandwidth and IO priority of backups. Bandwidth values are in KiB/s, where 0 is unlimited.
+type Limits struct {
+	UploadKiBps   int `yaml:"uploadKiBps,omitempty"`
+	DownloadKiBps int `yaml:"downloadKiBps,omitempty"`
+
+	// Schedule is a list of time-of-day windows during which different bandwidth limits apply; outside of the windows,
+	// UploadKiBps and DownloadKiBps apply.
+	Schedule []LimitWindow `yaml:"schedule,omitempty"`
+
+	// IOPriority is one of 'normal' (the default), 'low' or 'idle'. It is applied via ionice on Linux, taskpolicy on macOS,
+	// and nice (CPU priority only) on other Unix OSes, and is ignored on Windows.
+	IOPriority string `yaml:"ioPriority,omitempty"`
+}
+
+// LimitWindow applies bandwidth limits between Start and End (local time, in 'HH:MM' format). If End is before Start, the
+// window spans midnight.
+type LimitWindow struct {
+	Start         string `yaml:"start"`
+	End           string `yaml:"end"`
+	UploadKiBps   int    `yaml:"uploadKiBps,omitempty"`
+	DownloadKiBps int    `yaml:"downloadKiBps,omitempty"`
+}
+
+const (
+	IOPriorityNormal = "normal"
+	IOPriorityLow    = "low"
+	IOPriorityIdle   = "idle"
+)
+
+// BandwidthLimit is an upload/download limit in KiB/s, where 0 is unlimited.
+type BandwidthLimit struct {
+	UploadKiBps   int
+	DownloadKiBps int
+}
+
+// BandwidthChange indicates that, from the time-of-day 'Minute' (minutes after midnight), Limit applies.
+type BandwidthChange struct {
+	Minute int
+	Limit  BandwidthLimit
+}
+
+const minutesPerDay = 24 * 60
+
+// GetLimits returns the validated 'limits' of the config file, or empty (unlimited) limits if not specified.
+func (cf *ConfigFile) GetLimits() (Limits, error) {
+
+	if cf.Limits == nil {
+		return Limits{}, nil
+	}
+
+	if err := cf.Limits.validate(); err != nil {
+		return Limits{}, fmt.Errorf("invalid limits: %w", err)
+	}
+
+	return *cf.Limits, nil
+}
+
+func (l Limits) validate() error {
+
+	if l.UploadKiBps < 0 || l.DownloadKiBps < 0 {
+		return fmt.Errorf("bandwidth limits must not be negative")
+	}
+
+	switch l.IOPriority {
+	case "", IOPriorityNormal, IOPriorityLow, IOPriorityIdle:
+	default:
+		return fmt.Errorf("unrecognized ioPriority '%s': expected one of %s, %s, %s", l.IOPriority, IOPriorityNormal, IOPriorityLow, IOPriorityIdle)
+	}
+
+	// The window (if any) that each minute of the day is in, to detect overlapping windows
+	minuteToWindow := make([]*LimitWindow, minutesPerDay)
+
+	for index := range l.Schedule {
+
+		window := &l.Schedule[index]
+
+		if window.UploadKiBps < 0 || window.DownloadKiBps < 0 {
+			return fmt.Errorf("bandwidth limits must not be negative")
+		}
+
+		start, end, err := window.minutes()
+		if err != nil {
+			return err
+		}
+
+		for minute := start; minute != end; minute = (minute + 1) % minutesPerDay {
+			if other := minuteToWindow[minute]; other != nil {
+				return fmt.Errorf("schedule windows %s-%s and %s-%s overlap", other.Start, other.End, window.Start, window.End)
+			}
+			minuteToWindow[minute] = window
+		}
+	}
+
+	return nil
+}
+
+// minutes returns the start and end of the window, as minutes after midnight.
+func (lw LimitWindow) minutes() (int, int, error) {
+
+	start, err := parseTimeOfDay(lw.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := parseTimeOfDay(lw.End)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start == end {
+		return 0, 0, fmt.Errorf("schedule window %s-%s is empty", lw.Start, lw.End)
+	}
+
+	return start, end, nil
+}
+
+func parseTimeOfDay(value string) (int, error) {
+
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day '%s': expected HH:MM", value)
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// DefaultBandwidth returns the bandwidth limit that applies outside of the schedule windows.
+func (l Limits) DefaultBandwidth() BandwidthLimit {
+	return BandwidthLimit{UploadKiBps: l.UploadKiBps, DownloadKiBps: l.DownloadKiBps}
+}
+
+// BandwidthAt returns the bandwidth limit that applies at the given time. The limits must have been validated.
+func (l Limits) BandwidthAt(t time.Time) BandwidthLimit {
+
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, window := range l.Schedule {
+
+		start, end, err := window.minutes()
+		if err != nil {
+			continue
+		}
+
+		inWindow := minute >= start && minute < end
+		if end < start {
+			inWindow = minute >= start || minute < end
+		}
+
+		if inWindow {
+			return BandwidthLimit{UploadKiBps: window.UploadKiBps, DownloadKiBps: window.DownloadKiBps}
+		}
+	}
+
+	return l.DefaultBandwidth()
+}
+
+// BandwidthTimetable returns the bandwidth changes over a day, in time-of-day order: at the start of each window the
+// window's limit applies, and at the end of the window the default limit applies (unless another window starts then).
+// The limits must have been validated.
+func (l Limits) BandwidthTimetable() []BandwidthChange {
+
+	// minute -> change at that minute; window starts take precedence over window ends
+	changes := map[int]BandwidthChange{}
+
+	for _, window := range l.Schedule {
+		_, end, err := window.minutes()
+		if err != nil {
+			continue
+		}
+		changes[end] = BandwidthChange{Minute: end, Limit: l.DefaultBandwidth()}
+	}
+
+	for _, window := range l.Schedule {
+		start, _, err := window.minutes()
+		if err != nil {
+			continue
+		}
+		changes[start] = BandwidthChange{Minute: start, Limit: BandwidthLimit{UploadKiBps: window.UploadKiBps, DownloadKiBps: window.DownloadKiBps}}
+	}
+
+	res := []BandwidthChange{}
+	for _, change := range changes {
+		res = append(res, change)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Minute < res[j].Minute
+	})
+
+	return res
+}
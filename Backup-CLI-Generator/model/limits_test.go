@@ -0,0 +1,70 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLimits(t *testing.T) {
+
+	officeHours := Limits{
+		UploadKiBps: 4096,
+		Schedule: []LimitWindow{
+			{Start: "08:00", End: "18:00", UploadKiBps: 512},
+			{Start: "22:00", End: "02:00", DownloadKiBps: 100},
+		},
+	}
+
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	for _, c := range []struct {
+		name     string
+		time     time.Time
+		expected BandwidthLimit
+	}{
+		{name: "before window", time: at(7, 59), expected: BandwidthLimit{UploadKiBps: 4096}},
+		{name: "start of window", time: at(8, 0), expected: BandwidthLimit{UploadKiBps: 512}},
+		{name: "end of window", time: at(18, 0), expected: BandwidthLimit{UploadKiBps: 4096}},
+		{name: "window spanning midnight, before midnight", time: at(23, 0), expected: BandwidthLimit{DownloadKiBps: 100}},
+		{name: "window spanning midnight, after midnight", time: at(1, 59), expected: BandwidthLimit{DownloadKiBps: 100}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := officeHours.BandwidthAt(c.time); actual != c.expected {
+				t.Errorf("unexpected limit: %v, expected %v", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("timetable", func(t *testing.T) {
+		expected := []BandwidthChange{
+			{Minute: 2 * 60, Limit: BandwidthLimit{UploadKiBps: 4096}},
+			{Minute: 8 * 60, Limit: BandwidthLimit{UploadKiBps: 512}},
+			{Minute: 18 * 60, Limit: BandwidthLimit{UploadKiBps: 4096}},
+			{Minute: 22 * 60, Limit: BandwidthLimit{DownloadKiBps: 100}},
+		}
+
+		if actual := officeHours.BandwidthTimetable(); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("unexpected timetable: %v", actual)
+		}
+	})
+
+	for _, c := range []struct {
+		name   string
+		limits Limits
+	}{
+		{name: "overlapping windows", limits: Limits{Schedule: []LimitWindow{{Start: "08:00", End: "18:00"}, {Start: "17:00", End: "19:00"}}}},
+		{name: "invalid time", limits: Limits{Schedule: []LimitWindow{{Start: "8am", End: "18:00"}}}},
+		{name: "negative limit", limits: Limits{UploadKiBps: -1}},
+		{name: "unrecognized io priority", limits: Limits{IOPriority: "high"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			config := ConfigFile{Limits: &c.limits}
+			if _, err := config.GetLimits(); err == nil {
+				t.Errorf("expected invalid limits to be rejected")
+			}
+		})
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"gopkg.in/yaml.v2"
@@ -17,6 +22,26 @@ type ConfigFile struct {
 	Folders          []Folder          `yaml:"folders,omitempty"`
 	MonitorFolders   []MonitorFolder   `yaml:"monitorFolders,omitempty"`
 	RobocopySettings *RobocopySettings `yaml:"robocopySettings,omitempty"`
+	Concurrency      int               `yaml:"concurrency,omitempty"`
+	Retry            *RetryPolicy      `yaml:"retry,omitempty"`
+
+	// MaxDuration is the maximum duration of a backup (in Go duration format, e.g. '6h'), after which it is cancelled.
+	MaxDuration string `yaml:"maxDuration,omitempty"`
+
+	// MaxAge is the maximum age of the newest backup (in Go duration format, or in days, e.g. '2d'), beyond which 'status'
+	// reports the config file as stale.
+	MaxAge string `yaml:"maxAge,omitempty"`
+
+	Limits *Limits `yaml:"limits,omitempty"`
+
+	Policy *RepositoryPolicy `yaml:"policy,omitempty"`
+
+	Notifications *Notifications `yaml:"notifications,omitempty"`
+
+	// Priority and After are used by 'backup-all' to order config files: configs with a higher priority are started first, and
+	// a config is not started until the config files listed in 'after' (relative to this config file) have completed.
+	Priority int      `yaml:"priority,omitempty"`
+	After    []string `yaml:"after,omitempty"`
 }
 
 type Metadata struct {
@@ -24,9 +49,35 @@ type Metadata struct {
 	AppendDateTime bool   `yaml:"appendDateTime"`
 }
 
+// MonitorFolder is a folder whose contents must be backed up: a file or folder under it that is not backed up (and not excluded)
+// is reported as an error.
 type MonitorFolder struct {
 	Path     string   `yaml:"path"`
 	Excludes []string `yaml:"excludes,omitempty"`
+
+	// Depth is the number of folder levels below the path that are checked (default 1: the folders directly under the path)
+	Depth int `yaml:"depth,omitempty"`
+
+	// Include, if specified, are the glob patterns of the files and folders to check: a pattern without a path separator is
+	// matched against the name, any other pattern against the path relative to the monitor folder
+	Include []string `yaml:"include,omitempty"`
+
+	// Files is true if files, as well as folders, are checked
+	Files bool `yaml:"files,omitempty"`
+}
+
+// GetDepth returns the depth of the monitor folder, or 1 if not specified.
+func (mf MonitorFolder) GetDepth() (int, error) {
+
+	if mf.Depth < 0 {
+		return 0, fmt.Errorf("monitor folder '%s': depth must not be negative", mf.Path)
+	}
+
+	if mf.Depth == 0 {
+		return 1, nil
+	}
+
+	return mf.Depth, nil
 }
 
 type Folder struct {
@@ -44,6 +95,18 @@ type RcloneFolderSettings struct {
 	DestFolderName string `yaml:"destFolderName"`
 }
 
+// RetryPolicy describes how backend invocations that fail with a transient error are retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first
+	Attempts int `yaml:"attempts"`
+	// InitialDelay is the delay before the first retry, as a duration (e.g. '30s'); the delay doubles on each subsequent retry.
+	InitialDelay string `yaml:"initialDelay,omitempty"`
+	// MaxDelay is the maximum delay between retries, as a duration (e.g. '10m')
+	MaxDelay string `yaml:"maxDelay,omitempty"`
+	// Jitter is the fraction (0-1) by which each delay is randomly increased or decreased
+	Jitter float64 `yaml:"jitter,omitempty"`
+}
+
 type Substitution struct {
 	Name  string `yaml:"name"`
 	Value string `yaml:"value"`
@@ -101,6 +164,80 @@ type RobocopySettings struct {
 	ExcludeFolders []string `yaml:"excludeFolders,omitempty"`
 }
 
+// GetConcurrency returns the maximum number of folder pairs to backup concurrently: the command line value if specified,
+// otherwise the config file value, otherwise 1.
+func (cf *ConfigFile) GetConcurrency(options BackupOptions) (int, error) {
+
+	if options.Parallel < 0 || cf.Concurrency < 0 {
+		return 0, fmt.Errorf("concurrency must be a positive value")
+	}
+
+	if options.Parallel > 0 {
+		return options.Parallel, nil
+	}
+
+	if cf.Concurrency > 0 {
+		return cf.Concurrency, nil
+	}
+
+	return 1, nil
+}
+
+// GetMaxDuration returns the parsed 'maxDuration' value, or 0 if not specified.
+func (cf *ConfigFile) GetMaxDuration() (time.Duration, error) {
+
+	if cf.MaxDuration == "" {
+		return 0, nil
+	}
+
+	maxDuration, err := time.ParseDuration(cf.MaxDuration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maxDuration '%s': %v", cf.MaxDuration, err)
+	}
+
+	if maxDuration <= 0 {
+		return 0, fmt.Errorf("maxDuration must be a positive value")
+	}
+
+	return maxDuration, nil
+}
+
+// DefaultMaxAge is the maximum age of the newest backup, when 'maxAge' is not specified.
+const DefaultMaxAge = 48 * time.Hour
+
+// GetMaxAge returns the parsed 'maxAge' value, or DefaultMaxAge if not specified.
+func (cf *ConfigFile) GetMaxAge() (time.Duration, error) {
+
+	if cf.MaxAge == "" {
+		return DefaultMaxAge, nil
+	}
+
+	maxAge, err := parseDays(cf.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maxAge '%s': %v", cf.MaxAge, err)
+	}
+
+	if maxAge <= 0 {
+		return 0, fmt.Errorf("maxAge must be a positive value")
+	}
+
+	return maxAge, nil
+}
+
+// parseDays parses a Go duration, or a whole number of days with a 'd' suffix (e.g. '7d').
+func parseDays(value string) (time.Duration, error) {
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		numDays, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days '%s'", days)
+		}
+		return time.Duration(numDays) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
 type ConfigType string
 
 const (
@@ -260,6 +397,95 @@ func (cf *ConfigFile) GetConfigType() (ConfigType, error) {
 	return "", errors.New("no credentials found")
 }
 
+// GetRepositoryID returns a string that uniquely identifies the repository (or destination folder) that the config file backs up to.
+// Two config files that return the same value are backing up to the same repository.
+func (cf *ConfigFile) GetRepositoryID() (string, error) {
+
+	configType, err := cf.GetConfigType()
+	if err != nil {
+		return "", err
+	}
+
+	credential := cf.Credentials[0]
+
+	switch configType {
+	case Restic:
+		if credential.Restic.S3 != nil {
+			return "restic:s3:" + credential.Restic.S3.URL, nil
+		} else if credential.Restic.RESTEndpoint != "" {
+			return "restic:rest:" + credential.Restic.RESTEndpoint, nil
+		}
+		return "", errors.New("unable to locate connection credentials")
+
+	case Kopia:
+		if credential.Kopia.KopiaS3 == nil {
+			return "", errors.New("missing S3 credentials")
+		}
+		return "kopia:s3:" + credential.Kopia.KopiaS3.Endpoint + "/" + credential.Kopia.KopiaS3.Bucket, nil
+
+	case Tarsnap:
+		return "tarsnap:" + normalizeRepositoryPath(credential.Tarsnap.ConfigFilePath), nil
+
+	case Robocopy:
+		return "folder:" + normalizeRepositoryPath(credential.Robocopy.DestinationFolder), nil
+
+	case Rclone:
+		return "folder:" + normalizeRepositoryPath(credential.Rclone.DestinationFolder), nil
+	}
+
+	return "", fmt.Errorf("unsupported config type: %v", configType)
+}
+
+// IsOffsite returns true if the config file backs up to a remote location: an S3 repository (restic, kopia), tarsnap, or an rclone
+// remote. Restic REST servers and destination folders (robocopy, or rclone without a remote) are assumed to be local.
+func (cf *ConfigFile) IsOffsite() (bool, error) {
+
+	configType, err := cf.GetConfigType()
+	if err != nil {
+		return false, err
+	}
+
+	credential := cf.Credentials[0]
+
+	switch configType {
+	case Restic:
+		return credential.Restic.S3 != nil, nil
+	case Kopia:
+		return credential.Kopia.KopiaS3 != nil, nil
+	case Tarsnap:
+		return true, nil
+	case Robocopy:
+		return false, nil
+	case Rclone:
+		return isRcloneRemotePath(credential.Rclone.DestinationFolder), nil
+	}
+
+	return false, fmt.Errorf("unsupported config type: %v", configType)
+}
+
+// isRcloneRemotePath returns true if the path is on an rclone remote ('remote:path'), rather than a local path (including Windows
+// paths with a drive letter, such as 'C:\backup').
+func isRcloneRemotePath(path string) bool {
+
+	remote, _, found := strings.Cut(path, ":")
+
+	return found && len(remote) > 1 && !strings.ContainsAny(remote, `/\`)
+}
+
+// normalizeRepositoryPath returns an absolute, cleaned path, which is lowercase on Windows.
+func normalizeRepositoryPath(path string) string {
+
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+
+	return path
+}
+
 func (cf *ConfigFile) GetRobocopyCredential() (RobocopyCredentials, error) {
 
 	// Must have a single kopia credential
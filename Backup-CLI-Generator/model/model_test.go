@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetMaxAge(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		maxAge      string
+		expected    time.Duration
+		expectError bool
+	}{
+		{name: "default", maxAge: "", expected: DefaultMaxAge},
+		{name: "duration", maxAge: "36h", expected: 36 * time.Hour},
+		{name: "days", maxAge: "7d", expected: 7 * 24 * time.Hour},
+		{name: "invalid days", maxAge: "xd", expectError: true},
+		{name: "invalid duration", maxAge: "soon", expectError: true},
+		{name: "zero", maxAge: "0d", expectError: true},
+		{name: "negative", maxAge: "-1h", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			config := ConfigFile{MaxAge: test.maxAge}
+
+			maxAge, err := config.GetMaxAge()
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", maxAge)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if maxAge != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, maxAge)
+			}
+		})
+	}
+}
+
+func TestIsRcloneRemotePath(t *testing.T) {
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "b2:bucket/backup", expected: true},
+		{path: "my-remote:", expected: true},
+		{path: "/mnt/backup", expected: false},
+		{path: `C:\backup`, expected: false},
+		{path: "./local:dir", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if actual := isRcloneRemotePath(test.path); actual != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
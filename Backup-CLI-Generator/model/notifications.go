@@ -0,0 +1,34 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Notifications describes how 'watch' reports the problems it finds with the monitor folders of a config file (new folders
+// that are not backed up, and monitor folders that disappear).
+type Notifications struct {
+	// Command is run (by 'sh -c', or 'cmd /C' on Windows) for each problem, with the problem described by the environment
+	// variables BACKUP_CLI_EVENT, BACKUP_CLI_MESSAGE, BACKUP_CLI_CONFIG and BACKUP_CLI_PATH.
+	Command string `yaml:"command,omitempty"`
+
+	// Webhook is a URL to which each problem is POSTed, as JSON.
+	Webhook string `yaml:"webhook,omitempty"`
+}
+
+// GetNotifications returns the validated 'notifications' of the config file, or nil if not specified.
+func (cf *ConfigFile) GetNotifications() (*Notifications, error) {
+
+	if cf.Notifications == nil {
+		return nil, nil
+	}
+
+	if cf.Notifications.Webhook != "" {
+		webhookURL, err := url.Parse(cf.Notifications.Webhook)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return nil, fmt.Errorf("notifications webhook must be an http or https URL: '%s'", cf.Notifications.Webhook)
+		}
+	}
+
+	return cf.Notifications, nil
+}
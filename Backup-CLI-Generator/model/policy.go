@@ -0,0 +1,43 @@
+package model
+
+import "fmt"
+
+// RepositoryPolicy is the retention and compression policy of the repository of a config file (restic, kopia). It is applied by
+// 'init-repo --apply-policy'.
+type RepositoryPolicy struct {
+	// KeepLast and the other Keep values are the number of snapshots to keep of each period (restic 'forget --keep-*', kopia
+	// 'policy set --keep-*'); values that are not specified are not applied.
+	KeepLast    int `yaml:"keepLast,omitempty"`
+	KeepHourly  int `yaml:"keepHourly,omitempty"`
+	KeepDaily   int `yaml:"keepDaily,omitempty"`
+	KeepWeekly  int `yaml:"keepWeekly,omitempty"`
+	KeepMonthly int `yaml:"keepMonthly,omitempty"`
+	KeepYearly  int `yaml:"keepYearly,omitempty"`
+
+	// Compression is the compression of new data: for restic 'auto', 'off' or 'max'; for kopia, a compressor name (as listed by
+	// 'kopia benchmark compression', e.g. 'zstd') or 'none'.
+	Compression string `yaml:"compression,omitempty"`
+}
+
+// GetPolicy returns the validated 'policy' of the config file, or nil if not specified.
+func (cf *ConfigFile) GetPolicy() (*RepositoryPolicy, error) {
+
+	if cf.Policy == nil {
+		return nil, nil
+	}
+
+	policy := *cf.Policy
+
+	for _, keep := range []int{policy.KeepLast, policy.KeepHourly, policy.KeepDaily, policy.KeepWeekly, policy.KeepMonthly, policy.KeepYearly} {
+		if keep < 0 {
+			return nil, fmt.Errorf("invalid policy: keep values must not be negative")
+		}
+	}
+
+	return &policy, nil
+}
+
+// HasRetention returns true if the policy keeps snapshots of any period.
+func (rp RepositoryPolicy) HasRetention() bool {
+	return rp.KeepLast > 0 || rp.KeepHourly > 0 || rp.KeepDaily > 0 || rp.KeepWeekly > 0 || rp.KeepMonthly > 0 || rp.KeepYearly > 0
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Snapshot is a snapshot (restic, kopia) or archive (tarsnap) of a repository.
+type Snapshot struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Host string    `json:"host,omitempty"`
+
+	// Tags are the restic tags, kopia description, or tarsnap archive name, of the snapshot
+	Tags []string `json:"tags,omitempty"`
+
+	// Paths are the source paths contained in the snapshot
+	Paths []string `json:"paths,omitempty"`
+
+	// Size is the total size of the files of the snapshot in bytes, or 0 if not reported by the backend
+	Size int64 `json:"size,omitempty"`
+}
+
+// SelectSnapshot returns the snapshot matched by the selector; when multiple snapshots match, the newest is returned.
+func SelectSnapshot(snapshots []Snapshot, selector SnapshotSelector) (Snapshot, error) {
+
+	var selected *Snapshot
+
+	for index := range snapshots {
+
+		snapshot := &snapshots[index]
+
+		switch selector.Type {
+		case SnapshotSelectLatest:
+		case SnapshotSelectTag:
+			if !snapshot.hasTagWithPrefix(selector.Value) {
+				continue
+			}
+		case SnapshotSelectID:
+			if !strings.HasPrefix(snapshot.ID, selector.Value) {
+				continue
+			}
+		case SnapshotSelectTime:
+			if snapshot.Time.After(selector.Time) {
+				continue
+			}
+		default:
+			return Snapshot{}, fmt.Errorf("unrecognized snapshot selector: %s", selector.Type)
+		}
+
+		if selected != nil && selector.Type == SnapshotSelectID && selected.ID != snapshot.ID {
+			return Snapshot{}, fmt.Errorf("snapshot ID '%s' is ambiguous: matches '%s' and '%s'", selector.Value, selected.ID, snapshot.ID)
+		}
+
+		if selected == nil || snapshot.Time.After(selected.Time) {
+			selected = snapshot
+		}
+	}
+
+	if selected == nil {
+		return Snapshot{}, fmt.Errorf("no snapshot matches %s", selector)
+	}
+
+	return *selected, nil
+}
+
+func (s Snapshot) hasTagWithPrefix(prefix string) bool {
+	for _, tag := range s.Tags {
+		if strings.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigSnapshots returns the snapshots that were taken by a config file, of a repository that may be shared with other config
+// files: if name (the metadata name of the config file) is non-empty, the snapshots with a tag that starts with the name, and of
+// those, the snapshots whose paths (if reported) are all among the source folders of the config file.
+func ConfigSnapshots(snapshots []Snapshot, sourceFolders []string, name string) []Snapshot {
+
+	res := []Snapshot{}
+
+	for _, snapshot := range snapshots {
+
+		if name != "" && !snapshot.hasTagWithPrefix(name) {
+			continue
+		}
+
+		ofConfig := true
+		for _, path := range snapshot.Paths {
+			if !containsPath(sourceFolders, path) {
+				ofConfig = false
+				break
+			}
+		}
+
+		if ofConfig {
+			res = append(res, snapshot)
+		}
+	}
+
+	return res
+}
+
+// LatestSnapshotsByFolder returns the newest snapshot that contains each of the source folders, in the same order as the folders:
+// for backends that snapshot each folder separately (kopia), these are different snapshots. A snapshot whose paths are not
+// reported (tarsnap) is assumed to contain every folder. An error is returned if a folder is not contained in any snapshot.
+func LatestSnapshotsByFolder(snapshots []Snapshot, sourceFolders []string) ([]Snapshot, error) {
+
+	res := []Snapshot{}
+
+	for _, sourceFolder := range sourceFolders {
+
+		folderSnapshots := []Snapshot{}
+		for _, snapshot := range snapshots {
+			if len(snapshot.Paths) == 0 || containsPath(snapshot.Paths, sourceFolder) {
+				folderSnapshots = append(folderSnapshots, snapshot)
+			}
+		}
+
+		snapshot, err := SelectSnapshot(folderSnapshots, SnapshotSelector{Type: SnapshotSelectLatest})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sourceFolder, err)
+		}
+
+		res = append(res, snapshot)
+	}
+
+	return res, nil
+}
+
+// containsPath returns true if the path is one of the paths, ignoring case on Windows.
+func containsPath(paths []string, path string) bool {
+
+	path = filepath.Clean(path)
+
+	for _, other := range paths {
+		if other = filepath.Clean(other); other == path || (runtime.GOOS == "windows" && strings.EqualFold(other, path)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectSnapshot(t *testing.T) {
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	snapshots := []Snapshot{
+		{ID: "aaa111", Time: day(1), Tags: []string{"home-2024-01-01"}},
+		{ID: "bbb222", Time: day(3), Tags: []string{"work-2024-01-03"}},
+		{ID: "aaa333", Time: day(2), Tags: []string{"home-2024-01-02"}},
+	}
+
+	for _, c := range []struct {
+		name       string
+		selector   SnapshotSelector
+		expectedID string
+	}{
+		{name: "latest", selector: SnapshotSelector{Type: SnapshotSelectLatest}, expectedID: "bbb222"},
+		{name: "newest with tag prefix", selector: SnapshotSelector{Type: SnapshotSelectTag, Value: "home"}, expectedID: "aaa333"},
+		{name: "id prefix", selector: SnapshotSelector{Type: SnapshotSelectID, Value: "bbb"}, expectedID: "bbb222"},
+		{name: "ambiguous id prefix", selector: SnapshotSelector{Type: SnapshotSelectID, Value: "aaa"}, expectedID: ""},
+		{name: "newest at or before time", selector: SnapshotSelector{Type: SnapshotSelectTime, Time: day(2).Add(time.Hour)}, expectedID: "aaa333"},
+		{name: "no match", selector: SnapshotSelector{Type: SnapshotSelectTag, Value: "other"}, expectedID: ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			snapshot, err := SelectSnapshot(snapshots, c.selector)
+
+			if c.expectedID == "" {
+				if err == nil {
+					t.Errorf("expected an error, but selected %s", snapshot.ID)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if snapshot.ID != c.expectedID {
+				t.Errorf("unexpected snapshot: %s, expected %s", snapshot.ID, c.expectedID)
+			}
+		})
+	}
+}
+
+func TestConfigSnapshots(t *testing.T) {
+
+	snapshots := []Snapshot{
+		{ID: "home1", Tags: []string{"home-2024-01-01"}, Paths: []string{"/home/docs", "/home/photos"}},
+		{ID: "home2", Tags: []string{"home-2024-01-02"}, Paths: []string{"/home/docs/"}},
+		{ID: "work1", Tags: []string{"work-2024-01-01"}, Paths: []string{"/home/docs"}},
+		{ID: "other1", Tags: []string{"home-2024-01-03"}, Paths: []string{"/home/docs", "/srv"}},
+		{ID: "tarsnap1", Tags: []string{"home-2024-01-04"}},
+	}
+
+	sourceFolders := []string{"/home/docs", "/home/photos"}
+
+	for _, c := range []struct {
+		name        string
+		configName  string
+		expectedIDs []string
+	}{
+		{name: "filtered by tag and paths", configName: "home", expectedIDs: []string{"home1", "home2", "tarsnap1"}},
+		{name: "filtered by paths only", configName: "", expectedIDs: []string{"home1", "home2", "work1", "tarsnap1"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			ids := []string{}
+			for _, snapshot := range ConfigSnapshots(snapshots, sourceFolders, c.configName) {
+				ids = append(ids, snapshot.ID)
+			}
+
+			if strings.Join(ids, ",") != strings.Join(c.expectedIDs, ",") {
+				t.Errorf("unexpected snapshots: %v, expected %v", ids, c.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestLatestSnapshotsByFolder(t *testing.T) {
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	sourceFolders := []string{"/home/docs", "/home/photos"}
+
+	t.Run("snapshot per folder", func(t *testing.T) {
+
+		snapshots := []Snapshot{
+			{ID: "docs1", Time: day(1), Paths: []string{"/home/docs"}},
+			{ID: "docs2", Time: day(3), Paths: []string{"/home/docs"}},
+			{ID: "photos1", Time: day(2), Paths: []string{"/home/photos"}},
+		}
+
+		latest, err := LatestSnapshotsByFolder(snapshots, sourceFolders)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(latest) != 2 || latest[0].ID != "docs2" || latest[1].ID != "photos1" {
+			t.Errorf("unexpected snapshots: %v", latest)
+		}
+	})
+
+	t.Run("snapshot of all folders", func(t *testing.T) {
+
+		snapshots := []Snapshot{
+			{ID: "all1", Time: day(1), Paths: sourceFolders},
+			{ID: "all2", Time: day(2), Paths: sourceFolders},
+		}
+
+		latest, err := LatestSnapshotsByFolder(snapshots, sourceFolders)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(latest) != 2 || latest[0].ID != "all2" || latest[1].ID != "all2" {
+			t.Errorf("unexpected snapshots: %v", latest)
+		}
+	})
+
+	t.Run("folder without a snapshot", func(t *testing.T) {
+
+		snapshots := []Snapshot{
+			{ID: "docs1", Time: day(1), Paths: []string{"/home/docs"}},
+		}
+
+		if latest, err := LatestSnapshotsByFolder(snapshots, sourceFolders); err == nil {
+			t.Errorf("expected an error, but selected %v", latest)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// CancellationGracePeriod is how long a child process is given to exit after being interrupted, before it is killed.
+const CancellationGracePeriod = 30 * time.Second
+
+// ConfigureGracefulCancellation configures a command created with exec.CommandContext so that, when the context is done, the
+// child process is first sent an interrupt signal, and is only killed if it has not exited after the grace period.
+func ConfigureGracefulCancellation(cmd *exec.Cmd) {
+
+	cmd.Cancel = func() error {
+		// Interrupt is not supported on Windows, in which case the process is killed immediately
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+
+	cmd.WaitDelay = CancellationGracePeriod
+}
@@ -0,0 +1,121 @@
+package analyze
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// AuditViolation is a file or folder that is not backed up as required by an audit rule
+type AuditViolation struct {
+	Rule          string `json:"rule"`
+	Path          string `json:"path"`
+	IsDir         bool   `json:"isDir"`
+	Copies        int    `json:"copies"`
+	OffsiteCopies int    `json:"offsiteCopies"`
+	Reason        string `json:"reason"`
+}
+
+// EvaluateAuditRule returns the files and folders under the path of the rule that are not backed up as it requires. When a
+// folder violates the rule, the files and folders under it are not reported. A folder that contains a config folder (and so
+// may be backed up differently from its other contents) is not reported itself, unless it is at the depth limit of the rule:
+// instead, its contents are evaluated.
+func EvaluateAuditRule(sources []CoverageSource, rule model.AuditRule) ([]AuditViolation, error) {
+
+	rulePath, err := util.Expand(rule.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(rulePath); err != nil {
+		return nil, fmt.Errorf("audit rule '%s': %w", rule.Description(), err)
+	}
+
+	excludes, err := expandAll(rule.Excludes, nil)
+	if err != nil {
+		return nil, err
+	}
+	matcher := util.NewExcludeMatcher(excludes)
+
+	depth := rule.GetDepth()
+
+	coverages, err := ComputeCoverage(sources, []string{rulePath}, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []AuditViolation{}
+	violatingFolders := []string{}
+
+	for _, coverage := range coverages {
+
+		relPath, _ := relativePath(rulePath, coverage.Path)
+		if excludedBelow(matcher, rulePath, relPath, coverage.IsDir) {
+			continue
+		}
+
+		if underAny(violatingFolders, coverage.Path) {
+			continue
+		}
+
+		if coverage.IsDir && coverage.Depth < depth && containsSourceFolder(sources, coverage.Path) {
+			continue
+		}
+
+		reasons := []string{}
+
+		if copies := coverage.Copies(); copies < rule.MinCopies {
+			reasons = append(reasons, fmt.Sprintf("backed up to %d of %d required targets", copies, rule.MinCopies))
+		}
+
+		if offsiteCopies := coverage.OffsiteCopies(); offsiteCopies < rule.MinOffsiteCopies {
+			reasons = append(reasons, fmt.Sprintf("%d of %d required offsite targets", offsiteCopies, rule.MinOffsiteCopies))
+		}
+
+		if len(reasons) == 0 {
+			continue
+		}
+
+		res = append(res, AuditViolation{
+			Rule:          rule.Description(),
+			Path:          coverage.Path,
+			IsDir:         coverage.IsDir,
+			Copies:        coverage.Copies(),
+			OffsiteCopies: coverage.OffsiteCopies(),
+			Reason:        strings.Join(reasons, ", "),
+		})
+
+		if coverage.IsDir {
+			violatingFolders = append(violatingFolders, coverage.Path)
+		}
+	}
+
+	return res, nil
+}
+
+// containsSourceFolder returns true if a folder of one of the sources is under (but is not) the path.
+func containsSourceFolder(sources []CoverageSource, path string) bool {
+
+	for _, source := range sources {
+		if relPath, under := relativePath(path, source.Folder); under && relPath != "." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// underAny returns true if the path is under (but is not) one of the folders.
+func underAny(folders []string, path string) bool {
+
+	for _, folder := range folders {
+		if relPath, under := relativePath(folder, path); under && relPath != "." {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestEvaluateAuditRule(t *testing.T) {
+
+	root := t.TempDir()
+
+	for _, folder := range []string{"docs/old", "photos", "cache"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"docs/a.txt", "docs/old/b.txt", "photos/c.jpg", "notes.tmp"} {
+		if err := os.WriteFile(filepath.Join(root, file), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := []CoverageSource{
+		{
+			ConfigPath: "restic.yaml",
+			Folder:     root,
+			Matcher:    util.NewExcludeMatcher([]string{"photos/"}),
+			Target:     "restic:s3:bucket",
+			Offsite:    true,
+		},
+		{
+			ConfigPath: "robocopy.yaml",
+			Folder:     filepath.Join(root, "docs"),
+			Target:     "folder:/mnt/docs",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rule     model.AuditRule
+		expected []string
+	}{
+		{
+			name: "two copies, one offsite",
+			rule: model.AuditRule{Path: root, MinCopies: 2, MinOffsiteCopies: 1, Excludes: []string{"*.tmp"}},
+			// 'docs' (and so the paths under it) meets the rule, and the paths under a violating folder are not reported
+			expected: []string{"cache", "photos"},
+		},
+		{
+			name:     "one offsite copy",
+			rule:     model.AuditRule{Path: root, MinCopies: 1, MinOffsiteCopies: 1},
+			expected: []string{"photos"},
+		},
+		{
+			name:     "depth limit",
+			rule:     model.AuditRule{Path: root, MinCopies: 2, Depth: intPointer(0)},
+			expected: []string{"."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			violations, err := EvaluateAuditRule(sources, test.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := []string{}
+			for _, violation := range violations {
+				relPath, err := filepath.Rel(root, violation.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual = append(actual, filepath.ToSlash(relPath))
+			}
+
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+			for index := range actual {
+				if actual[index] != test.expected[index] {
+					t.Fatalf("expected %v, got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func intPointer(value int) *int {
+	depth := value
+	return &depth
+}
@@ -0,0 +1,354 @@
+package analyze
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// CoverageSource is a folder of a config file, which is backed up (honouring the excludes of the matcher) to a target
+type CoverageSource struct {
+	ConfigPath string
+	ConfigType model.ConfigType
+	Folder     string
+	Matcher    util.ExcludeMatcher
+
+	// Target is the repository (as returned by GetRepositoryID), or for mirror backends the destination folder, of the backup
+	Target string
+
+	// Offsite is true if the target is a remote location
+	Offsite bool
+}
+
+// Coverage is a config file and target that a path is backed up by
+type Coverage struct {
+	ConfigPath string           `json:"configPath"`
+	ConfigType model.ConfigType `json:"configType"`
+	Target     string           `json:"target"`
+	Offsite    bool             `json:"offsite"`
+}
+
+// PathCoverage is the coverage of a file or folder, found under one of the roots of a coverage computation
+type PathCoverage struct {
+	Path string `json:"path"`
+
+	// Root is the root folder that the path was found under; Depth is the number of path elements below the root (0 for the root)
+	Root  string `json:"root"`
+	Depth int    `json:"depth"`
+	IsDir bool   `json:"isDir"`
+
+	// Coverage is the config files and targets that back up the whole of the path
+	Coverage []Coverage `json:"coverage"`
+
+	// Partial is true if the path is not backed up as a whole, but some of the files or folders under it are
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Copies returns the number of distinct targets that the path is backed up to.
+func (pc PathCoverage) Copies() int {
+
+	targets := map[string]bool{}
+	for _, coverage := range pc.Coverage {
+		targets[coverage.Target] = true
+	}
+
+	return len(targets)
+}
+
+// OffsiteCopies returns the number of distinct offsite targets that the path is backed up to.
+func (pc PathCoverage) OffsiteCopies() int {
+
+	targets := map[string]bool{}
+	for _, coverage := range pc.Coverage {
+		if coverage.Offsite {
+			targets[coverage.Target] = true
+		}
+	}
+
+	return len(targets)
+}
+
+// NewCoverageSources returns the coverage sources of the folders of the config file, with the global, folder, and robocopy
+// excludes of the config file. For mirror backends, folderPairs are the source folders and the destination folder that each
+// is mirrored to; otherwise the target is the repository.
+func NewCoverageSources(configFilePath string, config model.ConfigFile, folderPairs []model.FolderPair) ([]CoverageSource, error) {
+
+	configType, err := config.GetConfigType()
+	if err != nil {
+		return nil, err
+	}
+
+	repositoryID, err := config.GetRepositoryID()
+	if err != nil {
+		return nil, err
+	}
+
+	offsite, err := config.IsOffsite()
+	if err != nil {
+		return nil, err
+	}
+
+	configExcludes, err := expandAll(config.GlobalExcludes, config.Substitutions)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RobocopySettings != nil {
+
+		fileExcludes, err := expandAll(config.RobocopySettings.ExcludeFiles, config.Substitutions)
+		if err != nil {
+			return nil, err
+		}
+		configExcludes = append(configExcludes, fileExcludes...)
+
+		folderExcludes, err := expandAll(config.RobocopySettings.ExcludeFolders, config.Substitutions)
+		if err != nil {
+			return nil, err
+		}
+
+		// Robocopy folder excludes ('/XD') only match folders
+		for _, folderExclude := range folderExcludes {
+			configExcludes = append(configExcludes, folderExclude+string(filepath.Separator))
+		}
+	}
+
+	res := []CoverageSource{}
+
+	for _, folder := range config.Folders {
+
+		folderPath, err := util.Expand(folder.Path, config.Substitutions)
+		if err != nil {
+			return nil, err
+		}
+
+		if folderPath == "" {
+			return nil, fmt.Errorf("expanded path of folder '%s' is empty", folder.Path)
+		}
+
+		folderExcludes, err := expandAll(folder.Excludes, config.Substitutions)
+		if err != nil {
+			return nil, err
+		}
+
+		target := repositoryID
+		for _, folderPair := range folderPairs {
+			if samePath(folderPair.Source, folderPath) {
+				target = "folder:" + folderPair.Dest
+			}
+		}
+
+		res = append(res, CoverageSource{
+			ConfigPath: configFilePath,
+			ConfigType: configType,
+			Folder:     filepath.Clean(folderPath),
+			Matcher:    util.NewExcludeMatcher(append(append([]string{}, configExcludes...), folderExcludes...)),
+			Target:     target,
+			Offsite:    offsite,
+		})
+	}
+
+	return res, nil
+}
+
+// ComputeCoverage walks each of the roots down to maxDepth path elements below the root, and returns the coverage of each
+// file and folder, sorted by root (and, within each root, in lexical order). Roots that are under another root are walked as
+// part of that root. Unreadable paths are reported as warnings, and skipped.
+func ComputeCoverage(sources []CoverageSource, roots []string, maxDepth int) ([]PathCoverage, error) {
+
+	if maxDepth < 0 {
+		return nil, fmt.Errorf("depth must not be negative")
+	}
+
+	res := []PathCoverage{}
+	visited := map[string]bool{}
+
+	for _, root := range topLevelRoots(roots) {
+
+		if _, err := os.Stat(root); err != nil {
+			fmt.Println("Warning: unable to read:", root, err)
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, dirEntry fs.DirEntry, err error) error {
+
+			if err != nil {
+				fmt.Println("Warning: unable to read:", path, err)
+				if dirEntry != nil && dirEntry.IsDir() && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			depth := 0
+			if relPath, _ := relativePath(root, path); relPath != "." {
+				depth = len(strings.Split(relPath, string(filepath.Separator)))
+			}
+
+			// A root may be a symbolic link to a folder under another root
+			key := normalizePath(path)
+			if visited[key] {
+				return nil
+			}
+			visited[key] = true
+
+			coverage, partial := CoverageOf(sources, path, dirEntry.IsDir())
+
+			res = append(res, PathCoverage{
+				Path:     path,
+				Root:     root,
+				Depth:    depth,
+				IsDir:    dirEntry.IsDir(),
+				Coverage: coverage,
+				Partial:  partial,
+			})
+
+			if dirEntry.IsDir() && depth >= maxDepth {
+				return filepath.SkipDir
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// CoverageOf returns the config files and targets that back up the whole of the path: those with a folder that is (or
+// contains) the path, where neither the path nor any of the folders between it and the config folder are excluded. If the
+// path is not backed up as a whole by any config file, partial is true if a config folder is under the path.
+func CoverageOf(sources []CoverageSource, path string, isDir bool) (coverage []Coverage, partial bool) {
+
+	coverage = []Coverage{}
+	path = filepath.Clean(path)
+
+	for _, source := range sources {
+
+		relPath, under := relativePath(source.Folder, path)
+
+		if !under {
+			if _, contains := relativePath(path, source.Folder); contains && isDir {
+				partial = true
+			}
+			continue
+		}
+
+		if !excludedBelow(source.Matcher, source.Folder, relPath, isDir) {
+			coverage = append(coverage, Coverage{
+				ConfigPath: source.ConfigPath,
+				ConfigType: source.ConfigType,
+				Target:     source.Target,
+				Offsite:    source.Offsite,
+			})
+		}
+	}
+
+	sort.SliceStable(coverage, func(i, j int) bool {
+		return coverage[i].ConfigPath < coverage[j].ConfigPath
+	})
+
+	return coverage, partial && len(coverage) == 0
+}
+
+// excludedBelow returns true if the path (relative to the folder), or any of the folders between it and the folder, is excluded
+// by the matcher.
+func excludedBelow(matcher util.ExcludeMatcher, folder string, relPath string, isDir bool) bool {
+
+	if relPath == "." {
+		return false
+	}
+
+	elements := strings.Split(relPath, string(filepath.Separator))
+
+	for index := range elements {
+		elementPath := filepath.Join(folder, filepath.Join(elements[0:index+1]...))
+		elementIsDir := index < len(elements)-1 || isDir
+		if matcher.Excluded(elementPath, elementIsDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topLevelRoots returns the cleaned roots, sorted and without duplicates, excluding those that are under another root.
+func topLevelRoots(roots []string) []string {
+
+	sorted := []string{}
+	for _, root := range roots {
+		sorted = append(sorted, filepath.Clean(root))
+	}
+	sort.Strings(sorted)
+
+	res := []string{}
+
+	for _, root := range sorted {
+
+		nested := false
+		for _, other := range res {
+			if _, under := relativePath(other, root); under {
+				nested = true
+				break
+			}
+		}
+
+		if !nested {
+			res = append(res, root)
+		}
+	}
+
+	return res
+}
+
+// relativePath returns the path relative to the folder, and true, if the path is (or is under) the folder.
+func relativePath(folder string, path string) (string, bool) {
+
+	// (filepath.Rel is case-insensitive on Windows)
+	relPath, err := filepath.Rel(folder, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return relPath, true
+}
+
+// normalizePath returns the cleaned path, in lowercase on Windows (where paths are case-insensitive).
+func normalizePath(path string) string {
+
+	path = filepath.Clean(path)
+
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+
+	return path
+}
+
+func samePath(a string, b string) bool {
+	return normalizePath(a) == normalizePath(b)
+}
+
+func expandAll(values []string, substitutions []model.Substitution) ([]string, error) {
+
+	res := []string{}
+
+	for _, value := range values {
+		expanded, err := util.Expand(value, substitutions)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, expanded)
+	}
+
+	return res, nil
+}
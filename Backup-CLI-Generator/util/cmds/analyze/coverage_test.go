@@ -0,0 +1,89 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestComputeCoverage(t *testing.T) {
+
+	root := t.TempDir()
+
+	for _, folder := range []string{"src/docs", "src/cache", "other"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"src/docs/a.txt", "src/b.tmp", "other/c.txt"} {
+		if err := os.WriteFile(filepath.Join(root, file), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := []CoverageSource{
+		{
+			ConfigPath: "restic.yaml",
+			Folder:     filepath.Join(root, "src"),
+			Matcher:    util.NewExcludeMatcher([]string{"cache/", "*.tmp"}),
+			Target:     "restic:s3:bucket",
+		},
+		{
+			ConfigPath: "robocopy.yaml",
+			Folder:     filepath.Join(root, "src", "docs"),
+			Target:     "folder:/mnt/docs",
+		},
+		{
+			// The same target as restic.yaml, so not a separate copy
+			ConfigPath: "restic-docs.yaml",
+			Folder:     filepath.Join(root, "src", "docs"),
+			Target:     "restic:s3:bucket",
+		},
+	}
+
+	coverages, err := ComputeCoverage(sources, []string{filepath.Join(root, "src", "docs"), root}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type expectedCoverage struct {
+		depth   int
+		copies  int
+		partial bool
+	}
+
+	expected := map[string]expectedCoverage{
+		".":           {depth: 0, partial: true},
+		"other":       {depth: 1},
+		"other/c.txt": {depth: 2},
+		"src":         {depth: 1, copies: 1},
+		"src/b.tmp":   {depth: 2},
+		"src/cache":   {depth: 2},
+		// 'src/docs' is also a root, but as it is under another root it is only walked to the depth of that root
+		"src/docs": {depth: 2, copies: 2},
+	}
+
+	if len(coverages) != len(expected) {
+		t.Errorf("expected %d paths, got %d: %v", len(expected), len(coverages), coverages)
+	}
+
+	for _, coverage := range coverages {
+
+		relPath, err := filepath.Rel(root, coverage.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedCoverage, exists := expected[filepath.ToSlash(relPath)]
+		if !exists {
+			t.Errorf("unexpected path: %s", relPath)
+			continue
+		}
+
+		if coverage.Depth != expectedCoverage.depth || coverage.Copies() != expectedCoverage.copies || coverage.Partial != expectedCoverage.partial {
+			t.Errorf("%s: expected %+v, got depth %d, copies %d, partial %v", relPath, expectedCoverage, coverage.Depth, coverage.Copies(), coverage.Partial)
+		}
+	}
+}
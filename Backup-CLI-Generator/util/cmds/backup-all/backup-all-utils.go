@@ -0,0 +1,344 @@
+package backupall
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+// ConfigEntry is a config file to be run by backup-all.
+type ConfigEntry struct {
+	// Path is the absolute path to the config file
+	Path string
+
+	// Priority: configs with a higher priority are started first
+	Priority int
+
+	// After is the list of absolute config file paths that must complete before this config is started
+	After []string
+
+	// RepositoryID identifies the repository/destination of the config: configs with the same RepositoryID are never run concurrently.
+	RepositoryID string
+}
+
+type ConfigStatus string
+
+const (
+	ConfigStatusSuccess ConfigStatus = "OK"
+	ConfigStatusFailed  ConfigStatus = "FAILED"
+	ConfigStatusSkipped ConfigStatus = "SKIPPED"
+)
+
+// ConfigResult is the result of running a single config file.
+type ConfigResult struct {
+	Path     string
+	Status   ConfigStatus
+	Duration time.Duration
+	Err      error
+}
+
+// RunConfigs runs each of the config entries using runConfig, with at most 'parallel' running at once. Entries are started in
+// priority order, once all of the entries they are 'after' have succeeded; an entry whose dependency failed is skipped.
+// Once the context is done, no further entries are started. The returned results are in the order the entries were completed.
+func RunConfigs(ctx context.Context, entries []ConfigEntry, parallel int, runConfig func(entry ConfigEntry) error) ([]ConfigResult, error) {
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	pending := orderEntries(entries)
+
+	// Ensure that all 'after' paths refer to known entries
+	{
+		knownPaths := map[string]bool{}
+		for _, entry := range entries {
+			knownPaths[entry.Path] = true
+		}
+		for _, entry := range entries {
+			for _, after := range entry.After {
+				if !knownPaths[after] {
+					return nil, fmt.Errorf("config '%s' must run after '%s', which was not found", entry.Path, after)
+				}
+			}
+		}
+	}
+
+	results := []ConfigResult{}
+
+	// config path -> status, for completed configs
+	completed := map[string]ConfigStatus{}
+
+	// repository ids of running configs
+	runningRepositories := map[string]bool{}
+	running := 0
+
+	completions := make(chan ConfigResult)
+
+	for len(pending) > 0 || running > 0 {
+
+		// Start (or skip) entries until no more progress can be made
+		for changed := true; changed; {
+			changed = false
+
+			remaining := []ConfigEntry{}
+
+			for _, entry := range pending {
+
+				if ctx.Err() != nil {
+					result := ConfigResult{
+						Path:   entry.Path,
+						Status: ConfigStatusSkipped,
+						Err:    fmt.Errorf("skipped, as backup-all was cancelled: %v", context.Cause(ctx)),
+					}
+					completed[entry.Path] = result.Status
+					results = append(results, result)
+					changed = true
+					continue
+				}
+
+				ready, failedDependency := checkDependencies(entry, completed)
+
+				if failedDependency != "" {
+					result := ConfigResult{
+						Path:   entry.Path,
+						Status: ConfigStatusSkipped,
+						Err:    fmt.Errorf("skipped, as dependency did not succeed: %s", failedDependency),
+					}
+					completed[entry.Path] = result.Status
+					results = append(results, result)
+					changed = true
+					continue
+				}
+
+				if !ready || running >= parallel || runningRepositories[entry.RepositoryID] {
+					remaining = append(remaining, entry)
+					continue
+				}
+
+				running++
+				runningRepositories[entry.RepositoryID] = true
+				changed = true
+
+				go func(entry ConfigEntry) {
+					start := time.Now()
+					err := runConfig(entry)
+
+					result := ConfigResult{
+						Path:     entry.Path,
+						Status:   ConfigStatusSuccess,
+						Duration: time.Since(start).Round(time.Second),
+						Err:      err,
+					}
+					if err != nil {
+						result.Status = ConfigStatusFailed
+					}
+					completions <- result
+				}(entry)
+			}
+
+			pending = remaining
+		}
+
+		if running == 0 {
+			// Nothing is running, and nothing more can be started: each remaining entry is part of a dependency cycle, or
+			// depends on one
+			results = append(results, dependencyCycleResults(pending)...)
+			break
+		}
+
+		result := <-completions
+		running--
+
+		for _, entry := range entries {
+			if entry.Path == result.Path {
+				delete(runningRepositories, entry.RepositoryID)
+			}
+		}
+
+		completed[result.Path] = result.Status
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// dependencyCycleResults returns the results of the pending entries, each of which depends on at least one other pending
+// entry: an entry that (directly or indirectly) depends on itself is part of a dependency cycle, and fails; the other entries
+// are blocked by a cycle, and are skipped.
+func dependencyCycleResults(pending []ConfigEntry) []ConfigResult {
+
+	// config path -> 'after' paths that are also pending
+	dependencies := map[string][]string{}
+	for _, entry := range pending {
+		dependencies[entry.Path] = []string{}
+	}
+	for _, entry := range pending {
+		for _, after := range entry.After {
+			if _, isPending := dependencies[after]; isPending {
+				dependencies[entry.Path] = append(dependencies[entry.Path], after)
+			}
+		}
+	}
+
+	// config path -> the shortest cycle from that config back to itself, for configs that are part of a cycle
+	cycles := map[string][]string{}
+	for _, entry := range pending {
+		if cycle := shortestDependencyPath(entry.Path, entry.Path, dependencies); cycle != nil {
+			cycles[entry.Path] = cycle
+		}
+	}
+
+	results := []ConfigResult{}
+
+	for _, entry := range pending {
+
+		if cycle, isCycleMember := cycles[entry.Path]; isCycleMember {
+			results = append(results, ConfigResult{
+				Path:   entry.Path,
+				Status: ConfigStatusFailed,
+				Err:    fmt.Errorf("config is part of an 'after' dependency cycle: %s", strings.Join(cycle, " -> ")),
+			})
+			continue
+		}
+
+		// Report the cycle members that the entry (directly or indirectly) depends on
+		blockedBy := []string{}
+		for _, other := range pending {
+			if _, isCycleMember := cycles[other.Path]; isCycleMember && shortestDependencyPath(entry.Path, other.Path, dependencies) != nil {
+				blockedBy = append(blockedBy, other.Path)
+			}
+		}
+
+		results = append(results, ConfigResult{
+			Path:   entry.Path,
+			Status: ConfigStatusSkipped,
+			Err:    fmt.Errorf("skipped, as dependency is blocked by an 'after' dependency cycle of: %s", strings.Join(blockedBy, ", ")),
+		})
+	}
+
+	return results
+}
+
+// shortestDependencyPath returns the shortest path (of one or more dependencies) from the 'from' config to the 'to' config,
+// including both, or nil if there is none.
+func shortestDependencyPath(from string, to string, dependencies map[string][]string) []string {
+
+	// config path -> the config it was first reached from
+	reachedFrom := map[string]string{}
+
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dependency := range dependencies[current] {
+
+			if dependency == to {
+				path := []string{to}
+				for node := current; node != from; node = reachedFrom[node] {
+					path = append([]string{node}, path...)
+				}
+				return append([]string{from}, path...)
+			}
+
+			if _, reached := reachedFrom[dependency]; reached || dependency == from {
+				continue
+			}
+
+			reachedFrom[dependency] = current
+			queue = append(queue, dependency)
+		}
+	}
+
+	return nil
+}
+
+// checkDependencies returns true if all of the entry's 'after' dependencies have succeeded, or, if a dependency has
+// completed without success, returns the path of that dependency.
+func checkDependencies(entry ConfigEntry, completed map[string]ConfigStatus) (bool, string) {
+
+	ready := true
+
+	for _, after := range entry.After {
+
+		status, exists := completed[after]
+		if !exists {
+			ready = false
+			continue
+		}
+
+		if status != ConfigStatusSuccess {
+			return false, after
+		}
+	}
+
+	return ready, ""
+}
+
+// orderEntries returns the entries sorted by priority (highest first), then by path.
+func orderEntries(entries []ConfigEntry) []ConfigEntry {
+
+	res := append([]ConfigEntry{}, entries...)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Priority != res[j].Priority {
+			return res[i].Priority > res[j].Priority
+		}
+		return res[i].Path < res[j].Path
+	})
+
+	return res
+}
+
+// PrintReport outputs a table containing the result of each config file.
+func PrintReport(out io.Writer, results []ConfigResult) {
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "-------------------------------------------------------------------")
+	fmt.Fprintln(out, "Summary:")
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tDURATION\tCONFIG\tERROR")
+	for _, result := range results {
+		errStr := ""
+		if result.Err != nil {
+			errStr = result.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%s\t%s\n", result.Status, result.Duration, result.Path, errStr)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%d of %d config(s) succeeded\n", len(results)-len(unsuccessfulResults(results)), len(results))
+}
+
+// ResultsError returns nil if all configs succeeded, otherwise an error that requests the config failure exit code.
+func ResultsError(results []ConfigResult) error {
+
+	unsuccessful := unsuccessfulResults(results)
+	if len(unsuccessful) == 0 {
+		return nil
+	}
+
+	return &util.ExitCodeError{
+		Code: util.ExitCodeConfigFailure,
+		Err:  fmt.Errorf("%d of %d config(s) did not succeed", len(unsuccessful), len(results)),
+	}
+}
+
+func unsuccessfulResults(results []ConfigResult) []ConfigResult {
+	res := []ConfigResult{}
+	for _, result := range results {
+		if result.Status != ConfigStatusSuccess {
+			res = append(res, result)
+		}
+	}
+	return res
+}
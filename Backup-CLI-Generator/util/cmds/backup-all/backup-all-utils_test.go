@@ -0,0 +1,257 @@
+package backupall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunConfigs(t *testing.T) {
+
+	for _, c := range []struct {
+		name             string
+		entries          []ConfigEntry
+		parallel         int
+		failing          map[string]bool
+		expectedOrder    []string
+		expectedStatuses map[string]ConfigStatus
+		expectErr        bool
+	}{
+		{
+			name: "priority order",
+			entries: []ConfigEntry{
+				{Path: "/a.yaml", Priority: 0, RepositoryID: "1"},
+				{Path: "/b.yaml", Priority: 10, RepositoryID: "2"},
+				{Path: "/c.yaml", Priority: 5, RepositoryID: "3"},
+			},
+			parallel:      1,
+			expectedOrder: []string{"/b.yaml", "/c.yaml", "/a.yaml"},
+		},
+		{
+			name: "after takes precedence over priority",
+			entries: []ConfigEntry{
+				{Path: "/a.yaml", Priority: 10, After: []string{"/b.yaml"}, RepositoryID: "1"},
+				{Path: "/b.yaml", Priority: 0, RepositoryID: "2"},
+			},
+			parallel:      2,
+			expectedOrder: []string{"/b.yaml", "/a.yaml"},
+		},
+		{
+			name: "dependency failure skips dependent",
+			entries: []ConfigEntry{
+				{Path: "/a.yaml", After: []string{"/b.yaml"}, RepositoryID: "1"},
+				{Path: "/b.yaml", RepositoryID: "2"},
+				{Path: "/c.yaml", RepositoryID: "3"},
+			},
+			parallel:      1,
+			failing:       map[string]bool{"/b.yaml": true},
+			expectedOrder: []string{"/b.yaml", "/c.yaml"},
+			expectedStatuses: map[string]ConfigStatus{
+				"/a.yaml": ConfigStatusSkipped,
+				"/b.yaml": ConfigStatusFailed,
+				"/c.yaml": ConfigStatusSuccess,
+			},
+		},
+		{
+			name: "dependency cycle",
+			entries: []ConfigEntry{
+				{Path: "/a.yaml", After: []string{"/b.yaml"}, RepositoryID: "1"},
+				{Path: "/b.yaml", After: []string{"/a.yaml"}, RepositoryID: "2"},
+			},
+			parallel:      2,
+			expectedOrder: []string{},
+			expectedStatuses: map[string]ConfigStatus{
+				"/a.yaml": ConfigStatusFailed,
+				"/b.yaml": ConfigStatusFailed,
+			},
+		},
+		{
+			name: "dependency cycle blocks dependents",
+			entries: []ConfigEntry{
+				{Path: "/a.yaml", After: []string{"/b.yaml"}, RepositoryID: "1"},
+				{Path: "/b.yaml", After: []string{"/a.yaml"}, RepositoryID: "2"},
+				{Path: "/c.yaml", After: []string{"/a.yaml"}, RepositoryID: "3"},
+				{Path: "/d.yaml", After: []string{"/c.yaml"}, RepositoryID: "4"},
+				{Path: "/e.yaml", RepositoryID: "5"},
+			},
+			parallel:      2,
+			expectedOrder: []string{"/e.yaml"},
+			expectedStatuses: map[string]ConfigStatus{
+				"/a.yaml": ConfigStatusFailed,
+				"/b.yaml": ConfigStatusFailed,
+				"/c.yaml": ConfigStatusSkipped,
+				"/d.yaml": ConfigStatusSkipped,
+			},
+		},
+		{
+			name: "unknown dependency",
+			entries: []ConfigEntry{
+				{Path: "/a.yaml", After: []string{"/missing.yaml"}, RepositoryID: "1"},
+			},
+			parallel:  1,
+			expectErr: true,
+		},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+
+			mutex := sync.Mutex{}
+			started := []string{}
+
+			results, err := RunConfigs(context.Background(), c.entries, c.parallel, func(entry ConfigEntry) error {
+				mutex.Lock()
+				started = append(started, entry.Path)
+				mutex.Unlock()
+
+				if c.failing[entry.Path] {
+					return fmt.Errorf("failed")
+				}
+				return nil
+			})
+
+			if (err != nil) != c.expectErr {
+				t.Fatalf("unexpected error value: %v", err)
+			}
+			if c.expectErr {
+				return
+			}
+
+			if len(results) != len(c.entries) {
+				t.Errorf("unexpected number of results: %v", results)
+			}
+
+			if fmt.Sprint(started) != fmt.Sprint(c.expectedOrder) {
+				t.Errorf("start order does not match: %v %v", started, c.expectedOrder)
+			}
+
+			for _, result := range results {
+				expectedStatus, exists := c.expectedStatuses[result.Path]
+				if !exists {
+					expectedStatus = ConfigStatusSuccess
+				}
+				if result.Status != expectedStatus {
+					t.Errorf("status of '%s' does not match: %v %v", result.Path, result.Status, expectedStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestRunConfigsSameRepository(t *testing.T) {
+
+	entries := []ConfigEntry{
+		{Path: "/a.yaml", RepositoryID: "shared"},
+		{Path: "/b.yaml", RepositoryID: "shared"},
+		{Path: "/c.yaml", RepositoryID: "shared"},
+	}
+
+	mutex := sync.Mutex{}
+	running := map[string]bool{}
+
+	results, err := RunConfigs(context.Background(), entries, 3, func(entry ConfigEntry) error {
+		mutex.Lock()
+		if running[entry.RepositoryID] {
+			mutex.Unlock()
+			return fmt.Errorf("repository is already in use")
+		}
+		running[entry.RepositoryID] = true
+		mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mutex.Lock()
+		delete(running, entry.RepositoryID)
+		mutex.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range results {
+		if result.Status != ConfigStatusSuccess {
+			t.Errorf("config '%s' did not succeed: %v", result.Path, result.Err)
+		}
+	}
+}
+
+func TestRunConfigsCancelled(t *testing.T) {
+
+	entries := []ConfigEntry{
+		{Path: "/a.yaml", RepositoryID: "a", Priority: 1},
+		{Path: "/b.yaml", RepositoryID: "b"},
+		{Path: "/c.yaml", RepositoryID: "c"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results, err := RunConfigs(ctx, entries, 1, func(entry ConfigEntry) error {
+		// The first config is interrupted: the remaining configs should not be started
+		cancel()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := map[string]ConfigStatus{}
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+
+	expected := map[string]ConfigStatus{"/a.yaml": ConfigStatusFailed, "/b.yaml": ConfigStatusSkipped, "/c.yaml": ConfigStatusSkipped}
+	for path, status := range expected {
+		if statuses[path] != status {
+			t.Errorf("%s: expected %s, got %s", path, status, statuses[path])
+		}
+	}
+}
+
+func TestDependencyCycleResults(t *testing.T) {
+
+	pending := []ConfigEntry{
+		{Path: "/a.yaml", After: []string{"/b.yaml"}},
+		{Path: "/b.yaml", After: []string{"/c.yaml"}},
+		{Path: "/c.yaml", After: []string{"/a.yaml"}},
+		{Path: "/d.yaml", After: []string{"/d.yaml"}},
+		{Path: "/e.yaml", After: []string{"/f.yaml", "/b.yaml"}},
+		{Path: "/f.yaml", After: []string{"/e.yaml"}},
+		{Path: "/g.yaml", After: []string{"/h.yaml"}},
+		{Path: "/h.yaml", After: []string{"/a.yaml", "/d.yaml"}},
+	}
+
+	expected := map[string]string{
+		"/a.yaml": "config is part of an 'after' dependency cycle: /a.yaml -> /b.yaml -> /c.yaml -> /a.yaml",
+		"/b.yaml": "config is part of an 'after' dependency cycle: /b.yaml -> /c.yaml -> /a.yaml -> /b.yaml",
+		"/c.yaml": "config is part of an 'after' dependency cycle: /c.yaml -> /a.yaml -> /b.yaml -> /c.yaml",
+		"/d.yaml": "config is part of an 'after' dependency cycle: /d.yaml -> /d.yaml",
+		"/e.yaml": "config is part of an 'after' dependency cycle: /e.yaml -> /f.yaml -> /e.yaml",
+		"/f.yaml": "config is part of an 'after' dependency cycle: /f.yaml -> /e.yaml -> /f.yaml",
+		"/g.yaml": "skipped, as dependency is blocked by an 'after' dependency cycle of: /a.yaml, /b.yaml, /c.yaml, /d.yaml",
+		"/h.yaml": "skipped, as dependency is blocked by an 'after' dependency cycle of: /a.yaml, /b.yaml, /c.yaml, /d.yaml",
+	}
+
+	results := dependencyCycleResults(pending)
+	if len(results) != len(pending) {
+		t.Fatalf("unexpected number of results: %v", results)
+	}
+
+	for _, result := range results {
+		if result.Err == nil || result.Err.Error() != expected[result.Path] {
+			t.Errorf("unexpected error for '%s': %v", result.Path, result.Err)
+		}
+
+		expectedStatus := ConfigStatusFailed
+		if strings.HasPrefix(expected[result.Path], "skipped") {
+			expectedStatus = ConfigStatusSkipped
+		}
+		if result.Status != expectedStatus {
+			t.Errorf("unexpected status for '%s': %v", result.Path, result.Status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package checkrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// CheckResult is the result of the most recent 'check-repo' of a config file, at a check level, as reported by 'status'.
+type CheckResult struct {
+	ConfigPath string           `json:"configPath"`
+	Level      model.CheckLevel `json:"level"`
+	StartTime  time.Time        `json:"startTime"`
+	EndTime    time.Time        `json:"endTime"`
+	Success    bool             `json:"success"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// checkLevels are the check levels, in the order they are reported
+var checkLevels = []model.CheckLevel{model.CheckLevelQuick, model.CheckLevelStandard, model.CheckLevelDeep}
+
+// RecordCheckResult records the result of a check of the config file, which started at startTime and failed with checkErr (if
+// non-nil). The result of each check level is recorded separately, so that a passing quick check does not hide a failing deep
+// check.
+func RecordCheckResult(configFilePath string, level model.CheckLevel, startTime time.Time, checkErr error) error {
+
+	checkResultFilePath, err := checkResultFilePath(configFilePath, level)
+	if err != nil {
+		return err
+	}
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	checkResult := CheckResult{
+		ConfigPath: absConfigFilePath,
+		Level:      level,
+		StartTime:  startTime,
+		EndTime:    time.Now(),
+		Success:    checkErr == nil,
+	}
+
+	if checkErr != nil {
+		checkResult.Error = checkErr.Error()
+	}
+
+	return util.WriteStateFile(checkResultFilePath, checkResult)
+}
+
+// ReadCheckResults returns the result of the most recent check of the config file at each check level (that the config
+// file was checked at), in order of increasing thoroughness.
+func ReadCheckResults(configFilePath string) ([]CheckResult, error) {
+
+	res := []CheckResult{}
+
+	for _, level := range checkLevels {
+
+		checkResultFilePath, err := checkResultFilePath(configFilePath, level)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := os.ReadFile(checkResultFilePath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		checkResult := CheckResult{}
+		if err := json.Unmarshal(content, &checkResult); err != nil {
+			return nil, fmt.Errorf("unable to parse check result '%s': %w", checkResultFilePath, err)
+		}
+
+		res = append(res, checkResult)
+	}
+
+	return res, nil
+}
+
+func checkResultFilePath(configFilePath string, level model.CheckLevel) (string, error) {
+	return util.ConfigStateFilePath(filepath.Join("checks", string(level)), configFilePath)
+}
@@ -0,0 +1,325 @@
+package estimate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/analyze"
+)
+
+// SizeEntry is a file or folder and its size; the size of a folder is the total size of the files under it that are not
+// excluded.
+type SizeEntry struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// FolderEstimate is the size of a folder of a config file, once the excludes of the config file are applied
+type FolderEstimate struct {
+	Folder string `json:"folder"`
+	Bytes  int64  `json:"bytes"`
+	Files  int64  `json:"files"`
+
+	// LargestSubfolders are the largest folders directly under the folder; LargestFiles are the largest files at any depth
+	LargestSubfolders []SizeEntry `json:"largestSubfolders"`
+	LargestFiles      []SizeEntry `json:"largestFiles"`
+}
+
+// Estimate is the size of each of the folders of a config file, as reported by 'estimate'
+type Estimate struct {
+	ConfigPath string           `json:"configPath"`
+	Time       time.Time        `json:"time"`
+	Folders    []FolderEstimate `json:"folders"`
+}
+
+// TotalBytes returns the total size of the folders.
+func (e Estimate) TotalBytes() int64 {
+
+	var res int64
+	for _, folder := range e.Folders {
+		res += folder.Bytes
+	}
+
+	return res
+}
+
+// TotalFiles returns the total number of files in the folders.
+func (e Estimate) TotalFiles() int64 {
+
+	var res int64
+	for _, folder := range e.Folders {
+		res += folder.Files
+	}
+
+	return res
+}
+
+// FindFolder returns the estimate of the folder, if the estimate contains it.
+func (e Estimate) FindFolder(folder string) (FolderEstimate, bool) {
+
+	for _, folderEstimate := range e.Folders {
+		if folderEstimate.Folder == folder {
+			return folderEstimate, true
+		}
+	}
+
+	return FolderEstimate{}, false
+}
+
+// NewEstimate estimates the size of each of the folders of the config file, applying the global, folder, and robocopy excludes of
+// the config file. See EstimateSources for workers and top.
+func NewEstimate(configFilePath string, config model.ConfigFile, workers int, top int) (Estimate, error) {
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	sources, err := analyze.NewCoverageSources(configFilePath, config, nil)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	folders, err := EstimateSources(sources, workers, top)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	return Estimate{
+		ConfigPath: absConfigFilePath,
+		Time:       time.Now(),
+		Folders:    folders,
+	}, nil
+}
+
+// EstimateSources walks the folders of the sources, skipping the files and folders excluded by the matcher of each, with at most
+// 'workers' additional goroutines reading folders at once (across all sources). The estimate of each folder contains its 'top'
+// largest subfolders and files. Unreadable files and folders are reported as warnings, and skipped.
+func EstimateSources(sources []analyze.CoverageSource, workers int, top int) ([]FolderEstimate, error) {
+
+	if workers < 1 {
+		return nil, fmt.Errorf("number of workers must be at least 1")
+	}
+
+	for _, source := range sources {
+		if _, err := os.Stat(source.Folder); err != nil {
+			return nil, err
+		}
+	}
+
+	semaphore := make(chan struct{}, workers)
+
+	walkers := []*folderWalker{}
+
+	for _, source := range sources {
+		walker := &folderWalker{
+			root:      source.Folder,
+			matcher:   source.Matcher,
+			top:       top,
+			semaphore: semaphore,
+			total:     &sizeCounter{},
+		}
+		walkers = append(walkers, walker)
+
+		walker.spawn(func() { walker.walk(walker.root, []*sizeCounter{walker.total}) })
+	}
+
+	res := []FolderEstimate{}
+
+	for _, walker := range walkers {
+		walker.wg.Wait()
+		res = append(res, walker.result())
+	}
+
+	return res, nil
+}
+
+type sizeCounter struct {
+	bytes atomic.Int64
+	files atomic.Int64
+}
+
+// folderWalker walks the folders under a root concurrently: each subfolder is walked by a new goroutine if a worker is available,
+// otherwise by the current goroutine.
+type folderWalker struct {
+	root    string
+	matcher util.ExcludeMatcher
+	top     int
+
+	semaphore chan struct{}
+	wg        sync.WaitGroup
+
+	total *sizeCounter
+
+	// mutex guards the fields below
+	mutex        sync.Mutex
+	subfolders   map[string]*sizeCounter
+	largestFiles []SizeEntry
+}
+
+func (fw *folderWalker) spawn(fn func()) {
+
+	select {
+	case fw.semaphore <- struct{}{}:
+		fw.wg.Add(1)
+		go func() {
+			defer fw.wg.Done()
+			defer func() { <-fw.semaphore }()
+			fn()
+		}()
+	default:
+		fn()
+	}
+}
+
+// walk adds the size of each file under the folder to each of the counters.
+func (fw *folderWalker) walk(folder string, counters []*sizeCounter) {
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		fmt.Println("Warning: unable to read:", folder, err)
+		return
+	}
+
+	for _, entry := range entries {
+
+		path := filepath.Join(folder, entry.Name())
+
+		if fw.matcher.Excluded(path, entry.IsDir()) {
+			continue
+		}
+
+		if entry.IsDir() {
+
+			childCounters := counters
+			if folder == fw.root {
+				childCounters = append([]*sizeCounter{}, counters...)
+				childCounters = append(childCounters, fw.addSubfolder(path))
+			}
+
+			fw.spawn(func() { fw.walk(path, childCounters) })
+			continue
+		}
+
+		// As with the backup utilities, symbolic links are not followed
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			fmt.Println("Warning: unable to read:", path, err)
+			continue
+		}
+
+		for _, counter := range counters {
+			counter.bytes.Add(info.Size())
+			counter.files.Add(1)
+		}
+
+		fw.addFile(SizeEntry{Path: path, Bytes: info.Size()})
+	}
+}
+
+func (fw *folderWalker) addSubfolder(path string) *sizeCounter {
+
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+
+	if fw.subfolders == nil {
+		fw.subfolders = map[string]*sizeCounter{}
+	}
+
+	counter := &sizeCounter{}
+	fw.subfolders[path] = counter
+
+	return counter
+}
+
+func (fw *folderWalker) addFile(file SizeEntry) {
+
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+
+	fw.largestFiles = largest(append(fw.largestFiles, file), fw.top)
+}
+
+// result returns the estimate of the folder, once the walk is complete.
+func (fw *folderWalker) result() FolderEstimate {
+
+	subfolders := []SizeEntry{}
+	for path, counter := range fw.subfolders {
+		subfolders = append(subfolders, SizeEntry{Path: path, Bytes: counter.bytes.Load()})
+	}
+
+	largestFiles := fw.largestFiles
+	if largestFiles == nil {
+		largestFiles = []SizeEntry{}
+	}
+
+	return FolderEstimate{
+		Folder:            fw.root,
+		Bytes:             fw.total.bytes.Load(),
+		Files:             fw.total.files.Load(),
+		LargestSubfolders: largest(subfolders, fw.top),
+		LargestFiles:      largestFiles,
+	}
+}
+
+// largest returns the (at most) top largest entries, largest first (and, for entries of the same size, by path).
+func largest(entries []SizeEntry, top int) []SizeEntry {
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Bytes != entries[j].Bytes {
+			return entries[i].Bytes > entries[j].Bytes
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	if len(entries) > top {
+		entries = entries[0:top]
+	}
+
+	return entries
+}
+
+// RecordEstimate saves the estimate, replacing the previous estimate of the config file.
+func RecordEstimate(configFilePath string, estimate Estimate) error {
+
+	estimateFilePath, err := util.ConfigStateFilePath("estimates", configFilePath)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteStateFile(estimateFilePath, estimate)
+}
+
+// ReadEstimate returns the most recently saved estimate of the config file; if the config file has not been estimated, the
+// returned error satisfies errors.Is(err, os.ErrNotExist).
+func ReadEstimate(configFilePath string) (Estimate, error) {
+
+	estimateFilePath, err := util.ConfigStateFilePath("estimates", configFilePath)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	content, err := os.ReadFile(estimateFilePath)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	estimate := Estimate{}
+	if err := json.Unmarshal(content, &estimate); err != nil {
+		return Estimate{}, fmt.Errorf("unable to parse estimate '%s': %w", estimateFilePath, err)
+	}
+
+	return estimate, nil
+}
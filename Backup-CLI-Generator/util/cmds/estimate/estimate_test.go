@@ -0,0 +1,85 @@
+package estimate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/analyze"
+)
+
+func TestEstimateSources(t *testing.T) {
+
+	root := t.TempDir()
+
+	files := map[string]int{
+		"a/big.bin":         100,
+		"a/deep/medium.bin": 50,
+		"b/small.txt":       10,
+		"b/cache/skip.bin":  1000,
+		"top.txt":           5,
+		"notes.tmp":         500,
+	}
+
+	for file, size := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := []analyze.CoverageSource{
+		{Folder: root, Matcher: util.NewExcludeMatcher([]string{"cache/", "*.tmp"})},
+		{Folder: filepath.Join(root, "a")},
+	}
+
+	for _, workers := range []int{1, 4} {
+
+		estimates, err := EstimateSources(sources, workers, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(estimates) != 2 {
+			t.Fatalf("expected 2 estimates, got %d", len(estimates))
+		}
+
+		rootEstimate := estimates[0]
+		if rootEstimate.Bytes != 165 || rootEstimate.Files != 4 {
+			t.Errorf("workers %d: expected 165 bytes in 4 files, got %d bytes in %d files", workers, rootEstimate.Bytes, rootEstimate.Files)
+		}
+
+		expectedSubfolders := []SizeEntry{{Path: filepath.Join(root, "a"), Bytes: 150}, {Path: filepath.Join(root, "b"), Bytes: 10}}
+		if !equalSizeEntries(rootEstimate.LargestSubfolders, expectedSubfolders) {
+			t.Errorf("workers %d: expected subfolders %v, got %v", workers, expectedSubfolders, rootEstimate.LargestSubfolders)
+		}
+
+		expectedFiles := []SizeEntry{{Path: filepath.Join(root, "a", "big.bin"), Bytes: 100}, {Path: filepath.Join(root, "a", "deep", "medium.bin"), Bytes: 50}}
+		if !equalSizeEntries(rootEstimate.LargestFiles, expectedFiles) {
+			t.Errorf("workers %d: expected files %v, got %v", workers, expectedFiles, rootEstimate.LargestFiles)
+		}
+
+		if estimates[1].Bytes != 150 || estimates[1].Files != 2 {
+			t.Errorf("workers %d: expected 150 bytes in 2 files, got %d bytes in %d files", workers, estimates[1].Bytes, estimates[1].Files)
+		}
+	}
+}
+
+func equalSizeEntries(a []SizeEntry, b []SizeEntry) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -4,16 +4,63 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/jgwest/backup-cli/model"
 	"github.com/jgwest/backup-cli/util"
 )
 
-// checkMonitorFolders verifies that there are no unignored child folders of monitor folders.
+// CheckMonitorFoldersForMissingChildren verifies that there are no unignored folders (or files) under monitor folders that are
+// not backed up.
 func CheckMonitorFoldersForMissingChildren(configFilePath string, config model.ConfigFile) error {
 
+	monitorFolderResults, err := FindUnbackedUpMonitorPaths(config)
+	if err != nil {
+		return err
+	}
+
+	allUnbackedupPaths := []string{}
+
+	for _, monitorFolderResult := range monitorFolderResults {
+
+		fmt.Println()
+		fmt.Println("Un-backed-up paths found:")
+		for _, ubPath := range monitorFolderResult.Paths {
+			rel, err := filepath.Rel(monitorFolderResult.MonitorPath, ubPath)
+			if err != nil {
+				return err
+			}
+			fmt.Println("      - " + rel + "  # " + ubPath)
+		}
+		fmt.Println()
+
+		allUnbackedupPaths = append(allUnbackedupPaths, monitorFolderResult.Paths...)
+	}
+
+	if len(allUnbackedupPaths) != 0 {
+		return fmt.Errorf("monitor folder contained un-backed-up path (run 'monitor --fix' to add them to the config file): %v", allUnbackedupPaths)
+	}
+
+	return nil
+}
+
+// UnbackedUpMonitorPaths are the paths under a monitor folder of a config file that are not backed up
+type UnbackedUpMonitorPaths struct {
+	// Index is the index of the monitor folder in the 'monitorFolders' of the config file
+	Index         int
+	MonitorFolder model.MonitorFolder
+	MonitorPath   string
+	Paths         []string
+}
+
+// FindUnbackedUpMonitorPaths returns the paths under each of the monitor folders of the config file that are not backed up, for
+// the monitor folders that have any.
+func FindUnbackedUpMonitorPaths(config model.ConfigFile) ([]UnbackedUpMonitorPaths, error) {
+
+	res := []UnbackedUpMonitorPaths{}
+
 	if len(config.MonitorFolders) == 0 {
-		return nil
+		return res, nil
 	}
 
 	// Expand the folders to backup (ensuring they exist)
@@ -22,51 +69,48 @@ func CheckMonitorFoldersForMissingChildren(configFilePath string, config model.C
 
 		expandedPath, err := util.Expand(folder.Path, config.Substitutions)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if _, err := os.Stat(expandedPath); os.IsNotExist(err) {
-			return fmt.Errorf("'folders' path does not exist: '%s'", folder.Path)
+			return nil, fmt.Errorf("'folders' path does not exist: '%s'", folder.Path)
 		}
 
 		expandedBackupPaths = append(expandedBackupPaths, expandedPath)
 	}
 
-	for _, monitorFolder := range config.MonitorFolders {
+	for index, monitorFolder := range config.MonitorFolders {
 
 		monitorPath, err := util.Expand(monitorFolder.Path, config.Substitutions)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// If the paths to backup contain the monitor path itself, then we are good, so continue to the next item
+		// If the paths to backup contain the monitor path itself (or one of its ancestors), then we are good, so continue to the
+		// next item; a monitor folder under a folder to backup is reported, as it is redundant
 		if backupPathContains(expandedBackupPaths, monitorPath) {
+			if err := reportFolderOverlaps(FindMonitorFolderOverlaps(expandedBackupPaths, []string{monitorPath})); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
 		unbackedupPaths, err := findUnbackedUpPaths(monitorPath, monitorFolder, expandedBackupPaths)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if len(unbackedupPaths) != 0 {
-			fmt.Println()
-			fmt.Println("Un-backed-up paths found:")
-			for _, ubPath := range unbackedupPaths {
-				rel, err := filepath.Rel(monitorPath, ubPath)
-				if err != nil {
-					return err
-				}
-				fmt.Println("      - " + rel + "  # " + ubPath)
-			}
-			fmt.Println()
-
-			return fmt.Errorf("monitor folder contained un-backed-up path: %v", unbackedupPaths)
+			res = append(res, UnbackedUpMonitorPaths{
+				Index:         index,
+				MonitorFolder: monitorFolder,
+				MonitorPath:   monitorPath,
+				Paths:         unbackedupPaths,
+			})
 		}
-
 	}
 
-	return nil
+	return res, nil
 }
 
 type PopulateProcessFoldersResultEntry struct {
@@ -103,6 +147,7 @@ func PopulateProcessedFolders(configType model.ConfigType, configFolders []model
 		if _, contains := checkDupesMap[srcFolderPath]; contains {
 			return nil, fmt.Errorf("backup path list contains duplicate path: '%s'", srcFolderPath)
 		}
+		checkDupesMap[srcFolderPath] = nil
 
 		if len(folder.Excludes) != 0 &&
 			(configType == model.Restic ||
@@ -117,60 +162,136 @@ func PopulateProcessedFolders(configType model.ConfigType, configFolders []model
 		processedFolders = append(processedFolders, PopulateProcessFoldersResultEntry{SrcFolderPath: srcFolderPath, Folder: folder})
 	}
 
+	srcFolderPaths := []string{}
+	for _, processedFolder := range processedFolders {
+		srcFolderPaths = append(srcFolderPaths, processedFolder.SrcFolderPath)
+	}
+
+	if err := reportFolderOverlaps(FindFolderOverlaps(configType, srcFolderPaths)); err != nil {
+		return nil, err
+	}
+
 	return processedFolders, nil
 
 }
 
+// findUnbackedUpPaths returns the folders (and, if enabled, files) under the monitor folder, down to the depth of the monitor
+// folder, that are not backed up: neither they nor any of their ancestors are one of the backup paths. A folder that is not
+// backed up, but contains a backup path (or, with includes, does not match an include), is descended into rather than reported,
+// unless it is at the depth limit.
 func findUnbackedUpPaths(monitorPath string, monitorFolder model.MonitorFolder, expandedBackupPaths []string) ([]string, error) {
 
 	if _, err := os.Stat(monitorPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("'monitor path' does not exist: '%s' (%s)", monitorPath, monitorFolder.Path)
 	}
 
-	pathInfo, err := os.ReadDir(monitorPath)
+	maxDepth, err := monitorFolder.GetDepth()
 	if err != nil {
 		return nil, err
 	}
 
+	for _, pattern := range append(append([]string{}, monitorFolder.Excludes...), monitorFolder.Include...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("monitor folder '%s': invalid pattern '%s': %w", monitorFolder.Path, pattern, err)
+		}
+	}
+
 	unbackedupPaths := []string{}
 
-	// For each child folder under the monitor folder...
-outer:
-	for _, monPathInfo := range pathInfo {
-		if !monPathInfo.IsDir() {
-			continue
+	var walk func(folder string, depth int) error
+	walk = func(folder string, depth int) error {
+
+		pathInfo, err := os.ReadDir(folder)
+		if err != nil {
+			return err
 		}
 
-		fullPathName := filepath.Join(monitorPath, monPathInfo.Name())
+		// For each child under the folder...
+		for _, monPathInfo := range pathInfo {
 
-		// For each of the excluded directories in the monitor folder, expand the glob if needed,
-		// then see if the fullPathName is one of the glob matches; if so, skip it.
-		for _, exclude := range monitorFolder.Excludes {
+			if !monPathInfo.IsDir() && !monitorFolder.Files {
+				continue
+			}
 
-			globMatches, err := filepath.Glob(filepath.Join(monitorPath, exclude))
+			fullPathName := filepath.Join(folder, monPathInfo.Name())
+
+			relPath, err := filepath.Rel(monitorPath, fullPathName)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			// The folder from the folder list matched an exclude, so skip it
-			if backupPathContains(globMatches, fullPathName) {
-				continue outer
+			// The path matched an exclude, so skip it
+			if monitorPatternMatches(monitorFolder.Excludes, relPath) {
+				continue
+			}
+
+			if backupPathContains(expandedBackupPaths, fullPathName) {
+				continue
 			}
-		}
 
-		// For each of the folders under monitorPath, ensure they are backed up
-		if !backupPathContains(expandedBackupPaths, fullPathName) {
-			unbackedupPaths = append(unbackedupPaths, fullPathName)
+			included := len(monitorFolder.Include) == 0 || monitorPatternMatches(monitorFolder.Include, relPath)
+
+			if monPathInfo.IsDir() && depth < maxDepth && (!included || containsBackupPath(expandedBackupPaths, fullPathName)) {
+				if err := walk(fullPathName, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if included {
+				unbackedupPaths = append(unbackedupPaths, fullPathName)
+			}
 		}
+
+		return nil
+	}
+
+	if err := walk(monitorPath, 1); err != nil {
+		return nil, err
 	}
 
 	return unbackedupPaths, nil
 
 }
 
-func backupPathContains(backupPaths []string, testStr string) bool {
+// monitorPatternMatches returns true if the path (relative to the monitor folder) matches one of the glob patterns: a pattern
+// without a path separator is matched against the name, any other pattern against the relative path. Matching is
+// case-insensitive on Windows.
+func monitorPatternMatches(patterns []string, relPath string) bool {
+
+	relPath = normalizePath(relPath)
+
+	for _, pattern := range patterns {
+
+		pattern = normalizePath(filepath.FromSlash(pattern))
+
+		candidate := relPath
+		if !strings.Contains(pattern, string(filepath.Separator)) {
+			candidate = filepath.Base(relPath)
+		}
+
+		if matched, err := filepath.Match(pattern, candidate); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backupPathContains returns true if the path, or one of its ancestors, is one of the backup paths.
+func backupPathContains(backupPaths []string, path string) bool {
+	for _, backupPath := range backupPaths {
+		if samePath(backupPath, path) || isUnder(backupPath, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsBackupPath returns true if one of the backup paths is under the folder.
+func containsBackupPath(backupPaths []string, folder string) bool {
 	for _, backupPath := range backupPaths {
-		if testStr == backupPath {
+		if isUnder(folder, backupPath) {
 			return true
 		}
 	}
@@ -0,0 +1,87 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestFindUnbackedUpPaths(t *testing.T) {
+
+	root := t.TempDir()
+
+	for _, folder := range []string{"me/docs", "me/projects/a/src", "me/projects/b", "you/music", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"notes.txt", "me/todo.txt"} {
+		if err := os.WriteFile(filepath.Join(root, file), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backupPaths := []string{filepath.Join(root, "me", "docs"), filepath.Join(root, "me", "projects")}
+
+	tests := []struct {
+		name          string
+		monitorFolder model.MonitorFolder
+		expected      []string
+	}{
+		{
+			name:          "immediate children",
+			monitorFolder: model.MonitorFolder{Excludes: []string{"tmp"}},
+			// 'me' contains backup paths, but is at the depth limit
+			expected: []string{"me", "you"},
+		},
+		{
+			name:          "depth",
+			monitorFolder: model.MonitorFolder{Depth: 3, Excludes: []string{"tmp"}},
+			// 'me/projects/a/src' is covered by its ancestor 'me/projects'
+			expected: []string{"you"},
+		},
+		{
+			name:          "files",
+			monitorFolder: model.MonitorFolder{Depth: 2, Files: true, Excludes: []string{"tmp", "notes.*"}},
+			expected:      []string{"me/todo.txt", "you"},
+		},
+		{
+			name:          "include",
+			monitorFolder: model.MonitorFolder{Depth: 2, Include: []string{"*/music", "tm?"}},
+			expected:      []string{"tmp", "you/music"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			monitorFolder := test.monitorFolder
+			monitorFolder.Path = root
+
+			unbackedupPaths, err := findUnbackedUpPaths(root, monitorFolder, backupPaths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := []string{}
+			for _, path := range unbackedupPaths {
+				relPath, err := filepath.Rel(root, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual = append(actual, filepath.ToSlash(relPath))
+			}
+
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+			for index := range actual {
+				if actual[index] != test.expected[index] {
+					t.Fatalf("expected %v, got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
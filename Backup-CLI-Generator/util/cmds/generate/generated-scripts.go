@@ -0,0 +1,78 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+// GeneratedScript is a script that was generated from a config file ('generate', 'generate generic'), or that was compared with
+// the script generated from a config file ('check'). Generated scripts contain the repository credentials, so 'rotate-key'
+// regenerates them.
+type GeneratedScript struct {
+	Path string `json:"path"`
+
+	// Generic is true for scripts generated with 'generate generic', rather than 'generate'
+	Generic bool `json:"generic,omitempty"`
+}
+
+// RecordGeneratedScript adds the script to the list of scripts of the config file, if not already present.
+func RecordGeneratedScript(configFilePath string, script GeneratedScript) error {
+
+	absScriptPath, err := filepath.Abs(script.Path)
+	if err != nil {
+		return err
+	}
+	script.Path = absScriptPath
+
+	scripts, err := ReadGeneratedScripts(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range scripts {
+		if existing == script {
+			return nil
+		}
+	}
+
+	scripts = append(scripts, script)
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].Path < scripts[j].Path
+	})
+
+	scriptsFilePath, err := util.ConfigStateFilePath("scripts", configFilePath)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteStateFile(scriptsFilePath, scripts)
+}
+
+// ReadGeneratedScripts returns the scripts that were generated from (or checked against) the config file.
+func ReadGeneratedScripts(configFilePath string) ([]GeneratedScript, error) {
+
+	scriptsFilePath, err := util.ConfigStateFilePath("scripts", configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(scriptsFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []GeneratedScript{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	scripts := []GeneratedScript{}
+	if err := json.Unmarshal(content, &scripts); err != nil {
+		return nil, fmt.Errorf("unable to parse generated scripts '%s': %w", scriptsFilePath, err)
+	}
+
+	return scripts, nil
+}
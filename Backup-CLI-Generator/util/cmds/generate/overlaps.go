@@ -0,0 +1,166 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+// FindingSeverity is the severity of a problem found in a config file: errors prevent the config file from being used, while
+// warnings are only reported.
+type FindingSeverity string
+
+const (
+	SeverityError   FindingSeverity = "error"
+	SeverityWarning FindingSeverity = "warning"
+)
+
+// FolderOverlap is a folder (or monitor folder) of a config file that overlaps with another folder of the config file
+type FolderOverlap struct {
+	Severity FindingSeverity
+	Path     string
+	Other    string
+	Message  string
+}
+
+// FindFolderOverlaps returns the overlaps between the (expanded) folder paths of a config file:
+//   - the same folder listed twice (case-insensitively on Windows): an error
+//   - a folder under another folder: an error for mirror backends (rclone, robocopy), where the nested folder is copied to two
+//     destination folders, otherwise a warning (the nested folder is backed up twice, which is redundant)
+//   - a folder that is, or is under, another folder once symbolic links are resolved: as for nested folders
+func FindFolderOverlaps(configType model.ConfigType, folderPaths []string) []FolderOverlap {
+
+	res := []FolderOverlap{}
+
+	nestedSeverity := SeverityWarning
+	if configType == model.Robocopy || configType == model.Rclone {
+		nestedSeverity = SeverityError
+	}
+
+	for index, path := range folderPaths {
+		for otherIndex, other := range folderPaths {
+
+			if index == otherIndex {
+				continue
+			}
+
+			if samePath(path, other) {
+				// Only report each duplicate pair once
+				if index < otherIndex {
+					res = append(res, FolderOverlap{
+						Severity: SeverityError,
+						Path:     path,
+						Other:    other,
+						Message:  fmt.Sprintf("folder '%s' is listed more than once", path),
+					})
+				}
+				continue
+			}
+
+			if isUnder(other, path) {
+				res = append(res, FolderOverlap{
+					Severity: nestedSeverity,
+					Path:     path,
+					Other:    other,
+					Message:  fmt.Sprintf("folder '%s' is under folder '%s', so it is backed up twice", path, other),
+				})
+				continue
+			}
+
+			resolvedPath, resolvedOther := resolvePath(path), resolvePath(other)
+			if (index < otherIndex && samePath(resolvedPath, resolvedOther)) || isUnder(resolvedOther, resolvedPath) {
+				res = append(res, FolderOverlap{
+					Severity: nestedSeverity,
+					Path:     path,
+					Other:    other,
+					Message:  fmt.Sprintf("folder '%s' resolves (via symbolic links) to '%s', which is in folder '%s', so it is backed up twice", path, resolvedPath, other),
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+// FindMonitorFolderOverlaps returns a warning for each of the (expanded) monitor paths that is under one of the folder paths, and
+// so is already backed up.
+func FindMonitorFolderOverlaps(folderPaths []string, monitorPaths []string) []FolderOverlap {
+
+	res := []FolderOverlap{}
+
+	for _, monitorPath := range monitorPaths {
+		for _, folderPath := range folderPaths {
+			if isUnder(folderPath, monitorPath) {
+				res = append(res, FolderOverlap{
+					Severity: SeverityWarning,
+					Path:     monitorPath,
+					Other:    folderPath,
+					Message:  fmt.Sprintf("monitor folder '%s' is under folder '%s', so it is already backed up", monitorPath, folderPath),
+				})
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+// reportFolderOverlaps prints the warnings, and returns an error describing the errors (if any).
+func reportFolderOverlaps(overlaps []FolderOverlap) error {
+
+	errs := []error{}
+
+	for _, overlap := range overlaps {
+		if overlap.Severity == SeverityError {
+			errs = append(errs, errors.New(overlap.Message))
+		} else {
+			fmt.Println("Warning:", overlap.Message)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolvePath returns the path with symbolic links resolved, or if they cannot be resolved, the path.
+func resolvePath(path string) string {
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+
+	if absResolved, err := filepath.Abs(resolved); err == nil {
+		resolved = absResolved
+	}
+
+	return resolved
+}
+
+// isUnder returns true if the path is under (but is not) the folder.
+func isUnder(folder string, path string) bool {
+
+	relPath, err := filepath.Rel(normalizePath(folder), normalizePath(path))
+
+	return err == nil && relPath != "." && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) &&
+		!filepath.IsAbs(relPath)
+}
+
+// normalizePath returns the cleaned path, in lowercase on Windows (where paths are case-insensitive).
+func normalizePath(path string) string {
+
+	path = filepath.Clean(path)
+
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+
+	return path
+}
+
+func samePath(a string, b string) bool {
+	return normalizePath(a) == normalizePath(b)
+}
@@ -0,0 +1,70 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestFindFolderOverlaps(t *testing.T) {
+
+	root := t.TempDir()
+
+	for _, folder := range []string{"home/me/projects", "data/photos"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 'photos' resolves to a folder under 'data'
+	if err := os.Symlink(filepath.Join(root, "data", "photos"), filepath.Join(root, "photos")); err != nil {
+		t.Skip("unable to create symbolic link:", err)
+	}
+
+	home := filepath.Join(root, "home", "me")
+	projects := filepath.Join(root, "home", "me", "projects")
+	data := filepath.Join(root, "data")
+	photos := filepath.Join(root, "photos")
+
+	tests := []struct {
+		name        string
+		configType  model.ConfigType
+		folderPaths []string
+		expected    []FindingSeverity
+	}{
+		{name: "no overlap", configType: model.Restic, folderPaths: []string{home, data}, expected: []FindingSeverity{}},
+		{name: "duplicate", configType: model.Restic, folderPaths: []string{home, filepath.Join(home, ".")}, expected: []FindingSeverity{SeverityError}},
+		{name: "nested", configType: model.Restic, folderPaths: []string{home, projects}, expected: []FindingSeverity{SeverityWarning}},
+		{name: "nested, mirror backend", configType: model.Robocopy, folderPaths: []string{projects, home}, expected: []FindingSeverity{SeverityError}},
+		{name: "symbolic link", configType: model.Kopia, folderPaths: []string{data, photos}, expected: []FindingSeverity{SeverityWarning}},
+		{name: "symbolic link, mirror backend", configType: model.Rclone, folderPaths: []string{photos, data}, expected: []FindingSeverity{SeverityError}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			overlaps := FindFolderOverlaps(test.configType, test.folderPaths)
+
+			if len(overlaps) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, overlaps)
+			}
+
+			for index, overlap := range overlaps {
+				if overlap.Severity != test.expected[index] {
+					t.Fatalf("expected %v, got %v", test.expected, overlaps)
+				}
+			}
+		})
+	}
+
+	t.Run("monitor folder", func(t *testing.T) {
+
+		overlaps := FindMonitorFolderOverlaps([]string{home}, []string{projects, data})
+
+		if len(overlaps) != 1 || overlaps[0].Path != projects || overlaps[0].Severity != SeverityWarning {
+			t.Fatalf("expected a warning for '%s', got %v", projects, overlaps)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFilePatch adds entries to a config file by inserting lines into it, so that the rest of the content of the config file
+// (comments, ordering, and formatting) is unchanged. The config file is parsed with yaml.v3, which records the position of each
+// node, to find where to insert.
+type ConfigFilePatch struct {
+	lines   []string
+	newline string
+}
+
+// NewConfigFilePatch returns a patch of the config file content.
+func NewConfigFilePatch(content []byte) (*ConfigFilePatch, error) {
+
+	text := string(content)
+
+	newline := "\n"
+	if strings.Contains(text, "\r\n") {
+		newline = "\r\n"
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+
+	res := &ConfigFilePatch{lines: strings.Split(text, "\n"), newline: newline}
+
+	if _, err := res.root(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Bytes returns the patched config file content.
+func (cfp *ConfigFilePatch) Bytes() []byte {
+	return []byte(strings.Join(cfp.lines, cfp.newline))
+}
+
+// AddFolder adds a folder with the path to 'folders'.
+func (cfp *ConfigFilePatch) AddFolder(path string) error {
+
+	root, err := cfp.root()
+	if err != nil {
+		return err
+	}
+
+	item, err := yamlScalar(path)
+	if err != nil {
+		return err
+	}
+
+	return cfp.appendSequenceItem(root, "folders", "path: "+item)
+}
+
+// AddMonitorFolderExclude adds the exclude to the 'excludes' of the monitor folder at the index of 'monitorFolders'.
+func (cfp *ConfigFilePatch) AddMonitorFolderExclude(index int, exclude string) error {
+
+	root, err := cfp.root()
+	if err != nil {
+		return err
+	}
+
+	monitorFolders := mappingValue(root, "monitorFolders")
+	if monitorFolders == nil || monitorFolders.Kind != yaml.SequenceNode || index < 0 || index >= len(monitorFolders.Content) {
+		return fmt.Errorf("config file has no monitor folder %d", index+1)
+	}
+
+	monitorFolder := monitorFolders.Content[index]
+	if monitorFolder.Kind != yaml.MappingNode || monitorFolder.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("monitor folder %d is not a block mapping, so it cannot be updated", index+1)
+	}
+
+	item, err := yamlScalar(exclude)
+	if err != nil {
+		return err
+	}
+
+	return cfp.appendSequenceItem(monitorFolder, "excludes", item)
+}
+
+// root returns the top-level mapping of the config file, or nil if the config file is empty.
+func (cfp *ConfigFilePatch) root() (*yaml.Node, error) {
+
+	document := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(strings.Join(cfp.lines, "\n")), &document); err != nil {
+		return nil, err
+	}
+
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode || root.Style&yaml.FlowStyle != 0 {
+		return nil, fmt.Errorf("config file is not a block mapping, so it cannot be updated")
+	}
+
+	return root, nil
+}
+
+// appendSequenceItem appends the item to the sequence that is the value of the key of the mapping (nil for an empty config file),
+// adding the key if needed. New sequences use the style of 'yaml.Marshal' (and so of most config files): items are not indented
+// relative to their key.
+func (cfp *ConfigFilePatch) appendSequenceItem(mapping *yaml.Node, key string, item string) error {
+
+	if mapping == nil {
+		cfp.insertLines(len(cfp.lines), []string{key + ":", "- " + item})
+		return nil
+	}
+
+	value := mappingValue(mapping, key)
+
+	if value == nil {
+		indent := strings.Repeat(" ", mapping.Column-1)
+		cfp.insertLines(endLine(mapping), []string{indent + key + ":", indent + "- " + item})
+		return nil
+	}
+
+	if value.Kind == yaml.ScalarNode && value.Tag == "!!null" && value.Value == "" {
+		keyNode := mappingKey(mapping, key)
+		cfp.insertLines(keyNode.Line, []string{strings.Repeat(" ", keyNode.Column-1) + "- " + item})
+		return nil
+	}
+
+	if value.Kind != yaml.SequenceNode || value.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("'%s' is not a block sequence, so it cannot be updated", key)
+	}
+
+	cfp.insertLines(endLine(value), []string{strings.Repeat(" ", value.Column-1) + "- " + item})
+
+	return nil
+}
+
+// insertLines inserts the lines after the line with the (1-based) line number; if the last line is not terminated by a newline,
+// lines inserted after it are.
+func (cfp *ConfigFilePatch) insertLines(lineNumber int, lines []string) {
+
+	if lineNumber >= len(cfp.lines) {
+		lineNumber = len(cfp.lines)
+		if cfp.lines[lineNumber-1] == "" {
+			// The content ends with a newline (and so the last element is empty): insert before it
+			lineNumber--
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	updated := append([]string{}, cfp.lines[0:lineNumber]...)
+	updated = append(updated, lines...)
+	cfp.lines = append(updated, cfp.lines[lineNumber:]...)
+}
+
+func mappingKey(mapping *yaml.Node, key string) *yaml.Node {
+
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value == key {
+			return mapping.Content[index]
+		}
+	}
+
+	return nil
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value == key {
+			return mapping.Content[index+1]
+		}
+	}
+
+	return nil
+}
+
+// endLine returns the (1-based) number of the last line of the node.
+func endLine(node *yaml.Node) int {
+
+	res := node.Line
+	if node.Kind == yaml.ScalarNode {
+		res += strings.Count(strings.TrimRight(node.Value, "\n"), "\n")
+	}
+
+	for _, child := range node.Content {
+		if childEndLine := endLine(child); childEndLine > res {
+			res = childEndLine
+		}
+	}
+
+	return res
+}
+
+// yamlScalar returns the value as a (quoted if needed) single-line YAML scalar.
+func yamlScalar(value string) (string, error) {
+
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("value contains a newline: %q", value)
+	}
+
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"testing"
+)
+
+func TestConfigFilePatch(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		content  string
+		patch    func(*ConfigFilePatch) error
+		expected string
+	}{
+		{
+			name: "add folder, preserving comments",
+			content: `# My backup
+folders:
+- path: /home/me/docs  # documents
+  excludes:
+  - "*.tmp"
+# photos
+- path: /home/me/photos
+monitorFolders:
+- path: /home/me
+`,
+			patch: func(patch *ConfigFilePatch) error { return patch.AddFolder("/home/me/new folder") },
+			expected: `# My backup
+folders:
+- path: /home/me/docs  # documents
+  excludes:
+  - "*.tmp"
+# photos
+- path: /home/me/photos
+- path: /home/me/new folder
+monitorFolders:
+- path: /home/me
+`,
+		},
+		{
+			name:     "add folder to indented sequence, without trailing newline",
+			content:  "folders:\n  - path: /a\n  - path: /b",
+			patch:    func(patch *ConfigFilePatch) error { return patch.AddFolder("$HOME/c") },
+			expected: "folders:\n  - path: /a\n  - path: /b\n  - path: $HOME/c\n",
+		},
+		{
+			name:     "add folders key",
+			content:  "metadata:\n  name: test\n",
+			patch:    func(patch *ConfigFilePatch) error { return patch.AddFolder("*special*") },
+			expected: "metadata:\n  name: test\nfolders:\n- path: '*special*'\n",
+		},
+		{
+			name:     "add to empty folders, with CRLF",
+			content:  "folders:\r\nmonitorFolders:\r\n- path: /home\r\n",
+			patch:    func(patch *ConfigFilePatch) error { return patch.AddFolder("/home/a") },
+			expected: "folders:\r\n- path: /home/a\r\nmonitorFolders:\r\n- path: /home\r\n",
+		},
+		{
+			name:     "add exclude to second monitor folder",
+			content:  "monitorFolders:\n- path: /a\n- path: /b\n  excludes:\n  - x\nfolders: []\n",
+			patch:    func(patch *ConfigFilePatch) error { return patch.AddMonitorFolderExclude(1, "tmp") },
+			expected: "monitorFolders:\n- path: /a\n- path: /b\n  excludes:\n  - x\n  - tmp\nfolders: []\n",
+		},
+		{
+			name:     "add excludes key",
+			content:  "monitorFolders:\n- path: /a  # home\n- path: /b\n",
+			patch:    func(patch *ConfigFilePatch) error { return patch.AddMonitorFolderExclude(0, "cache") },
+			expected: "monitorFolders:\n- path: /a  # home\n  excludes:\n  - cache\n- path: /b\n",
+		},
+		{
+			name:    "flow sequence",
+			content: "folders: []\n",
+			patch:   func(patch *ConfigFilePatch) error { return patch.AddFolder("/a") },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			patch, err := NewConfigFilePatch([]byte(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = test.patch(patch)
+			if test.expected == "" {
+				if err == nil {
+					t.Fatalf("expected an error, got:\n%s", string(patch.Bytes()))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := string(patch.Bytes()); actual != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", test.expected, actual)
+			}
+		})
+	}
+}
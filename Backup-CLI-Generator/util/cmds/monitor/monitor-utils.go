@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+// FolderPath returns the 'folders' path of a file or folder under a monitor folder: the (unexpanded) path of the monitor folder,
+// followed by the path of the file or folder relative to the monitor folder, so that the substitutions and path separators of
+// the monitor folder are preserved.
+func FolderPath(monitorFolder model.MonitorFolder, monitorPath string, path string) (string, error) {
+
+	relPath, err := filepath.Rel(monitorPath, path)
+	if err != nil {
+		return "", err
+	}
+
+	separator := "/"
+	if strings.Contains(monitorFolder.Path, `\`) && !strings.Contains(monitorFolder.Path, "/") {
+		separator = `\`
+	}
+
+	return strings.TrimRight(monitorFolder.Path, `/\`) + separator + strings.Join(strings.Split(relPath, string(filepath.Separator)), separator), nil
+}
+
+// ExcludePattern returns a monitor folder exclude that matches only the file or folder under the monitor folder: its path relative
+// to the monitor folder, with glob characters escaped.
+func ExcludePattern(monitorPath string, path string) (string, error) {
+
+	relPath, err := filepath.Rel(monitorPath, path)
+	if err != nil {
+		return "", err
+	}
+
+	escaper := strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]")
+
+	return escaper.Replace(filepath.ToSlash(relPath)), nil
+}
+
+// WriteConfigFile replaces the content of the config file, once the new content is verified to be a valid config file.
+func WriteConfigFile(configFilePath string, content []byte) error {
+
+	info, err := os.Stat(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	tempFilePath := configFilePath + ".tmp"
+
+	if err := os.WriteFile(tempFilePath, content, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if _, err := model.ReadConfigFile(tempFilePath); err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("the updated config file is not valid, so it was not written: %w", err)
+	}
+
+	return os.Rename(tempFilePath, configFilePath)
+}
@@ -0,0 +1,59 @@
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PrepareTarget ensures the restore target folder exists, and, unless force is set, that it is empty.
+func PrepareTarget(target string, force bool) error {
+
+	if target == "" {
+		return fmt.Errorf("a restore target folder is required")
+	}
+
+	dirEntries, err := os.ReadDir(target)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(target, 0700)
+	} else if err != nil {
+		return fmt.Errorf("unable to read restore target '%s': %w", target, err)
+	}
+
+	if len(dirEntries) > 0 && !force {
+		return fmt.Errorf("restore target '%s' is not empty: use --force to restore into it anyway", target)
+	}
+
+	return nil
+}
+
+// TargetPath returns the path that the file/folder originally at originalPath is restored to: the target folder joined with
+// the original absolute path, without the volume name (for example, 'C:\Users\me' is restored to '(target)\Users\me').
+func TargetPath(target string, originalPath string) string {
+	return filepath.Join(target, strings.TrimPrefix(originalPath, filepath.VolumeName(originalPath)))
+}
+
+// RelativePathsUnder returns the paths (from 'paths') that are equal to, or under, the folder, relative to that folder. If
+// paths is empty, the folder itself ('.') is returned.
+func RelativePathsUnder(folder string, paths []string) []string {
+
+	if len(paths) == 0 {
+		return []string{"."}
+	}
+
+	res := []string{}
+
+	for _, path := range paths {
+
+		// (On Windows, filepath.Rel is case insensitive)
+		relPath, err := filepath.Rel(folder, path)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		res = append(res, relPath)
+	}
+
+	return res
+}
@@ -0,0 +1,49 @@
+package restore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrepareTarget(t *testing.T) {
+
+	target := filepath.Join(t.TempDir(), "target")
+
+	if err := PrepareTarget(target, false); err != nil {
+		t.Fatalf("missing target should be created: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(target, "file"), []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PrepareTarget(target, false); err == nil {
+		t.Errorf("non-empty target should be rejected")
+	}
+
+	if err := PrepareTarget(target, true); err != nil {
+		t.Errorf("non-empty target should be accepted with force: %v", err)
+	}
+}
+
+func TestRelativePathsUnder(t *testing.T) {
+
+	folder := filepath.Join(string(filepath.Separator), "home", "me")
+
+	paths := []string{
+		filepath.Join(folder, "docs"),
+		folder,
+		filepath.Join(string(filepath.Separator), "home", "meow"),
+	}
+
+	actual := RelativePathsUnder(folder, paths)
+
+	if len(actual) != 2 || actual[0] != "docs" || actual[1] != "." {
+		t.Errorf("unexpected relative paths: %v", actual)
+	}
+
+	if actual := RelativePathsUnder(folder, nil); len(actual) != 1 || actual[0] != "." {
+		t.Errorf("unexpected relative paths: %v", actual)
+	}
+}
@@ -0,0 +1,149 @@
+package rotatekey
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+// GeneratePassword returns a new random password of 256 bits.
+func GeneratePassword() (string, error) {
+
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(value), nil
+}
+
+// SavePendingPassword saves the new password of the config file in the state directory until the rotation completes, so that it
+// is not lost if the repository key is changed but the config file cannot be updated. The path of the file is returned.
+func SavePendingPassword(configFilePath string, password string) (string, error) {
+
+	pendingPasswordFilePath, err := util.ConfigStateFilePath("rotate-key", configFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	return pendingPasswordFilePath, writeSecretFile(pendingPasswordFilePath, password)
+}
+
+// UpdateConfigPassword updates the secret reference of the credentials of the config file to the new password: a password
+// file is overwritten with the new password, while a password in the config file is replaced in place (preserving the rest of
+// the file).
+func UpdateConfigPassword(configFilePath string, config model.ConfigFile, newPassword string) error {
+
+	configType, err := config.GetConfigType()
+	if err != nil {
+		return err
+	}
+
+	oldPassword := ""
+
+	switch configType {
+	case model.Restic:
+		resticCredentials, err := config.GetResticCredential()
+		if err != nil {
+			return err
+		}
+
+		if resticCredentials.PasswordFile != "" {
+			return writeSecretFile(resticCredentials.PasswordFile, newPassword)
+		}
+		oldPassword = resticCredentials.Password
+
+	case model.Kopia:
+		kopiaCredentials, err := config.GetKopiaCredential()
+		if err != nil {
+			return err
+		}
+		oldPassword = kopiaCredentials.Password
+
+	default:
+		return fmt.Errorf("config type '%v' does not have a repository password", configType)
+	}
+
+	content, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	updatedContent, err := replacePasswordValue(string(content), oldPassword, newPassword)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	tempFilePath := configFilePath + ".tmp"
+
+	if err := os.WriteFile(tempFilePath, []byte(updatedContent), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilePath, configFilePath)
+}
+
+// passwordLine matches a 'password' key of a YAML file, and its value
+var passwordLine = regexp.MustCompile(`^(\s*(?:-\s+)?password:\s*)(.*?)(\r?)$`)
+
+// replacePasswordValue replaces the value of the single 'password' key of the YAML content whose value is oldPassword.
+func replacePasswordValue(content string, oldPassword string, newPassword string) (string, error) {
+
+	quotedNewPassword, err := yaml.Marshal(newPassword)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(content, "\n")
+	matchingLine := -1
+
+	for index, line := range lines {
+
+		match := passwordLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		value := ""
+		if err := yaml.Unmarshal([]byte(match[2]), &value); err != nil || value != oldPassword {
+			continue
+		}
+
+		if matchingLine != -1 {
+			return "", fmt.Errorf("the password occurs more than once in the config file")
+		}
+		matchingLine = index
+
+		lines[index] = match[1] + strings.TrimSpace(string(quotedNewPassword)) + match[3]
+	}
+
+	if matchingLine == -1 {
+		return "", fmt.Errorf("unable to locate the password in the config file")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// writeSecretFile writes the secret to a temporary file (readable only by the user), then renames it.
+func writeSecretFile(path string, secret string) error {
+
+	tempFilePath := path + ".tmp"
+
+	if err := os.WriteFile(tempFilePath, []byte(secret), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilePath, path)
+}
@@ -0,0 +1,63 @@
+package rotatekey
+
+import (
+	"testing"
+)
+
+func TestReplacePasswordValue(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "plain value",
+			content:  "credentials:\n- kopia:\n    password: old\n    s3:\n      url: x\n",
+			expected: "credentials:\n- kopia:\n    password: new-password\n    s3:\n      url: x\n",
+		},
+		{
+			name:     "quoted value, CRLF",
+			content:  "credentials:\r\n- restic:\r\n    password: \"old\"\r\n",
+			expected: "credentials:\r\n- restic:\r\n    password: new-password\r\n",
+		},
+		{
+			name:     "first key of a list item",
+			content:  "credentials:\n- password: old\n",
+			expected: "credentials:\n- password: new-password\n",
+		},
+		{
+			name:        "different password",
+			content:     "credentials:\n- kopia:\n    password: other\n",
+			expectError: true,
+		},
+		{
+			name:        "ambiguous",
+			content:     "a:\n  password: old\nb:\n  password: old\n",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			actual, err := replacePasswordValue(test.content, "old", "new-password")
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %q", actual)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package runbackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/runlock"
+)
+
+// BeginBackup acquires the run lock of the config file and repository, and returns a context that is cancelled once the
+// 'maxDuration' of the config file (if any) has elapsed. The returned function must be called once the backup has ended.
+func BeginBackup(ctx context.Context, configFilePath string, config model.ConfigFile, options model.BackupOptions) (context.Context, func(), error) {
+	return beginBackup(ctx, configFilePath, config, options, os.Stdout)
+}
+
+// beginBackup is BeginBackup, reporting a cancelled backup to 'out'.
+func beginBackup(ctx context.Context, configFilePath string, config model.ConfigFile, options model.BackupOptions, out io.Writer) (context.Context, func(), error) {
+
+	maxDuration, err := config.GetMaxDuration()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := runlock.Acquire(ctx, configFilePath, config, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cancel := func() {}
+	if maxDuration > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, maxDuration, fmt.Errorf("backup exceeded maxDuration of %v", maxDuration))
+	}
+
+	start := time.Now()
+
+	endBackup := func() {
+		// The cause must be read before cancel(), which would otherwise cancel a backup that ended normally: the context is
+		// only done here if the maxDuration elapsed, or the parent context (e.g. on a signal) was cancelled.
+		if ctx.Err() != nil {
+			fmt.Fprintf(out, "Backup was cancelled after %v: %v\n", time.Since(start).Round(time.Second), context.Cause(ctx))
+		}
+		cancel()
+		lock.Release()
+	}
+
+	return ctx, endBackup, nil
+}
@@ -0,0 +1,105 @@
+package runbackup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestBeginBackup(t *testing.T) {
+
+	t.Setenv(util.StateDirEnvVar, t.TempDir())
+
+	for _, c := range []struct {
+		name        string
+		maxDuration string
+
+		// cancelParent, if true, cancels the parent context (as on a signal) during the backup
+		cancelParent bool
+
+		// runFor is how long the backup runs: until the context is done, if zero
+		runFor time.Duration
+
+		expectedOutput string
+	}{
+		{
+			name:           "ends normally, without maxDuration",
+			runFor:         time.Millisecond,
+			expectedOutput: "",
+		},
+		{
+			name:           "ends normally, within maxDuration",
+			maxDuration:    "1h",
+			runFor:         time.Millisecond,
+			expectedOutput: "",
+		},
+		{
+			name:           "exceeds maxDuration",
+			maxDuration:    "10ms",
+			expectedOutput: "backup exceeded maxDuration of 10ms",
+		},
+		{
+			name:           "parent cancelled",
+			maxDuration:    "1h",
+			cancelParent:   true,
+			expectedOutput: "interrupted",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			config := model.ConfigFile{
+				MaxDuration: c.maxDuration,
+				Credentials: []model.Credentials{
+					{Rclone: &model.RcloneCredentials{DestinationFolder: t.TempDir()}},
+				},
+			}
+
+			parentCtx, cancelParent := context.WithCancelCause(context.Background())
+			defer cancelParent(nil)
+
+			out := &bytes.Buffer{}
+
+			ctx, endBackup, err := beginBackup(parentCtx, "/config.yaml", config, model.BackupOptions{}, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.cancelParent {
+				cancelParent(errors.New("interrupted"))
+			}
+
+			if c.runFor > 0 {
+				time.Sleep(c.runFor)
+			} else {
+				select {
+				case <-ctx.Done():
+				case <-time.After(5 * time.Second):
+					t.Fatal("context was not cancelled")
+				}
+			}
+
+			endBackup()
+
+			if c.expectedOutput == "" {
+				if out.Len() != 0 {
+					t.Errorf("unexpected output: %s", out.String())
+				}
+			} else if !strings.Contains(out.String(), "Backup was cancelled") || !strings.Contains(out.String(), c.expectedOutput) {
+				t.Errorf("output does not report '%s': %s", c.expectedOutput, out.String())
+			}
+
+			// The lock is released, so another backup can begin
+			_, endNextBackup, err := beginBackup(context.Background(), "/config.yaml", config, model.BackupOptions{}, out)
+			if err != nil {
+				t.Fatalf("lock was not released: %v", err)
+			}
+			endNextBackup()
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package runbackup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// Checkpoint records which folder pairs of a config file were successfully backed up, so that an interrupted run may be
+// resumed with 'backup --resume'.
+type Checkpoint struct {
+	ConfigPath string `json:"configPath"`
+
+	// FolderListHash identifies the folder pairs of the config file at the time of the run: if the folder list has since
+	// changed, the checkpoint is ignored.
+	FolderListHash string `json:"folderListHash"`
+
+	// Completed is a map of folder pair key ('source -> dest') to the time the pair was last successfully backed up
+	Completed map[string]time.Time `json:"completed"`
+}
+
+// ExecuteFolderJobsWithCheckpoint runs the folder jobs via ExecuteFolderJobs, recording each successful job in the checkpoint file
+// of the config file as it completes. If options.Resume is set, jobs that completed within options.ResumeWindow (according to
+// the previous checkpoint) are skipped.
+func ExecuteFolderJobsWithCheckpoint(configFilePath string, jobs []FolderJob, concurrency int, options model.BackupOptions) (RunResult, error) {
+
+	checkpointFilePath, err := checkpointFilePath(configFilePath)
+	if err != nil {
+		return RunResult{}, err
+	}
+
+	checkpoint := Checkpoint{
+		ConfigPath:     configFilePath,
+		FolderListHash: folderListHash(jobs),
+		Completed:      map[string]time.Time{},
+	}
+
+	if options.Resume {
+
+		previous, err := ReadCheckpoint(configFilePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return RunResult{}, err
+		}
+
+		if err == nil {
+			if previous.FolderListHash == checkpoint.FolderListHash {
+				checkpoint.Completed = previous.Completed
+			} else {
+				fmt.Println("The folder list has changed since the previous run: all folders will be backed up.")
+			}
+		}
+	}
+
+	results := make([]FolderResult, len(jobs))
+
+	// indices into 'jobs' of the jobs that were not skipped
+	jobIndices := []int{}
+	jobsToRun := []FolderJob{}
+
+	mutex := sync.Mutex{}
+
+	for index, job := range jobs {
+
+		key := folderPairKey(job)
+
+		if completedTime, exists := checkpoint.Completed[key]; exists && time.Since(completedTime) <= options.ResumeWindow {
+			fmt.Printf("Skipping '%s', which was backed up at %s\n", job.Source, completedTime.Format(time.RFC3339))
+			results[index] = FolderResult{Source: job.Source, Dest: job.Dest, Status: FolderStatusSkipped}
+			continue
+		}
+
+		// Completions outside of the resume window are forgotten, so that a failure of the job is not hidden
+		delete(checkpoint.Completed, key)
+
+		run := job.Run
+		job.Run = func(outputPrefix string) error {
+
+			err := run(outputPrefix)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err == nil {
+				checkpoint.Completed[key] = time.Now()
+			}
+
+			if writeErr := writeCheckpoint(checkpointFilePath, checkpoint); writeErr != nil {
+				fmt.Println(outputPrefix+"Warning: unable to write checkpoint:", writeErr)
+			}
+
+			return err
+		}
+
+		jobIndices = append(jobIndices, index)
+		jobsToRun = append(jobsToRun, job)
+	}
+
+	if err := writeCheckpoint(checkpointFilePath, checkpoint); err != nil {
+		return RunResult{}, fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+
+	runResult := ExecuteFolderJobs(jobsToRun, concurrency)
+
+	for resultIndex, jobIndex := range jobIndices {
+		results[jobIndex] = runResult.Folders[resultIndex]
+	}
+
+	return RunResult{Folders: results}, nil
+}
+
+// ReadCheckpoint returns the checkpoint of the previous run of the config file; if there is no checkpoint, the returned error
+// satisfies errors.Is(err, os.ErrNotExist).
+func ReadCheckpoint(configFilePath string) (Checkpoint, error) {
+
+	checkpointFilePath, err := checkpointFilePath(configFilePath)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	content, err := os.ReadFile(checkpointFilePath)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	checkpoint := Checkpoint{}
+	if err := json.Unmarshal(content, &checkpoint); err != nil {
+		return Checkpoint{}, fmt.Errorf("unable to parse checkpoint '%s': %w", checkpointFilePath, err)
+	}
+
+	if checkpoint.Completed == nil {
+		checkpoint.Completed = map[string]time.Time{}
+	}
+
+	return checkpoint, nil
+}
+
+func writeCheckpoint(checkpointFilePath string, checkpoint Checkpoint) error {
+	return util.WriteStateFile(checkpointFilePath, checkpoint)
+}
+
+func checkpointFilePath(configFilePath string) (string, error) {
+	return util.ConfigStateFilePath("checkpoints", configFilePath)
+}
+
+func folderPairKey(job FolderJob) string {
+	return job.Source + " -> " + job.Dest
+}
+
+// folderListHash returns a hash of the (unordered) set of folder pairs of the jobs.
+func folderListHash(jobs []FolderJob) string {
+
+	keys := []string{}
+	for _, job := range jobs {
+		keys = append(keys, folderPairKey(job))
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key + "\n"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package runbackup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestExecuteFolderJobsWithCheckpoint(t *testing.T) {
+
+	t.Setenv(util.StateDirEnvVar, t.TempDir())
+
+	// newJobs returns jobs for the given sources, which record that they ran, and fail if listed in 'failing'
+	newJobs := func(sources []string, ran map[string]bool, failing map[string]bool) []FolderJob {
+		jobs := []FolderJob{}
+		for _, source := range sources {
+			jobs = append(jobs, FolderJob{
+				Source: source,
+				Dest:   "/dest" + source,
+				Run: func(outputPrefix string) error {
+					ran[source] = true
+					if failing[source] {
+						return fmt.Errorf("failure")
+					}
+					return nil
+				},
+			})
+		}
+		return jobs
+	}
+
+	resumeOptions := model.BackupOptions{Resume: true, ResumeWindow: time.Hour}
+
+	for _, c := range []struct {
+		name        string
+		sources     []string
+		failing     map[string]bool
+		options     model.BackupOptions
+		expectedRan []string
+	}{
+		{
+			name:        "initial run, with a failure",
+			sources:     []string{"/a", "/b", "/c"},
+			failing:     map[string]bool{"/b": true},
+			options:     model.BackupOptions{},
+			expectedRan: []string{"/a", "/b", "/c"},
+		},
+		{
+			name:        "resume runs only the failed folder",
+			sources:     []string{"/a", "/b", "/c"},
+			options:     resumeOptions,
+			expectedRan: []string{"/b"},
+		},
+		{
+			name:        "resume outside of the window runs all folders",
+			sources:     []string{"/a", "/b", "/c"},
+			options:     model.BackupOptions{Resume: true, ResumeWindow: 0},
+			expectedRan: []string{"/a", "/b", "/c"},
+		},
+		{
+			name:        "changed folder list invalidates the checkpoint",
+			sources:     []string{"/a", "/b", "/c", "/d"},
+			options:     resumeOptions,
+			expectedRan: []string{"/a", "/b", "/c", "/d"},
+		},
+		{
+			name:        "resume after a complete run runs nothing",
+			sources:     []string{"/a", "/b", "/c", "/d"},
+			options:     resumeOptions,
+			expectedRan: []string{},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			ran := map[string]bool{}
+
+			runResult, err := ExecuteFolderJobsWithCheckpoint("/config.yaml", newJobs(c.sources, ran, c.failing), 1, c.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ran) != len(c.expectedRan) {
+				t.Errorf("unexpected folders ran: %v, expected %v", ran, c.expectedRan)
+			}
+			for _, source := range c.expectedRan {
+				if !ran[source] {
+					t.Errorf("expected folder to run: %s", source)
+				}
+			}
+
+			if len(runResult.Folders) != len(c.sources) {
+				t.Errorf("unexpected number of results: %d", len(runResult.Folders))
+			}
+
+			if len(runResult.FailedFolders()) != len(c.failing) {
+				t.Errorf("unexpected number of failed folders: %v", runResult.FailedFolders())
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package runbackup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+// FolderJob is the backup of a single source folder to a destination folder.
+type FolderJob struct {
+	Source string
+	Dest   string
+
+	// Run performs the backup; outputPrefix is non-empty if jobs are running concurrently, and should be used to prefix output.
+	Run func(outputPrefix string) error
+}
+
+// ExecuteFolderJobs runs the folder jobs, with at most 'concurrency' jobs running at once. On failure of a job, the remaining jobs
+// are still run. The result contains an entry for each job, in the same order as the input.
+//
+// When running concurrently, jobs are grouped by the device of their source folder: jobs on the same device are run
+// one-at-a-time, to avoid thrashing a single disk.
+func ExecuteFolderJobs(jobs []FolderJob, concurrency int) RunResult {
+
+	results := make([]FolderResult, len(jobs))
+
+	if concurrency <= 1 {
+		for index, job := range jobs {
+			results[index] = runFolder(job.Source, job.Dest, "", func() error { return job.Run("") })
+		}
+		return RunResult{Folders: results}
+	}
+
+	groups := groupJobIndicesBySourceDevice(jobs)
+	outputPrefixes := jobOutputPrefixes(jobs)
+
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for _, group := range groups {
+
+		wg.Add(1)
+		go func(group []int) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			for _, index := range group {
+				job := jobs[index]
+				outputPrefix := outputPrefixes[index]
+				results[index] = runFolder(job.Source, job.Dest, outputPrefix, func() error { return job.Run(outputPrefix) })
+			}
+		}(group)
+	}
+
+	wg.Wait()
+
+	return RunResult{Folders: results}
+}
+
+// sourceDeviceID returns the device of a source folder; a variable so that tests can simulate sources on different devices.
+var sourceDeviceID = util.DeviceID
+
+// groupJobIndicesBySourceDevice returns the indices of the jobs, grouped by source device, in order of first appearance.
+// If the device of a source folder cannot be determined, that job is placed in a group by itself.
+func groupJobIndicesBySourceDevice(jobs []FolderJob) [][]int {
+
+	groups := [][]int{}
+
+	// device id -> index into groups
+	deviceToGroup := map[string]int{}
+
+	for index, job := range jobs {
+
+		deviceID, err := sourceDeviceID(job.Source)
+		if err != nil {
+			fmt.Println("Warning: unable to determine device of source folder:", err)
+			groups = append(groups, []int{index})
+			continue
+		}
+
+		if groupIndex, exists := deviceToGroup[deviceID]; exists {
+			groups[groupIndex] = append(groups[groupIndex], index)
+			continue
+		}
+
+		deviceToGroup[deviceID] = len(groups)
+		groups = append(groups, []int{index})
+	}
+
+	return groups
+}
+
+// jobOutputPrefixes returns the output prefix of each job: the name of its source folder, with as many parent folders as are
+// needed to distinguish it from the other jobs (e.g. '[a/data] ' and '[b/data] '). If the source folders are still not
+// distinguished (e.g. the same source is used twice), the job number is appended.
+func jobOutputPrefixes(jobs []FolderJob) []string {
+
+	elements := make([][]string, len(jobs))
+	for index, job := range jobs {
+		elements[index] = strings.FieldsFunc(filepath.ToSlash(filepath.Clean(job.Source)), func(r rune) bool { return r == '/' })
+	}
+
+	names := make([]string, len(jobs))
+
+	// The number of trailing path elements used for each job, increased until the names are unique
+	lengths := make([]int, len(jobs))
+	for index := range lengths {
+		lengths[index] = 1
+	}
+
+	for {
+		for index := range jobs {
+			names[index] = strings.Join(elements[index][max(len(elements[index])-lengths[index], 0):], "/")
+			if names[index] == "" {
+				// A root folder
+				names[index] = jobs[index].Source
+			}
+		}
+
+		// name -> indices of the jobs with that name
+		nameToIndices := map[string][]int{}
+		for index, name := range names {
+			nameToIndices[name] = append(nameToIndices[name], index)
+		}
+
+		extended := false
+		for _, indices := range nameToIndices {
+			if len(indices) == 1 {
+				continue
+			}
+			for _, index := range indices {
+				if lengths[index] < len(elements[index]) {
+					lengths[index]++
+					extended = true
+				}
+			}
+		}
+
+		if !extended {
+			break
+		}
+	}
+
+	nameCount := map[string]int{}
+	for _, name := range names {
+		nameCount[name]++
+	}
+
+	res := make([]string, len(jobs))
+	for index, name := range names {
+		if nameCount[name] > 1 {
+			name = fmt.Sprintf("%s #%d", name, index+1)
+		}
+		res[index] = "[" + name + "] "
+	}
+
+	return res
+}
@@ -0,0 +1,284 @@
+package runbackup
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+// withSourceDevices replaces the device lookup of source folders with the given source -> device map, for the duration of the test.
+func withSourceDevices(t *testing.T, devices map[string]string) {
+	original := sourceDeviceID
+	sourceDeviceID = func(path string) (string, error) {
+		device, exists := devices[path]
+		if !exists {
+			return "", fmt.Errorf("unknown source '%s'", path)
+		}
+		return device, nil
+	}
+	t.Cleanup(func() { sourceDeviceID = original })
+}
+
+func TestExecuteFolderJobs(t *testing.T) {
+
+	withSourceDevices(t, map[string]string{"/a/data": "1", "/b/data": "2", "/c/data": "3", "/d/data": "4"})
+
+	for _, c := range []struct {
+		name           string
+		concurrency    int
+		failingJobs    map[int]bool
+		expectedFailed int
+	}{
+		{
+			name:           "serial, no failures",
+			concurrency:    1,
+			failingJobs:    map[int]bool{},
+			expectedFailed: 0,
+		},
+		{
+			name:           "serial, failure does not stop remaining jobs",
+			concurrency:    1,
+			failingJobs:    map[int]bool{0: true},
+			expectedFailed: 1,
+		},
+		{
+			name:           "concurrent, multiple failures",
+			concurrency:    3,
+			failingJobs:    map[int]bool{1: true, 3: true},
+			expectedFailed: 2,
+		},
+	} {
+
+		t.Run(c.name, func(t *testing.T) {
+
+			sources := []string{"/a/data", "/b/data", "/c/data", "/d/data"}
+			ran := make([]bool, len(sources))
+
+			jobs := []FolderJob{}
+			for index, source := range sources {
+				jobs = append(jobs, FolderJob{
+					Source: source,
+					Dest:   fmt.Sprintf("dest-%d", index),
+					Run: func(outputPrefix string) error {
+						ran[index] = true
+						if c.failingJobs[index] {
+							return fmt.Errorf("job %d failed", index)
+						}
+						return nil
+					},
+				})
+			}
+
+			result := ExecuteFolderJobs(jobs, c.concurrency)
+
+			if len(result.Folders) != len(jobs) {
+				t.Fatalf("unexpected number of results: %v", len(result.Folders))
+			}
+
+			for index, folder := range result.Folders {
+				if !ran[index] {
+					t.Errorf("job %d did not run", index)
+				}
+
+				// Results should be in the same order as the jobs
+				if folder.Dest != jobs[index].Dest {
+					t.Errorf("result %d does not match job: %v %v", index, folder.Dest, jobs[index].Dest)
+				}
+
+				if (folder.Status == FolderStatusFailed) != c.failingJobs[index] {
+					t.Errorf("unexpected status for job %d: %v", index, folder.Status)
+				}
+			}
+
+			if len(result.FailedFolders()) != c.expectedFailed {
+				t.Errorf("unexpected number of failed folders: %v", len(result.FailedFolders()))
+			}
+
+			if (result.Error() != nil) != (c.expectedFailed > 0) {
+				t.Errorf("unexpected error value: %v", result.Error())
+			}
+		})
+	}
+}
+
+func TestExecuteFolderJobsByDevice(t *testing.T) {
+
+	// Two sources on each device
+	withSourceDevices(t, map[string]string{"/a/data": "1", "/a/photos": "1", "/b/data": "2", "/b/photos": "2"})
+	sources := []string{"/a/data", "/a/photos", "/b/data", "/b/photos"}
+
+	var mutex sync.Mutex
+	activeByDevice := map[string]int{}
+	maxActiveByDevice := map[string]int{}
+
+	// The first job of each device waits for the first job of the other device to start, so the test fails (rather than
+	// passing by chance) if the devices are not backed up concurrently
+	firstJobsStarted := sync.WaitGroup{}
+	firstJobsStarted.Add(2)
+
+	out := &bytes.Buffer{}
+	prefixes := make([]string, len(sources))
+	overlapped := make([]bool, len(sources))
+
+	jobs := []FolderJob{}
+	for index, source := range sources {
+		device := map[string]string{"/a": "1", "/b": "2"}[source[:2]]
+
+		jobs = append(jobs, FolderJob{
+			Source: source,
+			Dest:   "/dest" + source,
+			Run: func(outputPrefix string) error {
+				prefixes[index] = outputPrefix
+
+				mutex.Lock()
+				activeByDevice[device]++
+				maxActiveByDevice[device] = max(maxActiveByDevice[device], activeByDevice[device])
+				mutex.Unlock()
+
+				defer func() {
+					mutex.Lock()
+					activeByDevice[device]--
+					mutex.Unlock()
+				}()
+
+				if index == 0 || index == 2 {
+					firstJobsStarted.Done()
+					overlapped[index] = waitTimeout(&firstJobsStarted, 5*time.Second)
+				}
+
+				pw := util.NewPrefixWriter(out, outputPrefix)
+				for line := 0; line < 20; line++ {
+					fmt.Fprintf(pw, "line %d\n", line)
+				}
+				return pw.Flush()
+			},
+		})
+	}
+
+	result := ExecuteFolderJobs(jobs, 2)
+	if err := result.Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !overlapped[0] || !overlapped[2] {
+		t.Errorf("jobs on different devices did not run concurrently")
+	}
+
+	for device, maxActive := range maxActiveByDevice {
+		if maxActive != 1 {
+			t.Errorf("%d jobs ran concurrently on device %s", maxActive, device)
+		}
+	}
+
+	expectedPrefixes := []string{"[a/data] ", "[a/photos] ", "[b/data] ", "[b/photos] "}
+	if !reflect.DeepEqual(prefixes, expectedPrefixes) {
+		t.Errorf("unexpected output prefixes: %q", prefixes)
+	}
+
+	// Each line of the (interleaved) output is complete, and has the prefix of the job that wrote it
+	linesByPrefix := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		found := false
+		for _, prefix := range expectedPrefixes {
+			if strings.HasPrefix(line, prefix+"line ") {
+				linesByPrefix[prefix]++
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("unexpected output line: '%s'", line)
+		}
+	}
+
+	for _, prefix := range expectedPrefixes {
+		if linesByPrefix[prefix] != 20 {
+			t.Errorf("unexpected number of lines with prefix '%s': %d", prefix, linesByPrefix[prefix])
+		}
+	}
+}
+
+// waitTimeout returns true if the wait group completes before the timeout.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestGroupJobIndicesBySourceDevice(t *testing.T) {
+
+	withSourceDevices(t, map[string]string{"/a": "1", "/b": "2", "/c": "1", "/d": "3", "/e": "2"})
+
+	jobs := []FolderJob{}
+	for _, source := range []string{"/a", "/b", "/c", "/unknown", "/d", "/e"} {
+		jobs = append(jobs, FolderJob{Source: source})
+	}
+
+	// Groups are in order of first appearance; a source whose device cannot be determined is in a group by itself
+	expected := [][]int{{0, 2}, {1, 5}, {3}, {4}}
+
+	if groups := groupJobIndicesBySourceDevice(jobs); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("unexpected groups: %v, expected %v", groups, expected)
+	}
+}
+
+func TestJobOutputPrefixes(t *testing.T) {
+
+	for _, c := range []struct {
+		name     string
+		sources  []string
+		expected []string
+	}{
+		{
+			name:     "distinct names",
+			sources:  []string{"/a/data", "/b/photos"},
+			expected: []string{"[data] ", "[photos] "},
+		},
+		{
+			name:     "shared name",
+			sources:  []string{"/a/data", "/b/data", "/b/photos"},
+			expected: []string{"[a/data] ", "[b/data] ", "[photos] "},
+		},
+		{
+			name:     "shared parent and name",
+			sources:  []string{"/x/a/data", "/y/a/data", "/a/data2"},
+			expected: []string{"[x/a/data] ", "[y/a/data] ", "[data2] "},
+		},
+		{
+			name:     "one source is the suffix of another",
+			sources:  []string{"/data", "/a/data"},
+			expected: []string{"[data] ", "[a/data] "},
+		},
+		{
+			name:     "same source",
+			sources:  []string{"/a/data", "/a/data"},
+			expected: []string{"[a/data #1] ", "[a/data #2] "},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			jobs := []FolderJob{}
+			for _, source := range c.sources {
+				jobs = append(jobs, FolderJob{Source: source})
+			}
+
+			if prefixes := jobOutputPrefixes(jobs); !reflect.DeepEqual(prefixes, c.expected) {
+				t.Errorf("unexpected prefixes: %q, expected %q", prefixes, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package runbackup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+// LastRun is the result of the most recent 'backup' of a config file, as reported by 'status'.
+type LastRun struct {
+	ConfigPath string    `json:"configPath"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+
+	// LastSuccessTime is the start time of the most recent successful backup (which may be an earlier run than this one), or
+	// zero if no successful backup has been recorded
+	LastSuccessTime time.Time `json:"lastSuccessTime,omitempty"`
+}
+
+// RecordLastRun records the result of a backup of the config file, which started at startTime and failed with runErr (if
+// non-nil). A backup that could not start because the config file was locked by another backup is not recorded, as that
+// other backup's result is recorded instead.
+func RecordLastRun(configFilePath string, startTime time.Time, runErr error) error {
+
+	var exitCodeErr *util.ExitCodeError
+	if errors.As(runErr, &exitCodeErr) && exitCodeErr.Code == util.ExitCodeLocked {
+		return nil
+	}
+
+	lastRunFilePath, err := util.ConfigStateFilePath("runs", configFilePath)
+	if err != nil {
+		return err
+	}
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	lastRun := LastRun{
+		ConfigPath: absConfigFilePath,
+		StartTime:  startTime,
+		EndTime:    time.Now(),
+		Success:    runErr == nil,
+	}
+
+	if runErr != nil {
+		lastRun.Error = runErr.Error()
+
+		// Keep the time of the most recent successful backup
+		if previousRun, err := ReadLastRun(configFilePath); err == nil {
+			lastRun.LastSuccessTime = previousRun.LastSuccessTime
+			if previousRun.Success && lastRun.LastSuccessTime.IsZero() {
+				lastRun.LastSuccessTime = previousRun.StartTime
+			}
+		}
+	} else {
+		lastRun.LastSuccessTime = startTime
+	}
+
+	return util.WriteStateFile(lastRunFilePath, lastRun)
+}
+
+// ReadLastRun returns the result of the most recent backup of the config file; if the config file has not been backed up,
+// the returned error satisfies errors.Is(err, os.ErrNotExist).
+func ReadLastRun(configFilePath string) (LastRun, error) {
+
+	lastRunFilePath, err := util.ConfigStateFilePath("runs", configFilePath)
+	if err != nil {
+		return LastRun{}, err
+	}
+
+	content, err := os.ReadFile(lastRunFilePath)
+	if err != nil {
+		return LastRun{}, err
+	}
+
+	lastRun := LastRun{}
+	if err := json.Unmarshal(content, &lastRun); err != nil {
+		return LastRun{}, fmt.Errorf("unable to parse last run '%s': %w", lastRunFilePath, err)
+	}
+
+	return lastRun, nil
+}
+
+// LastSuccessfulBackupTime returns the start time of the most recent successful backup of the config file, as recorded by
+// RecordLastRun or, if that has not been recorded, the time of the newest backup manifest. If no successful backup has been
+// recorded, the zero time is returned.
+func LastSuccessfulBackupTime(configFilePath string) (time.Time, error) {
+
+	lastRun, err := ReadLastRun(configFilePath)
+	if err == nil {
+		if !lastRun.LastSuccessTime.IsZero() {
+			return lastRun.LastSuccessTime, nil
+		}
+		if lastRun.Success {
+			return lastRun.StartTime, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, err
+	}
+
+	manifests, err := ListManifests(configFilePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(manifests) == 0 {
+		return time.Time{}, nil
+	}
+
+	return manifests[len(manifests)-1].Time, nil
+}
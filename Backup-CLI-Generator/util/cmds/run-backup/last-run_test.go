@@ -0,0 +1,68 @@
+package runbackup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestLastSuccessfulBackupTime(t *testing.T) {
+
+	t.Setenv(util.StateDirEnvVar, t.TempDir())
+
+	configFilePath := "/config.yaml"
+
+	expectLastSuccess := func(expected time.Time) {
+		t.Helper()
+		lastSuccess, err := LastSuccessfulBackupTime(configFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !lastSuccess.Equal(expected) {
+			t.Errorf("unexpected last successful backup time: %v, expected %v", lastSuccess, expected)
+		}
+	}
+
+	// No backup recorded
+	expectLastSuccess(time.Time{})
+
+	// A manifest is used if no run is recorded
+	if err := RecordManifest(configFilePath, []string{t.TempDir()}, util.NewExcludeMatcher(nil)); err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := ListManifests(configFilePath)
+	if err != nil || len(manifests) != 1 {
+		t.Fatalf("unexpected manifests: %v %v", manifests, err)
+	}
+	expectLastSuccess(manifests[0].Time)
+
+	successStart := time.Now().Add(-2 * time.Hour).Round(0)
+	if err := RecordLastRun(configFilePath, successStart, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectLastSuccess(successStart)
+
+	// Failed runs keep the time of the last successful run
+	for i := 0; i < 2; i++ {
+		if err := RecordLastRun(configFilePath, time.Now().Add(-time.Hour), errors.New("failure")); err != nil {
+			t.Fatal(err)
+		}
+		expectLastSuccess(successStart)
+	}
+
+	lastRun, err := ReadLastRun(configFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastRun.Success {
+		t.Errorf("last run should be the failed run: %v", lastRun)
+	}
+
+	nextSuccessStart := time.Now().Round(0)
+	if err := RecordLastRun(configFilePath, nextSuccessStart, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectLastSuccess(nextSuccessStart)
+}
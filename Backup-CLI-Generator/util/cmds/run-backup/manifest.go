@@ -0,0 +1,286 @@
+package runbackup
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// Manifest is the list of files of the source folders of a mirror backend (robocopy, rclone), recorded after each successful
+// backup. Mirror backends only keep the latest copy of each file, so backups are compared ('diff') using their manifests.
+type Manifest struct {
+	ConfigPath string                  `json:"configPath"`
+	Time       time.Time               `json:"time"`
+	Folders    []string                `json:"folders"`
+	Files      map[string]ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file or folder of a manifest
+type ManifestFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir,omitempty"`
+}
+
+const (
+	// manifestIDFormat is the time format of manifest IDs, which are also their file names
+	manifestIDFormat = "20060102-150405"
+
+	manifestFileSuffix = ".json.gz"
+
+	// maxManifests is the number of manifests kept for each config file; older manifests are deleted
+	maxManifests = 10
+)
+
+// RecordManifest walks the source folders of the config file (skipping excluded files and folders), and records the size and
+// modification time of each file and folder as a new manifest.
+func RecordManifest(configFilePath string, sourceFolders []string, matcher util.ExcludeMatcher) error {
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		ConfigPath: absConfigFilePath,
+		Time:       time.Now(),
+		Folders:    sourceFolders,
+		Files:      map[string]ManifestFile{},
+	}
+
+	for _, sourceFolder := range sourceFolders {
+
+		if err := matcher.WalkDir(sourceFolder, func(path string, dirEntry fs.DirEntry) error {
+
+			info, err := dirEntry.Info()
+			if err != nil {
+				fmt.Println("Warning: unable to read:", path, err)
+				return nil
+			}
+
+			file := ManifestFile{ModTime: info.ModTime(), IsDir: dirEntry.IsDir()}
+			if !file.IsDir {
+				file.Size = info.Size()
+			}
+
+			manifest.Files[path] = file
+
+			return nil
+
+		}); err != nil {
+			return err
+		}
+	}
+
+	manifestDir, err := util.ConfigStateDir("manifests", configFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err := writeManifest(filepath.Join(manifestDir, manifestID(manifest.Time)+manifestFileSuffix), manifest); err != nil {
+		return err
+	}
+
+	return pruneManifests(manifestDir)
+}
+
+// ListManifests returns the recorded manifests of the config file as snapshots, oldest first.
+func ListManifests(configFilePath string) ([]model.Snapshot, error) {
+
+	manifestDir, err := util.ConfigStateDir("manifests", configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := listManifestIDs(manifestDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []model.Snapshot{}
+
+	for _, id := range ids {
+
+		manifestTime, err := time.ParseInLocation(manifestIDFormat, id, time.UTC)
+		if err != nil {
+			continue
+		}
+
+		res = append(res, model.Snapshot{ID: id, Time: manifestTime})
+	}
+
+	return res, nil
+}
+
+// ReadManifest returns the recorded manifest of the config file with the given ID.
+func ReadManifest(configFilePath string, id string) (Manifest, error) {
+
+	manifestDir, err := util.ConfigStateDir("manifests", configFilePath)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	file, err := os.Open(filepath.Join(manifestDir, id+manifestFileSuffix))
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("unable to read manifest '%s': %w", id, err)
+	}
+	defer reader.Close()
+
+	manifest := Manifest{}
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("unable to read manifest '%s': %w", id, err)
+	}
+
+	return manifest, nil
+}
+
+// DiffManifestSnapshots compares two recorded manifests of the config file: those identified by the options, or by default the
+// previous and latest manifests.
+func DiffManifestSnapshots(configFilePath string, options model.DiffOptions) ([]model.SnapshotDiff, error) {
+
+	snapshots, err := ListManifests(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := model.SelectDiffSnapshots(snapshots, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w (a manifest is recorded after each successful backup)", err)
+	}
+
+	fromManifest, err := ReadManifest(configFilePath, from.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	toManifest, err := ReadManifest(configFilePath, to.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	from.Paths, to.Paths = fromManifest.Folders, toManifest.Folders
+
+	return []model.SnapshotDiff{{From: from, To: to, Changes: DiffManifests(fromManifest, toManifest)}}, nil
+}
+
+// DiffManifests returns the files and folders that were added or removed between the two manifests, and the files whose size or
+// modification time changed, sorted by path.
+func DiffManifests(from Manifest, to Manifest) []model.DiffEntry {
+
+	res := []model.DiffEntry{}
+
+	for path, toFile := range to.Files {
+
+		fromFile, exists := from.Files[path]
+
+		if !exists {
+			res = append(res, model.DiffEntry{Path: path, Change: model.DiffAdded, IsDir: toFile.IsDir, Size: toFile.Size})
+
+		} else if fromFile.IsDir != toFile.IsDir ||
+			(!toFile.IsDir && (fromFile.Size != toFile.Size || !fromFile.ModTime.Equal(toFile.ModTime))) {
+
+			res = append(res, model.DiffEntry{Path: path, Change: model.DiffModified, IsDir: toFile.IsDir, Size: toFile.Size, OldSize: fromFile.Size})
+		}
+	}
+
+	for path, fromFile := range from.Files {
+		if _, exists := to.Files[path]; !exists {
+			res = append(res, model.DiffEntry{Path: path, Change: model.DiffRemoved, IsDir: fromFile.IsDir, Size: fromFile.Size})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+
+	return res
+}
+
+func manifestID(manifestTime time.Time) string {
+	return manifestTime.UTC().Format(manifestIDFormat)
+}
+
+// writeManifest writes the manifest as gzipped JSON to a temporary file, then renames it.
+func writeManifest(manifestFilePath string, manifest Manifest) error {
+
+	tempFilePath := manifestFilePath + ".tmp"
+
+	file, err := os.OpenFile(tempFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(file)
+
+	if err := json.NewEncoder(writer).Encode(manifest); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilePath, manifestFilePath)
+}
+
+// listManifestIDs returns the IDs of the manifests in the directory, oldest first.
+func listManifestIDs(manifestDir string) ([]string, error) {
+
+	entries, err := os.ReadDir(manifestDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), manifestFileSuffix) {
+			res = append(res, strings.TrimSuffix(entry.Name(), manifestFileSuffix))
+		}
+	}
+
+	// IDs are times, formatted such that they sort chronologically
+	sort.Strings(res)
+
+	return res, nil
+}
+
+func pruneManifests(manifestDir string) error {
+
+	ids, err := listManifestIDs(manifestDir)
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > maxManifests {
+
+		if err := os.Remove(filepath.Join(manifestDir, ids[0]+manifestFileSuffix)); err != nil {
+			return err
+		}
+
+		ids = ids[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package runbackup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestDiffManifests(t *testing.T) {
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	from := Manifest{Files: map[string]ManifestFile{
+		"/src/unchanged.txt": {Size: 10, ModTime: older},
+		"/src/resized.txt":   {Size: 10, ModTime: older},
+		"/src/touched.txt":   {Size: 10, ModTime: older},
+		"/src/removed.txt":   {Size: 5, ModTime: older},
+		"/src/folder":        {IsDir: true, ModTime: older},
+		"/src/old-folder":    {IsDir: true, ModTime: older},
+	}}
+
+	to := Manifest{Files: map[string]ManifestFile{
+		"/src/unchanged.txt": {Size: 10, ModTime: older},
+		"/src/resized.txt":   {Size: 20, ModTime: older},
+		"/src/touched.txt":   {Size: 10, ModTime: newer},
+		"/src/added.txt":     {Size: 7, ModTime: newer},
+		"/src/folder":        {IsDir: true, ModTime: newer},
+		"/src/new-folder":    {IsDir: true, ModTime: newer},
+	}}
+
+	expected := []model.DiffEntry{
+		{Path: "/src/added.txt", Change: model.DiffAdded, Size: 7},
+		{Path: "/src/new-folder", Change: model.DiffAdded, IsDir: true},
+		{Path: "/src/old-folder", Change: model.DiffRemoved, IsDir: true},
+		{Path: "/src/removed.txt", Change: model.DiffRemoved, Size: 5},
+		{Path: "/src/resized.txt", Change: model.DiffModified, Size: 20, OldSize: 10},
+		{Path: "/src/touched.txt", Change: model.DiffModified, Size: 10, OldSize: 10},
+	}
+
+	actual := DiffManifests(from, to)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
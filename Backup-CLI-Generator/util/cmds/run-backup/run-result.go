@@ -0,0 +1,121 @@
+package runbackup
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+type FolderStatus string
+
+const (
+	FolderStatusSuccess FolderStatus = "OK"
+	FolderStatusFailed  FolderStatus = "FAILED"
+
+	// FolderStatusSkipped: the folder was not backed up, as it was completed by a previous run (see 'backup --resume')
+	FolderStatusSkipped FolderStatus = "SKIPPED"
+)
+
+// FolderResult records the outcome of backing up a single source/destination folder pair.
+type FolderResult struct {
+	Source   string
+	Dest     string
+	Status   FolderStatus
+	Duration time.Duration
+	Err      error
+}
+
+// RunResult is the list of folder results for a single backup run, in the order the folders were processed.
+type RunResult struct {
+	Folders []FolderResult
+}
+
+// runFolder invokes fn for the given source/destination pair, and returns the status, duration and error.
+func runFolder(source string, dest string, outputPrefix string, fn func() error) FolderResult {
+
+	start := time.Now()
+
+	err := fn()
+
+	folderResult := FolderResult{
+		Source:   source,
+		Dest:     dest,
+		Status:   FolderStatusSuccess,
+		Duration: time.Since(start).Round(time.Second),
+		Err:      err,
+	}
+
+	if err != nil {
+		fmt.Println(outputPrefix+"ERROR:", err)
+		folderResult.Status = FolderStatusFailed
+	}
+
+	return folderResult
+}
+
+// FailedFolders returns the folder results that failed.
+func (r RunResult) FailedFolders() []FolderResult {
+	res := []FolderResult{}
+	for _, folder := range r.Folders {
+		if folder.Status == FolderStatusFailed {
+			res = append(res, folder)
+		}
+	}
+	return res
+}
+
+// PrintSummary outputs a table containing the result of each folder pair.
+func (r RunResult) PrintSummary(out io.Writer) {
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "-------------------------------------------------------------------")
+	fmt.Fprintln(out, "Summary:")
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tDURATION\tSOURCE\tDESTINATION\tERROR")
+	for _, folder := range r.Folders {
+		errStr := ""
+		if folder.Err != nil {
+			errStr = folder.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%s\t%s\t%s\n", folder.Status, folder.Duration, folder.Source, folder.Dest, errStr)
+	}
+	tw.Flush()
+
+	skipped := 0
+	for _, folder := range r.Folders {
+		if folder.Status == FolderStatusSkipped {
+			skipped++
+		}
+	}
+
+	fmt.Fprintln(out)
+	if skipped > 0 {
+		fmt.Fprintf(out, "%d of %d folder(s) succeeded (%d skipped, as completed by a previous run)\n", len(r.Folders)-len(r.FailedFolders()), len(r.Folders), skipped)
+	} else {
+		fmt.Fprintf(out, "%d of %d folder(s) succeeded\n", len(r.Folders)-len(r.FailedFolders()), len(r.Folders))
+	}
+
+}
+
+// Error returns nil if all folders succeeded, otherwise an error that requests the folder failure exit code.
+func (r RunResult) Error() error {
+	return r.FailureError("backup")
+}
+
+// FailureError is Error, for folder jobs that perform an action other than a backup (such as 'check').
+func (r RunResult) FailureError(action string) error {
+
+	failed := r.FailedFolders()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &util.ExitCodeError{
+		Code: util.ExitCodeFolderFailure,
+		Err:  fmt.Errorf("%d of %d folder(s) failed to %s", len(failed), len(r.Folders), action),
+	}
+}
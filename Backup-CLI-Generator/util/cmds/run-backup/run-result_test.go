@@ -0,0 +1,116 @@
+package runbackup
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestRunResult(t *testing.T) {
+
+	succeeded := func(source string) FolderResult {
+		return FolderResult{Source: source, Dest: "/dest" + source, Status: FolderStatusSuccess, Duration: time.Second}
+	}
+
+	failed := func(source string) FolderResult {
+		return FolderResult{Source: source, Dest: "/dest" + source, Status: FolderStatusFailed, Duration: time.Second, Err: errors.New("copy failed: " + source)}
+	}
+
+	skipped := func(source string) FolderResult {
+		return FolderResult{Source: source, Dest: "/dest" + source, Status: FolderStatusSkipped}
+	}
+
+	for _, c := range []struct {
+		name             string
+		folders          []FolderResult
+		expectedError    string
+		expectedExitCode int
+		expectedSummary  []string
+	}{
+		{
+			name:             "all succeeded",
+			folders:          []FolderResult{succeeded("/a"), succeeded("/b")},
+			expectedExitCode: 0,
+			expectedSummary:  []string{"2 of 2 folder(s) succeeded"},
+		},
+		{
+			name:             "mixed results",
+			folders:          []FolderResult{succeeded("/a"), failed("/b"), succeeded("/c")},
+			expectedError:    "1 of 3 folder(s) failed to backup",
+			expectedExitCode: util.ExitCodeFolderFailure,
+			expectedSummary:  []string{"2 of 3 folder(s) succeeded", "copy failed: /b"},
+		},
+		{
+			name:             "all failed",
+			folders:          []FolderResult{failed("/a"), failed("/b")},
+			expectedError:    "2 of 2 folder(s) failed to backup",
+			expectedExitCode: util.ExitCodeFolderFailure,
+			expectedSummary:  []string{"0 of 2 folder(s) succeeded", "copy failed: /a", "copy failed: /b"},
+		},
+		{
+			name:             "skipped and failed",
+			folders:          []FolderResult{skipped("/a"), failed("/b")},
+			expectedError:    "1 of 2 folder(s) failed to backup",
+			expectedExitCode: util.ExitCodeFolderFailure,
+			expectedSummary:  []string{"1 of 2 folder(s) succeeded (1 skipped, as completed by a previous run)"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			result := RunResult{Folders: c.folders}
+
+			err := result.Error()
+			if c.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else {
+				if err == nil || err.Error() != c.expectedError {
+					t.Fatalf("unexpected error: %v, expected '%s'", err, c.expectedError)
+				}
+				if code := util.ExitCodeFor(err); code != c.expectedExitCode {
+					t.Errorf("unexpected exit code: %d, expected %d", code, c.expectedExitCode)
+				}
+			}
+
+			out := &bytes.Buffer{}
+			result.PrintSummary(out)
+			summary := out.String()
+
+			for _, expected := range c.expectedSummary {
+				if !strings.Contains(summary, expected) {
+					t.Errorf("summary does not contain '%s':\n%s", expected, summary)
+				}
+			}
+
+			// Every folder has a row in the summary, with its status
+			for _, folder := range c.folders {
+				found := false
+				for _, line := range strings.Split(summary, "\n") {
+					if strings.HasPrefix(line, string(folder.Status)) && strings.Contains(line, folder.Source) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("summary has no %s row for '%s':\n%s", folder.Status, folder.Source, summary)
+				}
+			}
+		})
+	}
+}
+
+func TestRunResultFailureError(t *testing.T) {
+
+	result := RunResult{Folders: []FolderResult{
+		{Source: "/a", Status: FolderStatusFailed, Err: errors.New("failure")},
+	}}
+
+	err := result.FailureError("check")
+	if err == nil || err.Error() != "1 of 1 folder(s) failed to check" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
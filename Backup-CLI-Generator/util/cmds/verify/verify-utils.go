@@ -0,0 +1,261 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util/cmds/analyze"
+)
+
+const (
+	// SampleRandom samples files uniformly from all of the folders of the config file
+	SampleRandom = "random"
+
+	// SampleStratified samples (up to) the same number of files from each folder of the config file, so that small folders are
+	// not crowded out by large ones
+	SampleStratified = "stratified"
+)
+
+// SourceFile is a file of a folder of the config file, that was sampled for verification
+type SourceFile struct {
+	Path   string
+	Folder string
+}
+
+type FileStatus string
+
+const (
+	// FileStatusOK: the restored file matches the live file
+	FileStatusOK FileStatus = "OK"
+
+	// FileStatusChanged: the live file was created or modified since the backup, so it is not expected to match
+	FileStatusChanged FileStatus = "CHANGED SINCE BACKUP"
+
+	// FileStatusMismatch: the restored file differs from the live file, which was not modified since the backup
+	FileStatusMismatch FileStatus = "MISMATCH"
+
+	// FileStatusMissing: the live file predates the backup, but was not restored
+	FileStatusMissing FileStatus = "MISSING"
+
+	// FileStatusError: the file could not be compared
+	FileStatusError FileStatus = "ERROR"
+)
+
+// FileResult is the result of the verification of a single sampled file
+type FileResult struct {
+	Path   string
+	Status FileStatus
+	Detail string
+}
+
+// IsFailure returns true if the result indicates that the backup does not contain the file as it should.
+func (fr FileResult) IsFailure() bool {
+	return fr.Status == FileStatusMismatch || fr.Status == FileStatusMissing || fr.Status == FileStatusError
+}
+
+// SampleSourceFiles returns a sample of up to sampleSize regular files from the folders of the config file, skipping the files and
+// folders that are not backed up: those that match the global, folder, or robocopy excludes of the config file.
+func SampleSourceFiles(configFilePath string, config model.ConfigFile, sampleSize int, strategy string, rng *rand.Rand) ([]SourceFile, error) {
+
+	if strategy != SampleRandom && strategy != SampleStratified {
+		return nil, fmt.Errorf("unrecognized sample strategy '%s': expected '%s' or '%s'", strategy, SampleRandom, SampleStratified)
+	}
+
+	if len(config.Folders) == 0 || sampleSize <= 0 {
+		return nil, nil
+	}
+
+	// The same excludes as are reported by 'coverage'
+	sources, err := analyze.NewCoverageSources(configFilePath, config, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	perFolderSize := sampleSize
+	if strategy == SampleStratified {
+		perFolderSize = (sampleSize + len(config.Folders) - 1) / len(config.Folders)
+	}
+
+	// With the random strategy, a single reservoir is shared by all folders
+	shared := newReservoir(sampleSize, rng)
+
+	res := []SourceFile{}
+
+	for _, source := range sources {
+
+		sample := shared
+		if strategy == SampleStratified {
+			sample = newReservoir(perFolderSize, rng)
+		}
+
+		if err := source.Matcher.WalkDir(source.Folder, func(path string, dirEntry fs.DirEntry) error {
+			if dirEntry.Type().IsRegular() {
+				sample.add(SourceFile{Path: path, Folder: source.Folder})
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		if strategy == SampleStratified {
+			res = append(res, sample.files...)
+		}
+	}
+
+	if strategy == SampleRandom {
+		res = shared.files
+	}
+
+	if len(res) > sampleSize {
+		// Stratified samples of many folders may round up past the sample size
+		rng.Shuffle(len(res), func(i, j int) { res[i], res[j] = res[j], res[i] })
+		res = res[0:sampleSize]
+	}
+
+	return res, nil
+}
+
+// SelectBackupSnapshots returns the selector that restores the newest snapshot of each of the source folders, and that snapshot
+// for each folder (keyed by source folder). The snapshots should be those taken by the config file (see model.ConfigSnapshots),
+// whose metadata name (if any) is name. If a single snapshot contains all of the folders (restic, tarsnap), it is selected by ID,
+// so that the snapshot restored is the one returned; otherwise (kopia, which snapshots each folder separately), the newest
+// snapshot of each folder is selected, by the tag of the config file if it has a name.
+func SelectBackupSnapshots(snapshots []model.Snapshot, sourceFolders []string, name string) (model.SnapshotSelector, map[string]model.Snapshot, error) {
+
+	latest, err := model.LatestSnapshotsByFolder(snapshots, sourceFolders)
+	if err != nil {
+		return model.SnapshotSelector{}, nil, err
+	}
+
+	folderSnapshots := map[string]model.Snapshot{}
+	for index, sourceFolder := range sourceFolders {
+		folderSnapshots[sourceFolder] = latest[index]
+	}
+
+	perFolderSelector := model.SnapshotSelector{Type: model.SnapshotSelectLatest}
+	if name != "" {
+		perFolderSelector = model.SnapshotSelector{Type: model.SnapshotSelectTag, Value: name}
+	}
+
+	if len(latest) == 0 {
+		return perFolderSelector, folderSnapshots, nil
+	}
+
+	for _, snapshot := range latest {
+		if snapshot.ID != latest[0].ID {
+			return perFolderSelector, folderSnapshots, nil
+		}
+	}
+
+	return model.SnapshotSelector{Type: model.SnapshotSelectID, Value: latest[0].ID}, folderSnapshots, nil
+}
+
+// CompareFile compares the live file with the copy restored from the backup, by SHA-256. If backupTime is non-zero, a live file
+// modified after backupTime is reported as changed since the backup; otherwise (for mirror backends, which have no snapshot time),
+// a live file is reported as changed if its modification time differs from that of the restored file.
+func CompareFile(file SourceFile, restoredPath string, backupTime time.Time) FileResult {
+
+	res := FileResult{Path: file.Path}
+
+	liveInfo, err := os.Stat(file.Path)
+	if err != nil {
+		res.Status, res.Detail = FileStatusError, err.Error()
+		return res
+	}
+
+	restoredInfo, err := os.Stat(restoredPath)
+	if err != nil && !os.IsNotExist(err) {
+		res.Status, res.Detail = FileStatusError, err.Error()
+		return res
+	}
+	restored := err == nil
+
+	changedSinceBackup := false
+	if !backupTime.IsZero() {
+		changedSinceBackup = liveInfo.ModTime().After(backupTime)
+	} else if restored {
+		changedSinceBackup = !liveInfo.ModTime().Truncate(time.Second).Equal(restoredInfo.ModTime().Truncate(time.Second))
+	}
+
+	if !restored {
+		if changedSinceBackup {
+			res.Status, res.Detail = FileStatusChanged, "created since the backup"
+		} else {
+			res.Status, res.Detail = FileStatusMissing, "not found in the backup"
+		}
+		return res
+	}
+
+	liveHash, err := HashFile(file.Path)
+	if err != nil {
+		res.Status, res.Detail = FileStatusError, err.Error()
+		return res
+	}
+
+	restoredHash, err := HashFile(restoredPath)
+	if err != nil {
+		res.Status, res.Detail = FileStatusError, err.Error()
+		return res
+	}
+
+	if liveHash == restoredHash {
+		res.Status = FileStatusOK
+	} else if changedSinceBackup {
+		res.Status, res.Detail = FileStatusChanged, "modified "+liveInfo.ModTime().Local().Format(time.DateTime)
+	} else {
+		res.Status, res.Detail = FileStatusMismatch, fmt.Sprintf("live %s, restored %s", liveHash[0:12], restoredHash[0:12])
+	}
+
+	return res
+}
+
+// HashFile returns the hex-encoded SHA-256 of the file content.
+func HashFile(path string) (string, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// reservoir is a uniform random sample of up to 'size' files, of a stream of files of unknown length (reservoir sampling), so
+// that large folder trees need not be held in memory.
+type reservoir struct {
+	size  int
+	seen  int
+	files []SourceFile
+	rng   *rand.Rand
+}
+
+func newReservoir(size int, rng *rand.Rand) *reservoir {
+	return &reservoir{size: size, rng: rng}
+}
+
+func (r *reservoir) add(file SourceFile) {
+
+	r.seen++
+
+	if len(r.files) < r.size {
+		r.files = append(r.files, file)
+		return
+	}
+
+	if index := r.rng.Intn(r.seen); index < r.size {
+		r.files[index] = file
+	}
+}
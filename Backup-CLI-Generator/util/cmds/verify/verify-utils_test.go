@@ -0,0 +1,240 @@
+package verify
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestSampleSourceFiles(t *testing.T) {
+
+	root := t.TempDir()
+
+	files := []string{
+		"big/a.txt", "big/b.txt", "big/c.txt", "big/d.txt", "big/e.txt", "big/f.tmp",
+		"big/node_modules/x.txt",
+		"small/g.txt",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := model.ConfigFile{
+		GlobalExcludes: []string{"*.tmp"},
+		Folders: []model.Folder{
+			{Path: filepath.Join(root, "big"), Excludes: []string{"node_modules/"}},
+			{Path: filepath.Join(root, "small")},
+		},
+		Credentials: []model.Credentials{
+			{Rclone: &model.RcloneCredentials{DestinationFolder: t.TempDir()}},
+		},
+	}
+
+	t.Run("excludes", func(t *testing.T) {
+
+		sample, err := SampleSourceFiles("/config.yaml", config, 100, SampleRandom, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sample) != 6 {
+			t.Errorf("expected all 6 unexcluded files, got %v", sample)
+		}
+
+		for _, file := range sample {
+			if strings.HasSuffix(file.Path, ".tmp") || strings.Contains(file.Path, "node_modules") {
+				t.Errorf("excluded file was sampled: %s", file.Path)
+			}
+		}
+	})
+
+	t.Run("stratified", func(t *testing.T) {
+
+		sample, err := SampleSourceFiles("/config.yaml", config, 2, SampleStratified, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sample) != 2 || sample[0].Folder == sample[1].Folder {
+			t.Errorf("expected one file from each folder, got %v", sample)
+		}
+	})
+
+	t.Run("robocopy excludes", func(t *testing.T) {
+
+		robocopyConfig := model.ConfigFile{
+			Folders: []model.Folder{
+				{Path: filepath.Join(root, "big")},
+				{Path: filepath.Join(root, "small")},
+			},
+			RobocopySettings: &model.RobocopySettings{
+				ExcludeFiles:   []string{"*.tmp", "g.txt"},
+				ExcludeFolders: []string{"node_modules"},
+			},
+			Credentials: []model.Credentials{
+				{Robocopy: &model.RobocopyCredentials{DestinationFolder: t.TempDir()}},
+			},
+		}
+
+		sample, err := SampleSourceFiles("/config.yaml", robocopyConfig, 100, SampleRandom, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(sample) != 5 {
+			t.Errorf("expected the 5 files that robocopy copies, got %v", sample)
+		}
+
+		for _, file := range sample {
+			if strings.HasSuffix(file.Path, ".tmp") || strings.Contains(file.Path, "node_modules") || strings.HasSuffix(file.Path, "g.txt") {
+				t.Errorf("file excluded by robocopy settings was sampled: %s", file.Path)
+			}
+		}
+	})
+}
+
+func TestCompareFile(t *testing.T) {
+
+	dir := t.TempDir()
+
+	backupTime := time.Now().Add(-time.Hour)
+	before := backupTime.Add(-time.Hour)
+
+	writeFile := func(name string, content string, modTime time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name       string
+		live       string
+		liveTime   time.Time
+		restored   *string
+		backupTime time.Time
+		expected   FileStatus
+	}{
+		{name: "match", live: "a", liveTime: before, restored: ptr("a"), backupTime: backupTime, expected: FileStatusOK},
+		{name: "mismatch", live: "a", liveTime: before, restored: ptr("b"), backupTime: backupTime, expected: FileStatusMismatch},
+		{name: "modified since backup", live: "a", liveTime: time.Now(), restored: ptr("b"), backupTime: backupTime, expected: FileStatusChanged},
+		{name: "missing", live: "a", liveTime: before, backupTime: backupTime, expected: FileStatusMissing},
+		{name: "created since backup", live: "a", liveTime: time.Now(), backupTime: backupTime, expected: FileStatusChanged},
+		{name: "mirror modified since backup", live: "a", liveTime: time.Now(), restored: ptr("b"), expected: FileStatusChanged},
+		{name: "mirror mismatch", live: "a", liveTime: before, restored: ptr("b"), expected: FileStatusMismatch},
+	}
+
+	for index, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			livePath := writeFile("live"+string(rune('0'+index)), test.live, test.liveTime)
+
+			restoredPath := filepath.Join(dir, "restored"+string(rune('0'+index)))
+			if test.restored != nil {
+				writeFile(filepath.Base(restoredPath), *test.restored, before)
+			}
+
+			result := CompareFile(SourceFile{Path: livePath, Folder: dir}, restoredPath, test.backupTime)
+			if result.Status != test.expected {
+				t.Errorf("expected %s, got %s (%s)", test.expected, result.Status, result.Detail)
+			}
+		})
+	}
+}
+
+func TestSelectBackupSnapshots(t *testing.T) {
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	sourceFolders := []string{"/home/docs", "/home/photos"}
+
+	t.Run("kopia snapshot per folder", func(t *testing.T) {
+
+		// The repository is shared with another config file ('work'), whose snapshot of the same folder is newer
+		snapshots := model.ConfigSnapshots([]model.Snapshot{
+			{ID: "k-docs1", Time: day(1), Tags: []string{"home"}, Paths: []string{"/home/docs"}},
+			{ID: "k-docs2", Time: day(4), Tags: []string{"home"}, Paths: []string{"/home/docs"}},
+			{ID: "k-photos1", Time: day(2), Tags: []string{"home"}, Paths: []string{"/home/photos"}},
+			{ID: "k-work1", Time: day(5), Tags: []string{"work"}, Paths: []string{"/home/photos"}},
+		}, sourceFolders, "home")
+
+		selector, folderSnapshots, err := SelectBackupSnapshots(snapshots, sourceFolders, "home")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if selector.Type != model.SnapshotSelectTag || selector.Value != "home" {
+			t.Errorf("unexpected selector: %v", selector)
+		}
+
+		if snapshot := folderSnapshots["/home/docs"]; snapshot.ID != "k-docs2" || !snapshot.Time.Equal(day(4)) {
+			t.Errorf("unexpected snapshot of docs: %v", snapshot)
+		}
+
+		if snapshot := folderSnapshots["/home/photos"]; snapshot.ID != "k-photos1" || !snapshot.Time.Equal(day(2)) {
+			t.Errorf("unexpected snapshot of photos: %v", snapshot)
+		}
+	})
+
+	t.Run("kopia snapshot per folder without a name", func(t *testing.T) {
+
+		snapshots := []model.Snapshot{
+			{ID: "k-docs1", Time: day(1), Paths: []string{"/home/docs"}},
+			{ID: "k-photos1", Time: day(2), Paths: []string{"/home/photos"}},
+		}
+
+		selector, _, err := SelectBackupSnapshots(snapshots, sourceFolders, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if selector.Type != model.SnapshotSelectLatest {
+			t.Errorf("unexpected selector: %v", selector)
+		}
+	})
+
+	t.Run("restic snapshot of all folders", func(t *testing.T) {
+
+		snapshots := model.ConfigSnapshots([]model.Snapshot{
+			{ID: "r-home1", Time: day(1), Tags: []string{"home-2024-01-01"}, Paths: sourceFolders},
+			{ID: "r-home2", Time: day(2), Tags: []string{"home-2024-01-02"}, Paths: sourceFolders},
+			{ID: "r-work1", Time: day(3), Tags: []string{"work-2024-01-03"}, Paths: []string{"/srv"}},
+		}, sourceFolders, "home")
+
+		selector, folderSnapshots, err := SelectBackupSnapshots(snapshots, sourceFolders, "home")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if selector.Type != model.SnapshotSelectID || selector.Value != "r-home2" {
+			t.Errorf("unexpected selector: %v", selector)
+		}
+
+		for _, sourceFolder := range sourceFolders {
+			if snapshot := folderSnapshots[sourceFolder]; snapshot.ID != "r-home2" {
+				t.Errorf("unexpected snapshot of %s: %v", sourceFolder, snapshot)
+			}
+		}
+	})
+}
+
+func ptr(value string) *string {
+	return &value
+}
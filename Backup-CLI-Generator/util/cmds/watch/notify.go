@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// webhookTimeout is the maximum duration of a webhook request
+const webhookTimeout = 30 * time.Second
+
+// SendNotification runs the notification command, and POSTs the event to the notification webhook, of the config file (if
+// specified).
+func SendNotification(ctx context.Context, notifications model.Notifications, event Event) error {
+
+	if notifications.Command != "" {
+
+		args := []string{"sh", "-c", notifications.Command}
+		if runtime.GOOS == "windows" {
+			args = []string{"cmd", "/C", notifications.Command}
+		}
+
+		invocation := util.DirectInvocation{
+			Args: args,
+			EnvironmentVariables: map[string]string{
+				"BACKUP_CLI_EVENT":   string(event.Type),
+				"BACKUP_CLI_MESSAGE": event.Message(),
+				"BACKUP_CLI_CONFIG":  event.ConfigPath,
+				"BACKUP_CLI_PATH":    event.Path,
+			},
+		}
+
+		if err := invocation.Execute(ctx); err != nil {
+			return fmt.Errorf("notification command failed: %w", err)
+		}
+	}
+
+	if notifications.Webhook != "" {
+		if err := postWebhook(ctx, notifications.Webhook, event); err != nil {
+			return fmt.Errorf("notification webhook failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// webhookPayload is the JSON body POSTed to a notification webhook
+type webhookPayload struct {
+	Event
+	Message string `json:"message"`
+}
+
+func postWebhook(ctx context.Context, webhookURL string, event Event) error {
+
+	body, err := json.Marshal(webhookPayload{Event: event, Message: event.Message()})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	return nil
+}
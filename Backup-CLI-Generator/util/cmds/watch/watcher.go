@@ -0,0 +1,276 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+	"github.com/jgwest/backup-cli/util/cmds/generate"
+)
+
+// EventType is the type of a problem found by 'watch'
+type EventType string
+
+const (
+	// EventUnbackedUpPath is a new file or folder under a monitor folder that is not backed up (and not excluded)
+	EventUnbackedUpPath EventType = "unbacked-up-path"
+
+	// EventMonitorFolderMissing is a monitor folder that no longer exists
+	EventMonitorFolderMissing EventType = "monitor-folder-missing"
+
+	// EventMonitorFolderRestored is a monitor folder that exists again, after it was reported missing
+	EventMonitorFolderRestored EventType = "monitor-folder-restored"
+)
+
+// Event is a problem (or the resolution of a problem) found by 'watch' with the monitor folders of a config file
+type Event struct {
+	Type          EventType `json:"type"`
+	Time          time.Time `json:"time"`
+	ConfigPath    string    `json:"configPath"`
+	MonitorFolder string    `json:"monitorFolder"`
+	Path          string    `json:"path"`
+}
+
+// Message returns a description of the event.
+func (e Event) Message() string {
+
+	switch e.Type {
+	case EventUnbackedUpPath:
+		return fmt.Sprintf("'%s' (under monitor folder '%s' of '%s') is not backed up", e.Path, e.MonitorFolder, e.ConfigPath)
+	case EventMonitorFolderMissing:
+		return fmt.Sprintf("monitor folder '%s' of '%s' does not exist", e.Path, e.ConfigPath)
+	case EventMonitorFolderRestored:
+		return fmt.Sprintf("monitor folder '%s' of '%s' exists again", e.Path, e.ConfigPath)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Type, e.Path)
+}
+
+// Watcher watches the monitor folders of config files, and reports new files and folders under them that are not backed up, and
+// monitor folders that disappear. Changes are debounced: the monitor folders are checked once no change has been seen for the
+// debounce duration, and also every rescan interval (to find monitor folders that reappear, and changes that were missed).
+type Watcher struct {
+	ConfigPaths    []string
+	Debounce       time.Duration
+	RescanInterval time.Duration
+
+	// OnEvent is called for each event, with the config file that the event is for
+	OnEvent func(config model.ConfigFile, event Event)
+
+	fsWatcher *fsnotify.Watcher
+	watched   map[string]bool
+	states    map[string]*configState
+}
+
+// configState is what has been reported for a config file
+type configState struct {
+	unbackedUpPaths map[string]bool
+	missingFolders  map[string]bool
+}
+
+// Run watches until the context is done.
+func (w *Watcher) Run(ctx context.Context) error {
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsWatcher.Close()
+
+	w.fsWatcher = fsWatcher
+	w.watched = map[string]bool{}
+	w.states = map[string]*configState{}
+
+	w.scan(true)
+
+	debounce := time.NewTimer(w.Debounce)
+	stopTimer(debounce)
+
+	rescan := time.NewTicker(w.RescanInterval)
+	defer rescan.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				// The watch of a removed folder is removed with it, so it must be watched again if it is recreated
+				delete(w.watched, filepath.Clean(event.Name))
+			}
+
+			if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				stopTimer(debounce)
+				debounce.Reset(w.Debounce)
+			}
+
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Println("Warning: watch error:", err)
+
+		case <-debounce.C:
+			w.scan(false)
+
+		case <-rescan.C:
+			w.scan(false)
+		}
+	}
+}
+
+// scan checks the monitor folders of each config file, reporting new problems (unless initial, in which case existing problems
+// are only listed), and watches any new folders under the monitor folders.
+func (w *Watcher) scan(initial bool) {
+
+	for _, configPath := range w.ConfigPaths {
+
+		// The config file is read on each scan, so that changes to it (for example, by 'monitor --fix') are seen
+		config, err := model.ReadConfigFile(configPath)
+		if err != nil {
+			fmt.Println("Warning: unable to read config file:", configPath, err)
+			continue
+		}
+
+		state, exists := w.states[configPath]
+		if !exists {
+			state = &configState{unbackedUpPaths: map[string]bool{}, missingFolders: map[string]bool{}}
+			w.states[configPath] = state
+		}
+
+		existingMonitorFolders := []model.MonitorFolder{}
+
+		for _, monitorFolder := range config.MonitorFolders {
+
+			monitorPath, err := util.Expand(monitorFolder.Path, config.Substitutions)
+			if err != nil {
+				fmt.Println("Warning: unable to expand monitor folder:", monitorFolder.Path, err)
+				continue
+			}
+
+			event := Event{Time: time.Now(), ConfigPath: configPath, MonitorFolder: monitorFolder.Path, Path: monitorPath}
+
+			if _, err := os.Stat(monitorPath); err != nil {
+				if !state.missingFolders[monitorPath] {
+					state.missingFolders[monitorPath] = true
+					event.Type = EventMonitorFolderMissing
+					w.OnEvent(config, event)
+				}
+				continue
+			}
+
+			if state.missingFolders[monitorPath] {
+				delete(state.missingFolders, monitorPath)
+				event.Type = EventMonitorFolderRestored
+				w.OnEvent(config, event)
+			}
+
+			depth, err := monitorFolder.GetDepth()
+			if err != nil {
+				fmt.Println("Warning:", err)
+				continue
+			}
+
+			// Changes at the depth of the monitor folder are seen by watching the folders above that depth
+			w.watchFolders(monitorPath, depth-1)
+
+			existingMonitorFolders = append(existingMonitorFolders, monitorFolder)
+		}
+
+		config.MonitorFolders = existingMonitorFolders
+
+		monitorFolderResults, err := generate.FindUnbackedUpMonitorPaths(config)
+		if err != nil {
+			fmt.Println("Warning: unable to check the monitor folders of:", configPath, err)
+			continue
+		}
+
+		unbackedUpPaths := map[string]bool{}
+
+		for _, monitorFolderResult := range monitorFolderResults {
+			for _, path := range monitorFolderResult.Paths {
+
+				unbackedUpPaths[path] = true
+
+				if state.unbackedUpPaths[path] {
+					continue
+				}
+
+				if initial {
+					fmt.Printf("Existing un-backed-up path (under monitor folder '%s' of '%s'): %s\n", monitorFolderResult.MonitorFolder.Path, configPath, path)
+					continue
+				}
+
+				w.OnEvent(config, Event{
+					Type:          EventUnbackedUpPath,
+					Time:          time.Now(),
+					ConfigPath:    configPath,
+					MonitorFolder: monitorFolderResult.MonitorFolder.Path,
+					Path:          path,
+				})
+			}
+		}
+
+		// Paths that are now backed up (or removed) are reported again if they reappear, but not those under monitor folders that
+		// are missing
+		for path := range state.unbackedUpPaths {
+			for missingFolder := range state.missingFolders {
+				if relPath, err := filepath.Rel(missingFolder, path); err == nil && !strings.HasPrefix(relPath, "..") {
+					unbackedUpPaths[path] = true
+				}
+			}
+		}
+		state.unbackedUpPaths = unbackedUpPaths
+	}
+}
+
+// watchFolders watches the folder, and the folders under it down to depth levels, that are not already watched.
+func (w *Watcher) watchFolders(folder string, depth int) {
+
+	folder = filepath.Clean(folder)
+
+	if !w.watched[folder] {
+		if err := w.fsWatcher.Add(folder); err != nil {
+			fmt.Println("Warning: unable to watch:", folder, err)
+			return
+		}
+		w.watched[folder] = true
+	}
+
+	if depth <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		fmt.Println("Warning: unable to read:", folder, err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			w.watchFolders(filepath.Join(folder, entry.Name()), depth-1)
+		}
+	}
+}
+
+// stopTimer stops the timer, draining its channel if it has already fired, so that it can be reset.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestWatcherScan(t *testing.T) {
+
+	root := t.TempDir()
+	monitorPath := filepath.Join(root, "home")
+
+	for _, folder := range []string{"home/docs", "home/existing"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configPath := filepath.Join(root, "config.yaml")
+	config := "folders:\n- path: " + filepath.Join(monitorPath, "docs") + "\nmonitorFolders:\n- path: " + monitorPath + "\n"
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsWatcher.Close()
+
+	events := []Event{}
+
+	watcher := &Watcher{
+		ConfigPaths: []string{configPath},
+		OnEvent:     func(_ model.ConfigFile, event Event) { events = append(events, event) },
+		fsWatcher:   fsWatcher,
+		watched:     map[string]bool{},
+		states:      map[string]*configState{},
+	}
+
+	expectEvents := func(step string, expected ...EventType) {
+		t.Helper()
+		if len(events) != len(expected) {
+			t.Fatalf("%s: expected %v, got %v", step, expected, events)
+		}
+		for index := range expected {
+			if events[index].Type != expected[index] {
+				t.Fatalf("%s: expected %v, got %v", step, expected, events)
+			}
+		}
+		events = []Event{}
+	}
+
+	// Existing problems are not reported as events
+	watcher.scan(true)
+	expectEvents("initial scan")
+
+	if err := os.Mkdir(filepath.Join(monitorPath, "new"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	watcher.scan(false)
+	expectEvents("new folder", EventUnbackedUpPath)
+
+	// Problems are only reported once
+	watcher.scan(false)
+	expectEvents("unchanged")
+
+	if err := os.Rename(monitorPath, monitorPath+".moved"); err != nil {
+		t.Fatal(err)
+	}
+	watcher.scan(false)
+	expectEvents("monitor folder moved", EventMonitorFolderMissing)
+
+	if err := os.Rename(monitorPath+".moved", monitorPath); err != nil {
+		t.Fatal(err)
+	}
+	watcher.scan(false)
+	expectEvents("monitor folder restored", EventMonitorFolderRestored)
+}
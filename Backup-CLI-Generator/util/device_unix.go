@@ -0,0 +1,25 @@
+//go:build !windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// DeviceID returns an identifier for the device (disk) that contains the given path.
+func DeviceID(path string) (string, error) {
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", fmt.Errorf("unable to determine device of '%s'", path)
+	}
+
+	return fmt.Sprintf("%d", stat.Dev), nil
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DeviceID returns an identifier for the device (disk) that contains the given path: on Windows, this is the volume name (e.g. 'C:').
+func DeviceID(path string) (string, error) {
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	volumeName := filepath.VolumeName(absPath)
+	if volumeName == "" {
+		return "", fmt.Errorf("unable to determine device of '%s'", path)
+	}
+
+	return strings.ToUpper(volumeName), nil
+}
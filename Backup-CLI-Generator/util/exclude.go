@@ -0,0 +1,112 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// ExcludeMatcher matches file and folder paths against the exclude patterns of a config file ('globalExcludes', and the
+// 'excludes' of a folder), approximating how the backup utilities interpret them:
+// - patterns are matched with filepath.Match
+// - a pattern without a path separator is matched against the name of the file or folder (e.g. '*.tmp', 'node_modules')
+// - an absolute pattern is matched against the full path; any other pattern with a path separator is matched against the
+// same number of trailing path elements (e.g. 'cache/*.bin')
+// - a pattern with a trailing separator only matches folders
+//
+// Excluding a folder excludes its contents: callers walking a folder tree should skip excluded folders.
+type ExcludeMatcher struct {
+	patterns []excludePattern
+}
+
+type excludePattern struct {
+	pattern    string
+	folderOnly bool
+	absolute   bool
+	elements   int
+}
+
+// NewExcludeMatcher returns a matcher for the (already expanded) exclude patterns.
+func NewExcludeMatcher(patterns []string) ExcludeMatcher {
+
+	res := ExcludeMatcher{}
+
+	for _, pattern := range patterns {
+
+		pattern = filepath.FromSlash(pattern)
+
+		folderOnly := strings.HasSuffix(pattern, string(filepath.Separator))
+		pattern = strings.TrimRight(pattern, string(filepath.Separator))
+
+		if pattern == "" {
+			continue
+		}
+
+		res.patterns = append(res.patterns, excludePattern{
+			pattern:    pattern,
+			folderOnly: folderOnly,
+			absolute:   filepath.IsAbs(pattern),
+			elements:   len(strings.Split(pattern, string(filepath.Separator))),
+		})
+	}
+
+	return res
+}
+
+// Excluded returns true if the file or folder at the (absolute) path matches an exclude pattern.
+func (em ExcludeMatcher) Excluded(path string, isDir bool) bool {
+
+	path = filepath.Clean(path)
+	elements := strings.Split(path, string(filepath.Separator))
+
+	for _, pattern := range em.patterns {
+
+		if pattern.folderOnly && !isDir {
+			continue
+		}
+
+		candidate := path
+		if !pattern.absolute {
+			if pattern.elements > len(elements) {
+				continue
+			}
+			candidate = strings.Join(elements[len(elements)-pattern.elements:], string(filepath.Separator))
+		}
+
+		if matched, err := filepath.Match(pattern.pattern, candidate); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WalkDir calls fn with each file and folder under root (but not root itself) that is not excluded; the contents of excluded
+// folders are skipped. Unreadable files and folders (other than root) are reported as warnings, and skipped.
+func (em ExcludeMatcher) WalkDir(root string, fn func(path string, dirEntry fs.DirEntry) error) error {
+
+	return filepath.WalkDir(root, func(path string, dirEntry fs.DirEntry, err error) error {
+
+		if err != nil {
+			if path == root {
+				return err
+			}
+			fmt.Println("Warning: unable to read:", path, err)
+			return nil
+		}
+
+		if path == root {
+			return nil
+		}
+
+		if em.Excluded(path, dirEntry.IsDir()) {
+			if dirEntry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(path, dirEntry)
+	})
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExcludeMatcher(t *testing.T) {
+
+	root := filepath.Join(string(filepath.Separator), "home", "me")
+
+	matcher := NewExcludeMatcher([]string{"*.tmp", "node_modules/", "cache/*.bin", filepath.Join(root, "private")})
+
+	tests := []struct {
+		name     string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{name: "name pattern", path: filepath.Join(root, "docs", "file.tmp"), expected: true},
+		{name: "name pattern does not match", path: filepath.Join(root, "docs", "file.txt"), expected: false},
+		{name: "folder pattern matches folder", path: filepath.Join(root, "src", "node_modules"), isDir: true, expected: true},
+		{name: "folder pattern does not match file", path: filepath.Join(root, "src", "node_modules"), expected: false},
+		{name: "relative path pattern", path: filepath.Join(root, "app", "cache", "data.bin"), expected: true},
+		{name: "relative path pattern different parent", path: filepath.Join(root, "app", "other", "data.bin"), expected: false},
+		{name: "absolute pattern", path: filepath.Join(root, "private"), isDir: true, expected: true},
+		{name: "absolute pattern elsewhere", path: filepath.Join(root, "docs", "private"), isDir: true, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := matcher.Excluded(test.path, test.isDir); actual != test.expected {
+				t.Errorf("Excluded(%s) = %v, expected %v", test.path, actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import "errors"
+
+const (
+	// ExitCodeFolderFailure is returned when one or more folders of a multi-folder backup failed.
+	ExitCodeFolderFailure = 2
+
+	// ExitCodeConfigFailure is returned when one or more config files of a multi-config command failed.
+	ExitCodeConfigFailure = 3
+
+	// ExitCodeLocked is returned when a backup could not be started because a lock is held by another process.
+	ExitCodeLocked = 4
+
+	// ExitCodeStale is returned by 'status' when the newest backup of one or more config files is older than its maximum age.
+	ExitCodeStale = 5
+
+	// ExitCodeVerifyFailure is returned by 'verify' when one or more sampled files do not match the backup.
+	ExitCodeVerifyFailure = 6
+
+	// ExitCodeAuditViolation is returned by 'audit' when one or more paths violate an audit rule.
+	ExitCodeAuditViolation = 7
+)
+
+// ExitCodeError is an error which, when reported by the CLI, causes the process to exit with the given code.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCodeFor returns the process exit code for an error reported by the CLI: the code of the (possibly wrapped)
+// ExitCodeError, otherwise 1.
+func ExitCodeFor(err error) int {
+
+	var exitCodeErr *ExitCodeError
+	if errors.As(err, &exitCodeErr) {
+		return exitCodeErr.Code
+	}
+
+	return 1
+}
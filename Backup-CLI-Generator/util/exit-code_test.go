@@ -0,0 +1,31 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCodeFor(t *testing.T) {
+
+	for _, c := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "plain error", err: errors.New("failure"), expected: 1},
+		{name: "folder failure", err: &ExitCodeError{Code: ExitCodeFolderFailure, Err: errors.New("failure")}, expected: 2},
+		{name: "config failure", err: &ExitCodeError{Code: ExitCodeConfigFailure, Err: errors.New("failure")}, expected: 3},
+		{name: "locked", err: &ExitCodeError{Code: ExitCodeLocked, Err: errors.New("failure")}, expected: 4},
+		{name: "stale", err: &ExitCodeError{Code: ExitCodeStale, Err: errors.New("failure")}, expected: 5},
+		{name: "verify failure", err: &ExitCodeError{Code: ExitCodeVerifyFailure, Err: errors.New("failure")}, expected: 6},
+		{name: "audit violation", err: &ExitCodeError{Code: ExitCodeAuditViolation, Err: errors.New("failure")}, expected: 7},
+		{name: "wrapped", err: fmt.Errorf("unable to back up: %w", &ExitCodeError{Code: ExitCodeLocked, Err: errors.New("failure")}), expected: 4},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if code := ExitCodeFor(c.err); code != c.expected {
+				t.Errorf("unexpected exit code: %d, expected %d", code, c.expected)
+			}
+		})
+	}
+}
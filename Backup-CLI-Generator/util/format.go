@@ -0,0 +1,21 @@
+package util
+
+import "fmt"
+
+// FormatBytes returns the size in human-readable binary units (e.g. '1.5 GiB').
+func FormatBytes(size int64) string {
+
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
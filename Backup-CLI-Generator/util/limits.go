@@ -0,0 +1,54 @@
+package util
+
+import (
+	"github.com/jgwest/backup-cli/model"
+)
+
+// IOPriorityCommandPrefix returns the command, and arguments, that the backup command should be prefixed with to run it at
+// the IO priority of the limits on the given OS (a runtime.GOOS value); nil is returned if no prefix is required. IO priority
+// is applied via ionice on Linux, and taskpolicy on macOS. Other Unix OSes have no equivalent, so nice is used to lower the
+// CPU priority instead. IO priority is not supported on Windows.
+func IOPriorityCommandPrefix(limits model.Limits, goos string) []string {
+
+	if limits.IOPriority != model.IOPriorityLow && limits.IOPriority != model.IOPriorityIdle {
+		return nil
+	}
+
+	idle := limits.IOPriority == model.IOPriorityIdle
+
+	switch goos {
+	case "windows":
+		return nil
+
+	case "linux":
+		if idle {
+			// Only receives disk time when no other process needs it
+			return []string{"ionice", "-c", "3"}
+		}
+		// Lowest priority of the 'best-effort' class
+		return []string{"ionice", "-c", "2", "-n", "7"}
+
+	case "darwin":
+		if idle {
+			return []string{"taskpolicy", "-d", "throttle"}
+		}
+		return []string{"taskpolicy", "-d", "utility"}
+
+	default:
+		if idle {
+			return []string{"nice", "-n", "19"}
+		}
+		return []string{"nice", "-n", "10"}
+	}
+}
+
+// AddLimitsScheduleNote outputs a comment if the limits contain a schedule, as the schedule is only applied by direct
+// invocation (or by backends that natively support a bandwidth timetable).
+func AddLimitsScheduleNote(node *TextNode, limits model.Limits) {
+
+	if len(limits.Schedule) == 0 {
+		return
+	}
+
+	node.Comment("Note: the 'limits' schedule is not applied by this script; the default bandwidth limits are used.")
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestIOPriorityCommandPrefix(t *testing.T) {
+
+	for _, c := range []struct {
+		ioPriority string
+		goos       string
+		expected   []string
+	}{
+		{ioPriority: "", goos: "linux", expected: nil},
+		{ioPriority: model.IOPriorityNormal, goos: "linux", expected: nil},
+		{ioPriority: model.IOPriorityLow, goos: "linux", expected: []string{"ionice", "-c", "2", "-n", "7"}},
+		{ioPriority: model.IOPriorityIdle, goos: "linux", expected: []string{"ionice", "-c", "3"}},
+		{ioPriority: model.IOPriorityLow, goos: "darwin", expected: []string{"taskpolicy", "-d", "utility"}},
+		{ioPriority: model.IOPriorityIdle, goos: "darwin", expected: []string{"taskpolicy", "-d", "throttle"}},
+		{ioPriority: model.IOPriorityLow, goos: "freebsd", expected: []string{"nice", "-n", "10"}},
+		{ioPriority: model.IOPriorityIdle, goos: "openbsd", expected: []string{"nice", "-n", "19"}},
+		{ioPriority: model.IOPriorityIdle, goos: "windows", expected: nil},
+	} {
+		prefix := IOPriorityCommandPrefix(model.Limits{IOPriority: c.ioPriority}, c.goos)
+		if !reflect.DeepEqual(prefix, c.expected) {
+			t.Errorf("unexpected prefix for '%s' on %s: %v, expected %v", c.ioPriority, c.goos, prefix, c.expected)
+		}
+	}
+}
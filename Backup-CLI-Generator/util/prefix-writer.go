@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// outputMutex ensures that lines written by concurrent prefix writers are not interleaved.
+var outputMutex sync.Mutex
+
+// PrefixWriter is an io.Writer that prepends a prefix to each line written to the underlying writer. This allows
+// the output of concurrently running commands to be distinguished.
+type PrefixWriter struct {
+	out    io.Writer
+	prefix string
+	buffer bytes.Buffer
+
+	// lastLineEndedWithCR is true if the previous line was terminated by '\r', used to detect '\r\n' line endings
+	lastLineEndedWithCR bool
+}
+
+func NewPrefixWriter(out io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{out: out, prefix: prefix}
+}
+
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+
+	pw.buffer.Write(p)
+
+	for {
+		// Carriage returns (used by progress output) are treated as line endings
+		index := bytes.IndexAny(pw.buffer.Bytes(), "\r\n")
+		if index == -1 {
+			break
+		}
+
+		line := pw.buffer.Next(index + 1)
+
+		// Skip the '\n' of a '\r\n' line ending
+		endedWithCR := pw.lastLineEndedWithCR
+		pw.lastLineEndedWithCR = line[len(line)-1] == '\r'
+		if endedWithCR && len(line) == 1 && line[0] == '\n' {
+			continue
+		}
+
+		if err := pw.writeLine(line[:len(line)-1]); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any remaining partial line.
+func (pw *PrefixWriter) Flush() error {
+	if pw.buffer.Len() == 0 {
+		return nil
+	}
+
+	line := pw.buffer.Bytes()
+	pw.buffer.Reset()
+
+	return pw.writeLine(line)
+}
+
+func (pw *PrefixWriter) writeLine(line []byte) error {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	_, err := pw.out.Write([]byte(pw.prefix + string(line) + "\n"))
+	return err
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"strings"
+)
+
+// redacted replaces secret values in the output of a DirectInvocation
+const redacted = "(redacted)"
+
+// secretNameElements are the elements of environment variable and flag names whose values are secret
+var secretNameElements = []string{"PASSWORD", "PASSWD", "PASSPHRASE", "SECRET", "TOKEN", "ACCESS_KEY", "API_KEY", "PRIVATE_KEY"}
+
+// isSecretName returns true if the value of the environment variable or flag (e.g. 'RESTIC_PASSWORD' or '--new-password') is
+// secret. Flags and variables that name a file containing the secret (e.g. '--password-file') are not secret themselves.
+func isSecretName(name string) bool {
+
+	name = strings.ToUpper(strings.ReplaceAll(strings.TrimLeft(name, "-"), "-", "_"))
+
+	if strings.HasSuffix(name, "_FILE") || strings.HasSuffix(name, "_COMMAND") {
+		return false
+	}
+
+	for _, element := range secretNameElements {
+		if strings.Contains(name, element) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactEnvironmentVariable returns the value of the environment variable, or a placeholder if the value is secret.
+func redactEnvironmentVariable(name string, value string) string {
+	if isSecretName(name) {
+		return redacted
+	}
+	return value
+}
+
+// redactArgs returns a copy of the command arguments, with the values of secret flags replaced by a placeholder: both
+// '--password=value' and '--password value' forms are redacted.
+func redactArgs(args []string) []string {
+
+	res := []string{}
+
+	for index, arg := range args {
+
+		if index > 0 && strings.HasPrefix(args[index-1], "-") && !strings.Contains(args[index-1], "=") && isSecretName(args[index-1]) {
+			res = append(res, redacted)
+			continue
+		}
+
+		if name, _, hasValue := strings.Cut(arg, "="); hasValue && strings.HasPrefix(name, "-") && isSecretName(name) {
+			res = append(res, name+"="+redacted)
+			continue
+		}
+
+		res = append(res, arg)
+	}
+
+	return res
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+
+	args := []string{
+		"kopia", "repository", "change-password", "--new-password=new-secret",
+		"--bucket=bucket", "--secret-access-key=aws-secret",
+		"--password", "old-secret",
+		"--password-file", "/tmp/password",
+		"--verbose", "backup",
+	}
+
+	expected := []string{
+		"kopia", "repository", "change-password", "--new-password=" + redacted,
+		"--bucket=bucket", "--secret-access-key=" + redacted,
+		"--password", redacted,
+		"--password-file", "/tmp/password",
+		"--verbose", "backup",
+	}
+
+	if res := redactArgs(args); !reflect.DeepEqual(res, expected) {
+		t.Errorf("unexpected redacted args: %v", res)
+	}
+}
+
+func TestRedactEnvironmentVariable(t *testing.T) {
+
+	for _, c := range []struct {
+		name     string
+		redacted bool
+	}{
+		{name: "RESTIC_PASSWORD", redacted: true},
+		{name: "KOPIA_PASSWORD", redacted: true},
+		{name: "KOPIA_NEW_PASSWORD", redacted: true},
+		{name: "AWS_SECRET_ACCESS_KEY", redacted: true},
+		{name: "AWS_ACCESS_KEY_ID", redacted: true},
+		{name: "RCLONE_CONFIG_PASS_TOKEN", redacted: true},
+		{name: "RESTIC_PASSWORD_FILE", redacted: false},
+		{name: "RESTIC_PASSWORD_COMMAND", redacted: false},
+		{name: "RESTIC_REPOSITORY", redacted: false},
+		{name: "HOME", redacted: false},
+	} {
+		value := redactEnvironmentVariable(c.name, "value")
+		if (value == redacted) != c.redacted {
+			t.Errorf("unexpected value for '%s': %s", c.name, value)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+const (
+	defaultRetryInitialDelay = 30 * time.Second
+	defaultRetryMaxDelay     = 10 * time.Minute
+
+	// maxTailBufferSize is the maximum amount of stderr output retained for classifying failures
+	maxTailBufferSize = 64 * 1024
+)
+
+// RetryableFailureClassifier returns true if a failed command, with the given exit code and stderr output, may succeed if retried.
+type RetryableFailureClassifier func(exitCode int, stderr string) bool
+
+// TransientNetworkErrorPatterns are stderr substrings that indicate a transient network or service failure.
+var TransientNetworkErrorPatterns = []string{
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"no such host",
+	"network is unreachable",
+	"unexpected EOF",
+	"context deadline exceeded",
+	"Service Unavailable",
+	"Internal Server Error",
+	"Bad Gateway",
+	"Gateway Timeout",
+	"SlowDown",
+	"RequestTimeout",
+}
+
+// ContainsAny returns true if str contains any of the patterns, ignoring case.
+func ContainsAny(str string, patterns []string) bool {
+
+	lowerStr := strings.ToLower(str)
+
+	for _, pattern := range patterns {
+		if strings.Contains(lowerStr, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryPolicy is the parsed form of model.RetryPolicy
+type retryPolicy struct {
+	attempts     int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	jitter       float64
+}
+
+// newRetryPolicy parses the config file retry policy; if nil, a policy of a single attempt is returned.
+func newRetryPolicy(policy *model.RetryPolicy) (retryPolicy, error) {
+
+	res := retryPolicy{
+		attempts:     1,
+		initialDelay: defaultRetryInitialDelay,
+		maxDelay:     defaultRetryMaxDelay,
+	}
+
+	if policy == nil {
+		return res, nil
+	}
+
+	if policy.Attempts < 0 {
+		return retryPolicy{}, fmt.Errorf("retry attempts must be a positive value")
+	} else if policy.Attempts > 0 {
+		res.attempts = policy.Attempts
+	}
+
+	if policy.InitialDelay != "" {
+		initialDelay, err := time.ParseDuration(policy.InitialDelay)
+		if err != nil {
+			return retryPolicy{}, fmt.Errorf("invalid retry initial delay: %w", err)
+		}
+		res.initialDelay = initialDelay
+	}
+
+	if policy.MaxDelay != "" {
+		maxDelay, err := time.ParseDuration(policy.MaxDelay)
+		if err != nil {
+			return retryPolicy{}, fmt.Errorf("invalid retry max delay: %w", err)
+		}
+		res.maxDelay = maxDelay
+	}
+
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return retryPolicy{}, fmt.Errorf("retry jitter must be between 0 and 1")
+	}
+	res.jitter = policy.Jitter
+
+	return res, nil
+}
+
+// delay returns the time to wait after the given (1-based) failed attempt: the initial delay, doubled on each
+// subsequent attempt, up to the maximum delay, then randomly adjusted by the jitter fraction.
+func (rp retryPolicy) delay(attempt int) time.Duration {
+
+	delay := rp.initialDelay
+	for i := 1; i < attempt && delay < rp.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > rp.maxDelay {
+		delay = rp.maxDelay
+	}
+
+	if rp.jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + rp.jitter*(2*rand.Float64()-1)))
+	}
+
+	return delay
+}
+
+// tailBuffer is an io.Writer that retains only the most recent output written to it.
+type tailBuffer struct {
+	data []byte
+}
+
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+
+	tb.data = append(tb.data, p...)
+
+	if len(tb.data) > maxTailBufferSize {
+		tb.data = tb.data[len(tb.data)-maxTailBufferSize:]
+	}
+
+	return len(p), nil
+}
+
+func (tb *tailBuffer) String() string {
+	return string(tb.data)
+}
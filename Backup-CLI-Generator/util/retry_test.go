@@ -0,0 +1,130 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+
+	policy, err := newRetryPolicy(&model.RetryPolicy{Attempts: 5, InitialDelay: "1s", MaxDelay: "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		attempt       int
+		expectedDelay time.Duration
+	}{
+		{attempt: 1, expectedDelay: 1 * time.Second},
+		{attempt: 2, expectedDelay: 2 * time.Second},
+		{attempt: 3, expectedDelay: 4 * time.Second},
+		{attempt: 4, expectedDelay: 5 * time.Second},
+		{attempt: 10, expectedDelay: 5 * time.Second},
+	} {
+		if delay := policy.delay(c.attempt); delay != c.expectedDelay {
+			t.Errorf("delay of attempt %d does not match: %v %v", c.attempt, delay, c.expectedDelay)
+		}
+	}
+
+	policy.jitter = 0.5
+	for attempt := 1; attempt < 5; attempt++ {
+		delay := policy.delay(attempt)
+		if delay < 500*time.Millisecond || delay > 7500*time.Millisecond {
+			t.Errorf("delay with jitter is out of range: %v", delay)
+		}
+	}
+}
+
+func TestExecuteRetriesTransientFailures(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	for _, c := range []struct {
+		name          string
+		stderr        string
+		expectSuccess bool
+		expectedRuns  int
+	}{
+		{
+			name:          "transient failure is retried",
+			stderr:        "connection reset by peer",
+			expectSuccess: true,
+			expectedRuns:  3,
+		},
+		{
+			name:          "permanent failure is not retried",
+			stderr:        "wrong password",
+			expectSuccess: false,
+			expectedRuns:  1,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+
+			counterFile := filepath.Join(t.TempDir(), "counter")
+
+			// Fails (writing to stderr) until it has been run 3 times
+			script := `echo x >> "$COUNTER"; if [ $(wc -l < "$COUNTER") -lt 3 ]; then echo "$MESSAGE" >&2; exit 1; fi`
+
+			di := DirectInvocation{
+				Args:                 []string{"sh", "-c", script},
+				EnvironmentVariables: map[string]string{"COUNTER": counterFile, "MESSAGE": c.stderr},
+				Retry:                &model.RetryPolicy{Attempts: 5, InitialDelay: "1ms"},
+				IsRetryable: func(exitCode int, stderr string) bool {
+					return strings.Contains(stderr, "connection reset")
+				},
+			}
+
+			err := di.Execute(context.Background())
+			if (err == nil) != c.expectSuccess {
+				t.Errorf("unexpected result: %v", err)
+			}
+
+			content, err := os.ReadFile(counterFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if runs := strings.Count(string(content), "x"); runs != c.expectedRuns {
+				t.Errorf("unexpected number of runs: %v %v", runs, c.expectedRuns)
+			}
+		})
+	}
+}
+
+func TestExecuteCancelled(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	di := DirectInvocation{
+		Args:  []string{"sh", "-c", "exec sleep 10"},
+		Retry: &model.RetryPolicy{Attempts: 5, InitialDelay: "1ms"},
+		IsRetryable: func(exitCode int, stderr string) bool {
+			return true
+		},
+	}
+
+	start := time.Now()
+
+	if err := di.Execute(ctx); err == nil {
+		t.Errorf("expected cancelled command to fail")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command was not interrupted: %v", elapsed)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package runlock
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// processExists returns true if a process with the given PID is running on this host. The start time of the process is not
+// available portably, so startedBefore (the time the lock was taken) is not checked.
+func processExists(pid int, startedBefore time.Time) bool {
+
+	err := syscall.Kill(pid, 0)
+
+	// EPERM: the process exists, but is owned by another user
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
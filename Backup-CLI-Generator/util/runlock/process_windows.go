@@ -0,0 +1,43 @@
+//go:build windows
+
+package runlock
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+
+	// stillActive is the exit code returned by GetExitCodeProcess for a process that has not exited
+	stillActive = 259
+)
+
+// processExists returns true if a process with the given PID is running on this host, and was started before the given
+// time (the time the lock was taken), so was not started after the lock process exited and its PID was reused.
+func processExists(pid int, startedBefore time.Time) bool {
+
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// ERROR_ACCESS_DENIED: the process exists, but is owned by another user (or is elevated)
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(handle)
+
+	// The handle of an exited process remains valid while other handles to it are open, so check the exit code
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(handle, &exitCode); err != nil || exitCode != stillActive {
+		return false
+	}
+
+	var creationTime, exitTime, kernelTime, userTime syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creationTime, &exitTime, &kernelTime, &userTime); err != nil {
+		// The process is running, but its start time cannot be compared
+		return true
+	}
+
+	// Allow for the precision of the lock file time
+	return !time.Unix(0, creationTime.Nanoseconds()).After(startedBefore.Add(time.Second))
+}
@@ -0,0 +1,349 @@
+package runlock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+// lockPollInterval is how often a held lock is rechecked, when waiting for it
+const lockPollInterval = 5 * time.Second
+
+// lockWriteGracePeriod is how long an unreadable lock file is assumed to be in the process of being written
+const lockWriteGracePeriod = 10 * time.Second
+
+// LockInfo is the content of a lock file: the key that is locked, and the process that holds the lock.
+type LockInfo struct {
+	Key        string    `json:"key"`
+	ConfigPath string    `json:"configPath"`
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	StartTime  time.Time `json:"startTime"`
+}
+
+// IsStale returns true if the lock was created on this host, by a process that no longer exists.
+func (li LockInfo) IsStale() bool {
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname != li.Host {
+		// The process of another host cannot be checked
+		return false
+	}
+
+	return !processExists(li.PID, li.StartTime)
+}
+
+func (li LockInfo) String() string {
+	return fmt.Sprintf("'%s' is locked by PID %d on host '%s' (config '%s'), since %s", li.Key, li.PID, li.Host, li.ConfigPath, li.StartTime.Format(time.RFC3339))
+}
+
+// Lock is a set of acquired lock files, which are removed on Release.
+type Lock struct {
+	lockFilePaths []string
+}
+
+// Acquire takes an advisory lock on both the config file path, and on the repository/destination of the config file, to prevent
+// overlapping backups. If a lock is held by another process, an error is returned, unless options.WaitForLock is set, in which case
+// Acquire waits for the lock (up to options.LockWaitTimeout, if non-zero). Stale locks, whose process no longer exists, are removed.
+func Acquire(ctx context.Context, configFilePath string, config model.ConfigFile, options model.BackupOptions) (*Lock, error) {
+
+	keys, err := lockKeys(configFilePath, config)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{}
+
+	startTime := time.Now()
+
+	for _, key := range keys {
+
+		lockInfo := LockInfo{
+			Key:        key,
+			ConfigPath: absConfigFilePath,
+			PID:        os.Getpid(),
+			Host:       hostname,
+			StartTime:  startTime,
+		}
+
+		lockFilePath, err := acquireKey(ctx, lockInfo, options)
+		if err != nil {
+			lock.Release()
+			return nil, err
+		}
+
+		lock.lockFilePaths = append(lock.lockFilePaths, lockFilePath)
+	}
+
+	return lock, nil
+}
+
+// Release removes the lock files that are held by this process.
+func (l *Lock) Release() {
+
+	for _, lockFilePath := range l.lockFilePaths {
+
+		lockInfo, err := readLockFile(lockFilePath)
+		if err != nil || lockInfo.PID != os.Getpid() {
+			continue
+		}
+
+		if err := os.Remove(lockFilePath); err != nil {
+			fmt.Println("Warning: unable to remove lock file:", err)
+		}
+	}
+
+	l.lockFilePaths = nil
+}
+
+// Unlock removes the locks of the config file. Locks held by a running process are not removed unless force is true.
+func Unlock(configFilePath string, config model.ConfigFile, force bool) error {
+
+	keys, err := lockKeys(configFilePath, config)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+
+		lockFilePath, err := lockFilePathForKey(key)
+		if err != nil {
+			return err
+		}
+
+		lockInfo, err := readLockFile(lockFilePath)
+		if os.IsNotExist(err) {
+			fmt.Println("Not locked:", key)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !force && !lockInfo.IsStale() {
+			return fmt.Errorf("%v: lock may still be held by a running process, use --force to remove", lockInfo)
+		}
+
+		if err := os.Remove(lockFilePath); err != nil {
+			return err
+		}
+
+		fmt.Println("Removed lock:", lockInfo)
+	}
+
+	return nil
+}
+
+// HeldLocks returns the locks of the config file that are currently held; stale locks are not returned.
+func HeldLocks(configFilePath string, config model.ConfigFile) ([]LockInfo, error) {
+
+	keys, err := lockKeys(configFilePath, config)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []LockInfo{}
+
+	for _, key := range keys {
+
+		lockFilePath, err := lockFilePathForKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		lockInfo, err := readLockFile(lockFilePath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read lock file '%s': %w", lockFilePath, err)
+		}
+
+		if !lockInfo.IsStale() {
+			res = append(res, lockInfo)
+		}
+	}
+
+	return res, nil
+}
+
+// acquireKey creates the lock file for the key, returning the lock file path.
+func acquireKey(ctx context.Context, lockInfo LockInfo, options model.BackupOptions) (string, error) {
+
+	lockFilePath, err := lockFilePathForKey(lockInfo.Key)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal(lockInfo)
+	if err != nil {
+		return "", err
+	}
+
+	waitStart := time.Now()
+
+	for {
+
+		// Atomically create the lock file; this fails if the file already exists
+		file, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = file.Write(content)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lockFilePath)
+				return "", err
+			}
+			return lockFilePath, nil
+		}
+
+		if !os.IsExist(err) {
+			return "", err
+		}
+
+		existingLockInfo, err := readLockFile(lockFilePath)
+		if os.IsNotExist(err) {
+			// Lock was released between the create and the read, so try again
+			continue
+		} else if err != nil {
+
+			// The lock file may have been created, but not yet written, by another process
+			if fileInfo, statErr := os.Stat(lockFilePath); statErr == nil && time.Since(fileInfo.ModTime()) < lockWriteGracePeriod {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+
+			return "", fmt.Errorf("unable to read lock file '%s': %w", lockFilePath, err)
+		}
+
+		if existingLockInfo.IsStale() {
+			fmt.Println("Removing stale lock:", existingLockInfo)
+			if err := removeStaleLock(lockFilePath, existingLockInfo); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		lockedErr := &util.ExitCodeError{Code: util.ExitCodeLocked, Err: errors.New(existingLockInfo.String())}
+
+		if !options.WaitForLock {
+			return "", lockedErr
+		}
+
+		if options.LockWaitTimeout > 0 && time.Since(waitStart) > options.LockWaitTimeout {
+			return "", fmt.Errorf("timed out waiting for lock: %w", lockedErr)
+		}
+
+		sleepTime := lockPollInterval
+		if remaining := options.LockWaitTimeout - time.Since(waitStart); options.LockWaitTimeout > 0 && remaining < sleepTime {
+			sleepTime = remaining
+		}
+
+		fmt.Println("Waiting for lock:", existingLockInfo)
+
+		select {
+		case <-time.After(sleepTime):
+		case <-ctx.Done():
+			return "", fmt.Errorf("cancelled while waiting for lock: %w", context.Cause(ctx))
+		}
+	}
+}
+
+// removeStaleLock removes the lock file, if it still contains the given stale lock. Other processes may have read the same stale
+// lock, and one of them may already have removed it and taken the lock: so the lock file is first claimed by renaming it to a
+// name unique to this process, and only removed if the claimed file is the stale lock. Otherwise, it is restored.
+func removeStaleLock(lockFilePath string, staleLockInfo LockInfo) error {
+
+	claimedFilePath := fmt.Sprintf("%s.%d-%d.stale", lockFilePath, os.Getpid(), time.Now().UnixNano())
+
+	if err := os.Rename(lockFilePath, claimedFilePath); err != nil {
+		if os.IsNotExist(err) {
+			// Already removed by another process
+			return nil
+		}
+		return err
+	}
+
+	claimedLockInfo, err := readLockFile(claimedFilePath)
+	if err == nil && claimedLockInfo.sameLock(staleLockInfo) {
+		return os.Remove(claimedFilePath)
+	}
+
+	// The stale lock was replaced (or is being written) by another process: restore it. A hard link, unlike a rename, fails
+	// rather than replacing a lock file that was created in the meantime.
+	if err := os.Link(claimedFilePath, lockFilePath); err != nil {
+		if os.IsExist(err) {
+			fmt.Println("Warning: a lock was replaced while removing a stale lock:", claimedLockInfo)
+		} else {
+			return fmt.Errorf("unable to restore lock file '%s' from '%s': %w", lockFilePath, claimedFilePath, err)
+		}
+	}
+
+	return os.Remove(claimedFilePath)
+}
+
+// sameLock returns true if both refer to the lock taken by the same process at the same time.
+func (li LockInfo) sameLock(other LockInfo) bool {
+	return li.Key == other.Key && li.PID == other.PID && li.Host == other.Host && li.StartTime.Equal(other.StartTime)
+}
+
+// lockKeys returns the keys to lock for the config file: the config file path, and the repository/destination.
+func lockKeys(configFilePath string, config model.ConfigFile) ([]string, error) {
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	repositoryID, err := config.GetRepositoryID()
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"config:" + absConfigFilePath, "repository:" + repositoryID}, nil
+}
+
+func lockFilePathForKey(key string) (string, error) {
+
+	lockDir, err := util.StateDir("locks")
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(key))
+
+	return filepath.Join(lockDir, hex.EncodeToString(hash[:])[0:32]+".lock"), nil
+}
+
+func readLockFile(lockFilePath string) (LockInfo, error) {
+
+	content, err := os.ReadFile(lockFilePath)
+	if err != nil {
+		return LockInfo{}, err
+	}
+
+	lockInfo := LockInfo{}
+	if err := json.Unmarshal(content, &lockInfo); err != nil {
+		return LockInfo{}, err
+	}
+
+	return lockInfo, nil
+}
@@ -0,0 +1,155 @@
+package runlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jgwest/backup-cli/model"
+	"github.com/jgwest/backup-cli/util"
+)
+
+func TestAcquire(t *testing.T) {
+
+	t.Setenv(util.StateDirEnvVar, t.TempDir())
+
+	destDir := t.TempDir()
+
+	newConfig := func() model.ConfigFile {
+		return model.ConfigFile{
+			Credentials: []model.Credentials{
+				{Rclone: &model.RcloneCredentials{DestinationFolder: destDir}},
+			},
+		}
+	}
+
+	lock, err := Acquire(context.Background(), "/config-a.yaml", newConfig(), model.BackupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A second config file with the same destination should not be able to acquire the lock
+	_, err = Acquire(context.Background(), "/config-b.yaml", newConfig(), model.BackupOptions{})
+	var exitCodeErr *util.ExitCodeError
+	if !errors.As(err, &exitCodeErr) || exitCodeErr.Code != util.ExitCodeLocked {
+		t.Fatalf("expected lock error: %v", err)
+	}
+
+	// Waiting with a timeout should also fail
+	_, err = Acquire(context.Background(), "/config-b.yaml", newConfig(), model.BackupOptions{WaitForLock: true, LockWaitTimeout: time.Millisecond})
+	if err == nil {
+		t.Fatalf("expected lock timeout error")
+	}
+
+	lock.Release()
+
+	lock, err = Acquire(context.Background(), "/config-b.yaml", newConfig(), model.BackupOptions{})
+	if err != nil {
+		t.Fatalf("lock should be available after release: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireRemovesStaleLock(t *testing.T) {
+
+	t.Setenv(util.StateDirEnvVar, t.TempDir())
+
+	config := model.ConfigFile{
+		Credentials: []model.Credentials{
+			{Rclone: &model.RcloneCredentials{DestinationFolder: t.TempDir()}},
+		},
+	}
+
+	keys, err := lockKeys("/config.yaml", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write a lock file for a process that does not exist
+	lockFilePath, err := lockFilePathForKey(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := json.Marshal(LockInfo{Key: keys[0], PID: 999999999, Host: hostname, StartTime: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lockFilePath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Acquire(context.Background(), "/config.yaml", config, model.BackupOptions{})
+	if err != nil {
+		t.Fatalf("stale lock should have been removed: %v", err)
+	}
+	lock.Release()
+
+	if _, err := os.Stat(lockFilePath); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed on release: %v", filepath.Base(lockFilePath))
+	}
+}
+
+func TestRemoveStaleLockAfterLockIsRetaken(t *testing.T) {
+
+	t.Setenv(util.StateDirEnvVar, t.TempDir())
+
+	lockFilePath, err := lockFilePathForKey("config:/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeLockFile := func(lockInfo LockInfo) {
+		content, err := json.Marshal(lockInfo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(lockFilePath, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	staleLockInfo := LockInfo{Key: "config:/config.yaml", PID: 999999999, Host: "host", StartTime: time.Now().Add(-time.Hour)}
+	writeLockFile(staleLockInfo)
+
+	// Two processes read the same stale lock: the first removes it, and takes the lock
+	if err := removeStaleLock(lockFilePath, staleLockInfo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(lockFilePath); !os.IsNotExist(err) {
+		t.Fatalf("stale lock file should be removed: %v", err)
+	}
+
+	newLockInfo := LockInfo{Key: "config:/config.yaml", PID: os.Getpid(), Host: "host", StartTime: time.Now()}
+	writeLockFile(newLockInfo)
+
+	// The second must not remove the lock of the first
+	if err := removeStaleLock(lockFilePath, staleLockInfo); err != nil {
+		t.Fatal(err)
+	}
+
+	lockInfo, err := readLockFile(lockFilePath)
+	if err != nil {
+		t.Fatalf("new lock file should not be removed: %v", err)
+	}
+	if !lockInfo.sameLock(newLockInfo) {
+		t.Errorf("unexpected lock: %v", lockInfo)
+	}
+
+	// The claimed files are removed
+	entries, err := os.ReadDir(filepath.Dir(lockFilePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("unexpected files in lock folder: %v", entries)
+	}
+}
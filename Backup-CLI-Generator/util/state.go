@@ -0,0 +1,94 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// StateDirEnvVar may be used to override the directory in which backup-cli stores its state (locks, run results, etc).
+const StateDirEnvVar = "BACKUP_CLI_STATE_DIR"
+
+// StateDir returns the path of the given subdirectory of the backup-cli state directory, creating it if needed. The state directory
+// is '$BACKUP_CLI_STATE_DIR' if set, otherwise 'backup-cli' under the user cache directory.
+func StateDir(subdir string) (string, error) {
+
+	stateDir := os.Getenv(StateDirEnvVar)
+
+	if stateDir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = filepath.Join(cacheDir, "backup-cli")
+	}
+
+	path := filepath.Join(stateDir, subdir)
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// ConfigStateFilePath returns the path of the JSON state file of the config file, within the given state subdirectory.
+func ConfigStateFilePath(subdir string, configFilePath string) (string, error) {
+
+	configHash, err := configFileHash(configFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	stateDir, err := StateDir(subdir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(stateDir, configHash+".json"), nil
+}
+
+// ConfigStateDir returns the path of the state directory of the config file (for state that is stored as multiple files), within
+// the given state subdirectory, creating it if needed.
+func ConfigStateDir(subdir string, configFilePath string) (string, error) {
+
+	configHash, err := configFileHash(configFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	return StateDir(filepath.Join(subdir, configHash))
+}
+
+// configFileHash returns an identifier of the config file that is derived from its absolute path.
+func configFileHash(configFilePath string) (string, error) {
+
+	absConfigFilePath, err := filepath.Abs(configFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(absConfigFilePath))
+
+	return hex.EncodeToString(hash[:])[0:32], nil
+}
+
+// WriteStateFile writes the value as JSON to a temporary file, then renames it, so that a partially written state file is
+// never read.
+func WriteStateFile(stateFilePath string, value any) error {
+
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFilePath := stateFilePath + ".tmp"
+
+	if err := os.WriteFile(tempFilePath, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilePath, stateFilePath)
+}
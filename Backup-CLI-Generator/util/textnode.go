@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"runtime/debug"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -29,6 +30,8 @@ func NewTextNodes() *TextNodes {
 type TextNode struct {
 	ID                 string
 	lines              []string
+	order              int
+	prefix             bool
 	requires           map[string]interface{}
 	exports            map[string]interface{}
 	directDependencies map[string]interface{}
@@ -40,6 +43,8 @@ func (tn *TextNodes) NewPrefixTextNode() *TextNode {
 
 	res := &TextNode{
 		parent:             tn,
+		order:              tn.nextChildId,
+		prefix:             true,
 		ID:                 tn.nextChildID(),
 		requires:           map[string]interface{}{},
 		exports:            map[string]interface{}{},
@@ -55,6 +60,7 @@ func (tn *TextNodes) NewPrefixTextNode() *TextNode {
 func (tn *TextNodes) NewTextNode() *TextNode {
 	res := &TextNode{
 		parent:             tn,
+		order:              tn.nextChildId,
 		ID:                 tn.nextChildID(),
 		requires:           map[string]interface{}{},
 		exports:            map[string]interface{}{},
@@ -142,6 +148,16 @@ func (textnode *TextNode) Header(str string) {
 
 }
 
+// Comment outputs a single line comment.
+func (textnode *TextNode) Comment(str string) {
+
+	if textnode.parent.IsWindows() {
+		textnode.Out("REM " + str)
+	} else {
+		textnode.Out("# " + str)
+	}
+}
+
 func (textnode *TextNode) ToString() string {
 	output := ""
 
@@ -168,127 +184,306 @@ func (tn TextNodes) ToString() (string, error) {
 	return tn.ToStringWithParams(false)
 }
 
+// ToStringWithParams returns the text of the nodes, ordered so that each node follows the nodes it depends on (added with
+// AddDependency, or that export a variable it requires); otherwise, prefix nodes are first, and nodes are in the order they were
+// created. A dependency cycle is an error. If allowUnresolved is false, a required variable that no node exports is an error.
 func (tn TextNodes) ToStringWithParams(allowUnresolved bool) (string, error) {
+
+	dependencies, err := tn.dependencies(allowUnresolved)
+	if err != nil {
+		return "", err
+	}
+
+	sortedNodes, err := tn.sortNodes(dependencies)
+	if err != nil {
+		return "", err
+	}
+
 	var res string
 
-	// Variable name -> Child text node id that exports that variable
-	exportedVars := map[string]string{}
-	{
-		for _, childTextNode := range tn.allNodesMap {
+	for _, node := range sortedNodes {
+		res += node.ToString()
+	}
+
+	return res, nil
+}
+
+// ToDOT returns the dependency graph of the nodes in Graphviz DOT format, with an edge from each node to each node it depends on
+// (labelled with the variables it requires from that node). Required variables that no node exports are ignored.
+func (tn TextNodes) ToDOT() (string, error) {
 
-			for exportedVar := range childTextNode.exports {
+	dependencies, err := tn.dependencies(true)
+	if err != nil {
+		return "", err
+	}
+
+	res := "digraph textnodes {\n"
+
+	for _, node := range tn.allNodes() {
 
-				if _, exists := exportedVars[exportedVar]; exists {
-					return "", fmt.Errorf("multiple child text nodes are exporting: %v", exportedVar)
+		label := node.ID
+		if description := node.description(); description != "" {
+			label += ": " + description
+		}
+
+		shape := "ellipse"
+		if node.prefix {
+			shape = "box"
+		}
+
+		res += fmt.Sprintf("  %s [label=%s, shape=%s];\n", strconv.Quote(node.ID), strconv.Quote(label), shape)
+	}
+
+	for _, node := range tn.allNodes() {
+		for _, dependency := range dependencies[node] {
+
+			attributes := "style=dashed"
+			if len(dependency.variables) > 0 {
+				attributes = "label=" + strconv.Quote(strings.Join(dependency.variables, ", "))
+				if dependency.direct {
+					attributes += ", style=bold"
 				}
+			}
+
+			res += fmt.Sprintf("  %s -> %s [%s];\n", strconv.Quote(node.ID), strconv.Quote(dependency.node.ID), attributes)
+		}
+	}
+
+	res += "}\n"
+
+	return res, nil
+}
 
-				exportedVars[exportedVar] = childTextNode.ID
+// textNodeDependency is a dependency of a text node on another text node: direct (added with AddDependency), and/or by the
+// variables that it requires, which the other node exports
+type textNodeDependency struct {
+	node      *TextNode
+	direct    bool
+	variables []string
+}
+
+// allNodes returns the prefix nodes, then the other nodes, each in creation order.
+func (tn TextNodes) allNodes() []*TextNode {
+
+	res := append([]*TextNode{}, tn.prefixNodes...)
+	res = append(res, tn.nodes...)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].prefix != res[j].prefix {
+			return res[i].prefix
+		}
+		return res[i].order < res[j].order
+	})
+
+	return res
+}
+
+// dependencies returns the dependencies of each node, in the creation order of the nodes they are on.
+func (tn TextNodes) dependencies(allowUnresolved bool) (map[*TextNode][]textNodeDependency, error) {
 
+	allNodes := tn.allNodes()
+
+	// Variable name -> Child text node that exports that variable
+	exportedVars := map[string]*TextNode{}
+	for _, childTextNode := range allNodes {
+		for _, exportedVar := range sortedKeys(childTextNode.exports) {
+
+			if _, exists := exportedVars[exportedVar]; exists {
+				return nil, fmt.Errorf("multiple child text nodes are exporting: %v", exportedVar)
 			}
+
+			exportedVars[exportedVar] = childTextNode
 		}
 	}
 
-	// text node id -> # of direct and indirect dependencies
-	totalChildDependencies := map[string]int{}
+	res := map[*TextNode][]textNodeDependency{}
 
-	{
-		// child text node id -> parent text node id
-		childToParentMap := map[string][]string{}
+	for _, childTextNode := range allNodes {
 
-		for _, childTextNode := range tn.allNodesMap {
+		// Dependency node -> dependency
+		childDependencies := map[*TextNode]*textNodeDependency{}
 
-			childDependencies := map[string]interface{}{}
+		getDependency := func(node *TextNode) *textNodeDependency {
+			if _, exists := childDependencies[node]; !exists {
+				childDependencies[node] = &textNodeDependency{node: node}
+			}
+			return childDependencies[node]
+		}
+
+		for _, directDependencyID := range sortedKeys(childTextNode.directDependencies) {
 
-			for directDependencyID := range childTextNode.directDependencies {
-				childDependencies[directDependencyID] = directDependencyID
+			directDependency, exists := tn.allNodesMap[directDependencyID]
+			if !exists {
+				return nil, fmt.Errorf("text node %s depends on unknown text node %s", childTextNode.ID, directDependencyID)
 			}
 
-			for requiredVar := range childTextNode.requires {
+			getDependency(directDependency).direct = true
+		}
 
-				parentNodeID, exists := exportedVars[requiredVar]
-				if !exists {
-					if !allowUnresolved {
-						return "", fmt.Errorf("child text node requires unexported variable: %v", requiredVar)
-					}
-				} else {
+		for _, requiredVar := range sortedKeys(childTextNode.requires) {
 
-					// Ignore self-references
-					if parentNodeID != childTextNode.ID {
-						childDependencies[parentNodeID] = parentNodeID
-					}
+			parentNode, exists := exportedVars[requiredVar]
+			if !exists {
+				if !allowUnresolved {
+					return nil, fmt.Errorf("child text node requires unexported variable: %v", requiredVar)
 				}
+				continue
 			}
 
-			var allChildDependencies []string
-			for parentID := range childDependencies {
-				allChildDependencies = append(allChildDependencies, parentID)
+			// Ignore self-references
+			if parentNode != childTextNode {
+				dependency := getDependency(parentNode)
+				dependency.variables = append(dependency.variables, requiredVar)
 			}
+		}
 
-			childToParentMap[childTextNode.ID] = allChildDependencies
+		for _, node := range allNodes {
+			if dependency, exists := childDependencies[node]; exists {
+				res[childTextNode] = append(res[childTextNode], *dependency)
+			}
 		}
+	}
 
-		var findIndirectDependencies func(childID string) []string
+	return res, nil
+}
 
-		findIndirectDependencies = func(currID string) []string {
+// sortNodes returns the nodes in topological order: each node follows the nodes it depends on. When more than one node may be
+// next, prefix nodes are preferred, then the earliest created.
+func (tn TextNodes) sortNodes(dependencies map[*TextNode][]textNodeDependency) ([]*TextNode, error) {
 
-			resultMap := map[string]string{}
+	allNodes := tn.allNodes()
 
-			for _, directDependency := range childToParentMap[currID] {
+	// Node -> number of its dependencies that are not yet sorted
+	unsortedDependencies := map[*TextNode]int{}
 
-				dependendenciesOfDirectDependecy := findIndirectDependencies(directDependency)
+	// Node -> nodes that depend on it
+	dependents := map[*TextNode][]*TextNode{}
 
-				for _, dependendencyOfChildDependecy := range dependendenciesOfDirectDependecy {
-					resultMap[dependendencyOfChildDependecy] = dependendencyOfChildDependecy
-				}
+	for _, node := range allNodes {
+		unsortedDependencies[node] = len(dependencies[node])
+		for _, dependency := range dependencies[node] {
+			dependents[dependency.node] = append(dependents[dependency.node], node)
+		}
+	}
 
-			}
+	res := []*TextNode{}
+	sorted := map[*TextNode]bool{}
+
+	for len(res) < len(allNodes) {
 
-			keys := make([]string, 0, len(resultMap))
-			for k := range resultMap {
-				keys = append(keys, k)
+		// As allNodes is in priority order, the first node with no unsorted dependencies is next
+		var next *TextNode
+		for _, node := range allNodes {
+			if !sorted[node] && unsortedDependencies[node] == 0 {
+				next = node
+				break
 			}
+		}
 
-			return keys
+		if next == nil {
+			return nil, dependencyCycleError(allNodes, sorted, dependencies)
 		}
 
-		for _, childTextNode := range tn.allNodesMap {
-			totalChildDependencies[childTextNode.ID] = len(findIndirectDependencies(childTextNode.ID))
+		res = append(res, next)
+		sorted[next] = true
+
+		for _, dependent := range dependents[next] {
+			unsortedDependencies[dependent]--
 		}
 	}
 
-	sortFunc := func(textNodes []*TextNode) {
-		sort.SliceStable(textNodes, func(i, j int) bool {
+	return res, nil
+}
+
+// dependencyCycleError returns an error describing a dependency cycle between the unsorted nodes: as each unsorted node depends
+// on at least one other unsorted node, following those dependencies must eventually return to a node that was already visited.
+func dependencyCycleError(allNodes []*TextNode, sorted map[*TextNode]bool, dependencies map[*TextNode][]textNodeDependency) error {
+
+	var current *TextNode
+	for _, node := range allNodes {
+		if !sorted[node] {
+			current = node
+			break
+		}
+	}
 
-			var iDependencies, jDependencies int
+	// The nodes visited, and the dependency followed from each
+	path := []textNodeDependency{}
+	visited := map[*TextNode]int{}
 
-			if totalDepends, exists := totalChildDependencies[textNodes[i].ID]; exists {
-				iDependencies = totalDepends
-			} else {
-				iDependencies = 0
-			}
+	for {
+		if index, exists := visited[current]; exists {
+			path = path[index:]
+			break
+		}
 
-			if totalDepends, exists := totalChildDependencies[textNodes[j].ID]; exists {
-				jDependencies = totalDepends
-			} else {
-				jDependencies = 0
+		visited[current] = len(path)
+
+		for _, dependency := range dependencies[current] {
+			if !sorted[dependency.node] {
+				path = append(path, textNodeDependency{node: current, direct: dependency.direct, variables: dependency.variables})
+				current = dependency.node
+				break
 			}
+		}
+	}
+
+	nodeNames := []string{}
+	steps := []string{}
+
+	for index, step := range path {
+
+		nodeName := step.node.ID
+		if description := step.node.description(); description != "" {
+			nodeName += fmt.Sprintf(" (%q)", description)
+		}
+		nodeNames = append(nodeNames, nodeName)
+
+		reasons := []string{}
+		if step.direct {
+			reasons = append(reasons, "depends on")
+		}
+		if len(step.variables) > 0 {
+			reasons = append(reasons, fmt.Sprintf("requires '%s' from", strings.Join(step.variables, "', '")))
+		}
 
-			return iDependencies > jDependencies
-		})
+		steps = append(steps, fmt.Sprintf("%s %s %s", step.node.ID, strings.Join(reasons, " and "), path[(index+1)%len(path)].node.ID))
 	}
 
-	sortFunc(tn.prefixNodes)
-	sortFunc(tn.nodes)
+	return fmt.Errorf("dependency cycle between text nodes %s: %s", strings.Join(nodeNames, ", "), strings.Join(steps, "; "))
+}
+
+// description returns the first non-empty line of the node (usually a header or comment, without the comment marker), to
+// identify it in errors and graphs.
+func (textnode *TextNode) description() string {
 
-	for _, prefixNode := range tn.prefixNodes {
-		res += prefixNode.ToString()
+	for _, line := range textnode.lines {
+
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "# ") {
+			line = line[len("# "):]
+		} else if len(line) >= len("REM ") && strings.EqualFold(line[:len("REM ")], "REM ") {
+			line = line[len("REM "):]
+		}
+
+		if line = strings.TrimSpace(strings.TrimRight(line, "-")); line != "" {
+			return line
+		}
 	}
 
-	for _, node := range tn.nodes {
-		res += node.ToString()
+	return ""
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+
+	res := make([]string, 0, len(values))
+	for key := range values {
+		res = append(res, key)
 	}
+	sort.Strings(res)
 
-	return res, nil
+	return res
 }
 
 func Panic(err string) {
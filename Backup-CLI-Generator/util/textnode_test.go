@@ -0,0 +1,143 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextNodesOrder(t *testing.T) {
+
+	tests := []struct {
+		name string
+		// build creates the nodes, each of which outputs its name
+		build         func(nodes *TextNodes)
+		expected      string
+		expectedError []string
+	}{
+		{
+			name: "creation order, prefix nodes first",
+			build: func(nodes *TextNodes) {
+				nodes.NewTextNode().Out("a")
+				nodes.NewPrefixTextNode().Out("prefix")
+				nodes.NewTextNode().Out("b")
+			},
+			expected: "prefix a b",
+		},
+		{
+			name: "variable dependency on a later node",
+			build: func(nodes *TextNodes) {
+				a := nodes.NewTextNode()
+				a.Out("a " + a.Env("X"))
+				nodes.NewTextNode().Out("b")
+				c := nodes.NewTextNode()
+				c.Out("c")
+				c.AddExports("X")
+			},
+			expected: "b c a ${X}",
+		},
+		{
+			name: "direct dependency, and dependencies of dependencies",
+			build: func(nodes *TextNodes) {
+				a := nodes.NewTextNode()
+				a.Out("a")
+				b := nodes.NewTextNode()
+				b.Out("b")
+				c := nodes.NewTextNode()
+				c.Out("c")
+				d := nodes.NewTextNode()
+				d.Out("d")
+				a.AddDependency(c)
+				c.AddDependency(d)
+			},
+			expected: "b d c a",
+		},
+		{
+			name: "cycle",
+			build: func(nodes *TextNodes) {
+				nodes.NewTextNode().Out("unrelated")
+				a := nodes.NewTextNode()
+				a.Header("Node A")
+				a.SetEnv("A", a.Env("B"))
+				b := nodes.NewTextNode()
+				b.SetEnv("B", "")
+				b.AddDependency(a)
+			},
+			expectedError: []string{"1 (\"Node A\")", "1 requires 'B' from 2", "2 depends on 1"},
+		},
+		{
+			name: "unresolved variable",
+			build: func(nodes *TextNodes) {
+				a := nodes.NewTextNode()
+				a.Out(a.Env("MISSING"))
+			},
+			expectedError: []string{"MISSING"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			nodes := NewTextNodes()
+			nodes.isWindows = false
+
+			test.build(nodes)
+
+			output, err := nodes.ToString()
+
+			if len(test.expectedError) > 0 {
+				if err == nil {
+					t.Fatalf("expected an error, got: %s", output)
+				}
+				for _, expectedError := range test.expectedError {
+					if !strings.Contains(err.Error(), expectedError) {
+						t.Fatalf("expected error to contain %q, got: %v", expectedError, err)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := strings.Join(strings.Fields(strings.TrimSpace(output)), " "); actual != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTextNodeDescription(t *testing.T) {
+
+	for _, isWindows := range []bool{false, true} {
+
+		nodes := NewTextNodes()
+		nodes.isWindows = isWindows
+
+		header := nodes.NewTextNode()
+		header.Header("Backup folders")
+		header.Out("echo")
+
+		comment := nodes.NewTextNode()
+		comment.Comment("Set the password")
+
+		command := nodes.NewTextNode()
+		command.Out("  echo hello  ")
+
+		empty := nodes.NewTextNode()
+
+		for node, expected := range map[*TextNode]string{header: "Backup folders", comment: "Set the password", command: "echo hello", empty: ""} {
+			if description := node.description(); description != expected {
+				t.Errorf("unexpected description (windows: %v): %q, expected %q", isWindows, description, expected)
+			}
+		}
+
+		dot, err := nodes.ToDOT()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(dot, `label="0: Backup folders"`) {
+			t.Errorf("graph does not label the node with its header (windows: %v): %s", isWindows, dot)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package util
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/jgwest/backup-cli/model"
 )
@@ -51,51 +56,159 @@ func Expand(input string, configFileSubstitutions []model.Substitution) (output
 type DirectInvocation struct {
 	Args                 []string
 	EnvironmentVariables map[string]string
+
+	// OutputPrefix, if non-empty, is prepended to each line of output (used when multiple invocations run concurrently)
+	OutputPrefix string
+
+	// ClassifyExitCode, if non-nil, is used to interpret non-zero exit codes of the command. If nil, any non-zero exit code is an error.
+	ClassifyExitCode ExitCodeClassifier
+
+	// Retry, if non-nil, is the policy used to retry failures that IsRetryable reports as transient.
+	Retry *model.RetryPolicy
+
+	// IsRetryable returns true if a failure is transient, based on the exit code and the (tail of the) stderr output of the command.
+	// If nil, failures are not retried.
+	IsRetryable RetryableFailureClassifier
+}
+
+// ExitCodeResult is a backend-specific interpretation of a process exit code.
+type ExitCodeResult struct {
+	// Success is true if the exit code does not indicate a failure
+	Success bool
+	// Description is a human-readable description of the exit code
+	Description string
+}
+
+// ExitCodeClassifier returns the backend-specific interpretation of a process exit code.
+type ExitCodeClassifier func(exitCode int) ExitCodeResult
+
+// Execute runs the command, retrying transient failures if a retry policy is specified. When the context is done, the command
+// is interrupted (then killed, after a grace period).
+func (di DirectInvocation) Execute(ctx context.Context) error {
+	return di.execute(ctx, nil)
+}
+
+// ExecuteWithOutput runs the command as Execute does, but returns the standard output of the command rather than printing it.
+// Informational output (the environment, arguments and retries) is written to stderr instead.
+func (di DirectInvocation) ExecuteWithOutput(ctx context.Context) ([]byte, error) {
+	output := &bytes.Buffer{}
+	err := di.execute(ctx, output)
+	return output.Bytes(), err
 }
 
-func (di DirectInvocation) Execute() error {
+// execute runs the command; if output is non-nil, the standard output of the command is written to it (and informational
+// output to stderr), otherwise to stdout.
+func (di DirectInvocation) execute(ctx context.Context, output *bytes.Buffer) error {
 
-	fmt.Println("-------------------------------------------------------------------")
-	fmt.Println("Environment Variables:")
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if di.OutputPrefix != "" {
+		stdoutPW, stderrPW := NewPrefixWriter(os.Stdout, di.OutputPrefix), NewPrefixWriter(os.Stderr, di.OutputPrefix)
+		defer stdoutPW.Flush()
+		defer stderrPW.Flush()
+		stdout, stderr = stdoutPW, stderrPW
+	}
+
+	commandStdout := stdout
+	if output != nil {
+		commandStdout, stdout = output, stderr
+	}
+
+	fmt.Fprintln(stdout, "-------------------------------------------------------------------")
+	fmt.Fprintln(stdout, "Environment Variables:")
 	envList := os.Environ()
 	for k, v := range di.EnvironmentVariables {
-		fmt.Println("-", k+"="+v)
+		// Secret values (such as passwords) are not output
+		fmt.Fprintln(stdout, "-", k+"="+redactEnvironmentVariable(k, v))
 		envList = append(envList, k+"="+v)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(stdout)
 
-	fmt.Println("Command Arguments:")
-	for _, arg := range di.Args {
-		fmt.Println("-", arg)
+	fmt.Fprintln(stdout, "Command Arguments:")
+	for _, arg := range redactArgs(di.Args) {
+		fmt.Fprintln(stdout, "-", arg)
 	}
-	fmt.Println()
+	fmt.Fprintln(stdout)
 
-	if di.Args[1] != "sync" {
-		return fmt.Errorf("arg 2 should be sync")
+	retryPolicy, err := newRetryPolicy(di.Retry)
+	if err != nil {
+		return err
 	}
 
-	srcArg := di.Args[2]
-	if strings.HasPrefix(strings.ToLower(srcArg), "b:") ||
-		strings.HasPrefix(strings.ToLower(srcArg), "m:") {
-		return fmt.Errorf("arg 2 should not be a backup drive")
-	}
+	for attempt := 1; ; attempt++ {
 
-	destArg := di.Args[3]
-	if !strings.HasPrefix(strings.ToLower(destArg), "b:") &&
-		!strings.HasPrefix(strings.ToLower(destArg), "m:") {
-		return fmt.Errorf("arg 3 should be a backup drive")
+		if retryPolicy.attempts > 1 {
+			fmt.Fprintf(stdout, "Attempt %d of %d: %s\n", attempt, retryPolicy.attempts, di.Args[0])
+		}
+
+		stderrTail := &tailBuffer{}
+
+		// Only the output of the final attempt is returned
+		if output != nil {
+			output.Reset()
+		}
+
+		err := di.executeAttempt(ctx, envList, commandStdout, io.MultiWriter(stderr, stderrTail))
+		if err == nil {
+			return nil
+		}
+
+		// Cancelled commands are never retried
+		if ctx.Err() != nil {
+			return err
+		}
+
+		var exitErr *exec.ExitError
+		if di.IsRetryable == nil || !errors.As(err, &exitErr) || !di.IsRetryable(exitErr.ExitCode(), stderrTail.String()) {
+			return err
+		}
+
+		if attempt >= retryPolicy.attempts {
+			if retryPolicy.attempts > 1 {
+				return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+			return err
+		}
+
+		delay := retryPolicy.delay(attempt)
+		fmt.Fprintf(stdout, "Attempt %d of %d failed with a retryable error: %v. Retrying in %v.\n", attempt, retryPolicy.attempts, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("cancelled while waiting to retry: %w (previous attempt: %v)", context.Cause(ctx), err)
+		}
 	}
 
-	cmd := exec.Command(di.Args[0], di.Args[1:]...)
+}
+
+// executeAttempt runs the command once, returning an error if the exit code indicates failure.
+func (di DirectInvocation) executeAttempt(ctx context.Context, envList []string, stdout io.Writer, stderr io.Writer) error {
+
+	cmd := exec.CommandContext(ctx, di.Args[0], di.Args[1:]...)
 	cmd.Env = envList
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	ConfigureGracefulCancellation(cmd)
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error from command execution: %w", err)
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("command cancelled (%v): %w", context.Cause(ctx), err)
+		}
+
+		var exitErr *exec.ExitError
+		if di.ClassifyExitCode == nil || !errors.As(err, &exitErr) {
+			return fmt.Errorf("error from command execution: %w", err)
+		}
+
+		result := di.ClassifyExitCode(exitErr.ExitCode())
+		if !result.Success {
+			return fmt.Errorf("error from command execution: %w (%s)", err, result.Description)
+		}
+
+		fmt.Fprintf(stdout, "%s: exit code %d: %s\n", di.Args[0], exitErr.ExitCode(), result.Description)
 	}
 
 	return nil
-
 }